@@ -0,0 +1,452 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Kinds of public API changes.
+const (
+	apiChangeRemoved = "removed"
+	apiChangeChanged = "changed"
+)
+
+// Visibility bits of TypeDef, Field and MethodDef flags (ECMA-335 II.23.1).
+const (
+	typeVisibilityMask  = 0x7
+	memberAccessMask    = 0x7
+	memberStatic        = 0x10
+	typePublic          = 1
+	typeNestedPublic    = 2
+	typeNestedFamily    = 4
+	typeNestedFamOrAsm  = 7
+	memberFamily        = 4
+	memberFamOrAssembly = 5
+	memberPublic        = 6
+)
+
+// apiSurface maps each public type of an assembly to the signatures of its
+// public and protected members.
+type apiSurface map[string]map[string]bool
+
+// APIChange is a public type or member of the previous release that is
+// missing or different in the package being pushed.
+type APIChange struct {
+	Assembly string `json:"assembly"`
+	Type     string `json:"type,omitempty"`
+	Member   string `json:"member,omitempty"`
+	Change   string `json:"change"`
+}
+
+// String describes the change in one line.
+func (c APIChange) String() string {
+	switch {
+	case c.Type == "":
+		return fmt.Sprintf("%s: %s assembly", c.Assembly, c.Change)
+	case c.Member == "":
+		return fmt.Sprintf("%s: %s type %s", c.Assembly, c.Change, c.Type)
+	default:
+		return fmt.Sprintf("%s: %s %s.%s", c.Assembly, c.Change, c.Type, c.Member)
+	}
+}
+
+// publicAPI lists the visible types of an assembly and their public and
+// protected fields and methods. Properties and events are covered by their
+// accessor methods.
+func (m *metadataTables) publicAPI() (apiSurface, error) {
+	if m.rows[tableFieldPtr] > 0 || m.rows[tableMethodPtr] > 0 {
+		return nil, fmt.Errorf("unoptimized metadata with pointer tables is not supported")
+	}
+	if err := m.readNestedClasses(); err != nil {
+		return nil, err
+	}
+
+	types := make([]typeDef, m.rows[tableTypeDef])
+	for i := range types {
+		td, err := m.typeDef(uint32(i + 1))
+		if err != nil {
+			return nil, err
+		}
+		types[i] = td
+	}
+	visible := func(row uint32) bool {
+		for depth := 0; depth <= maxSignatureDepth; depth++ {
+			switch types[row-1].flags & typeVisibilityMask {
+			case typePublic:
+				return true
+			case typeNestedPublic, typeNestedFamily, typeNestedFamOrAsm:
+				outer, ok := m.enclosing[row]
+				if !ok || outer == 0 || outer > uint32(len(types)) {
+					return false
+				}
+				row = outer
+			default:
+				return false
+			}
+		}
+		return false
+	}
+
+	surface := make(apiSurface)
+	for i, td := range types {
+		row := uint32(i + 1)
+		if !visible(row) {
+			continue
+		}
+		name, err := m.typeDefName(row, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		fieldEnd, methodEnd := m.rows[tableField]+1, m.rows[tableMethodDef]+1
+		if i+1 < len(types) {
+			fieldEnd, methodEnd = types[i+1].fieldList, types[i+1].methodList
+		}
+		members := make(map[string]bool)
+		for f := td.fieldList; f < fieldEnd; f++ {
+			sig, ok, err := m.fieldMember(f)
+			if err != nil {
+				return nil, fmt.Errorf("field %d of %s: %w", f, name, err)
+			}
+			if ok {
+				members[sig] = true
+			}
+		}
+		for md := td.methodList; md < methodEnd; md++ {
+			sig, ok, err := m.methodMember(md)
+			if err != nil {
+				return nil, fmt.Errorf("method %d of %s: %w", md, name, err)
+			}
+			if ok {
+				members[sig] = true
+			}
+		}
+		surface[name] = members
+	}
+	return surface, nil
+}
+
+// visibleMember reports whether member access flags make a member part of
+// the public API.
+func visibleMember(flags uint32) bool {
+	switch flags & memberAccessMask {
+	case memberFamily, memberFamOrAssembly, memberPublic:
+		return true
+	}
+	return false
+}
+
+// fieldMember renders a visible field as "Name: type".
+func (m *metadataTables) fieldMember(i uint32) (string, bool, error) {
+	row, err := m.row(tableField, i)
+	if err != nil {
+		return "", false, err
+	}
+	c := columns{b: row}
+	flags := c.next(2)
+	if !visibleMember(flags) {
+		return "", false, nil
+	}
+	name := m.str(c.next(m.widths.str))
+	blob, err := m.blob(c.next(m.widths.blob))
+	if err != nil {
+		return "", false, err
+	}
+
+	r := &sigReader{m: m, b: blob}
+	if r.byte() != 0x06 {
+		r.fail(fmt.Errorf("invalid field signature"))
+	}
+	sig := staticPrefix(flags) + name + ": " + r.typ()
+	return sig, true, r.err
+}
+
+// methodMember renders a visible method as "Name<arity>(params): ret".
+func (m *metadataTables) methodMember(i uint32) (string, bool, error) {
+	row, err := m.row(tableMethodDef, i)
+	if err != nil {
+		return "", false, err
+	}
+	c := columns{b: row}
+	c.next(4) // RVA
+	c.next(2) // ImplFlags
+	flags := c.next(2)
+	if !visibleMember(flags) {
+		return "", false, nil
+	}
+	name := m.str(c.next(m.widths.str))
+	blob, err := m.blob(c.next(m.widths.blob))
+	if err != nil {
+		return "", false, err
+	}
+
+	r := &sigReader{m: m, b: blob}
+	generic, params, ret := r.methodSig()
+	return staticPrefix(flags) + formatMethod(name, generic, params, ret), true, r.err
+}
+
+// staticPrefix marks static members, since making a member static or
+// instance breaks callers.
+func staticPrefix(flags uint32) string {
+	if flags&memberStatic != 0 {
+		return "static "
+	}
+	return ""
+}
+
+// memberName returns the name of a rendered member, without its signature.
+func memberName(member string) string {
+	member = strings.TrimPrefix(member, "static ")
+	if i := strings.IndexAny(member, "(:<"); i >= 0 {
+		return member[:i]
+	}
+	return member
+}
+
+// compareAPI lists the types and members of old that are missing from cur.
+// A missing member is reported as changed when cur has a new member of the
+// same name, such as an overload with different parameters.
+func compareAPI(assembly string, old, cur apiSurface) []APIChange {
+	var changes []APIChange
+	for _, typ := range sortedSet(old) {
+		curMembers, ok := cur[typ]
+		if !ok {
+			changes = append(changes, APIChange{Assembly: assembly, Type: typ, Change: apiChangeRemoved})
+			continue
+		}
+
+		added := make(map[string]bool)
+		for member := range curMembers {
+			if !old[typ][member] {
+				added[memberName(member)] = true
+			}
+		}
+		for _, member := range sortedSet(old[typ]) {
+			if curMembers[member] {
+				continue
+			}
+			change := apiChangeRemoved
+			if added[memberName(member)] {
+				change = apiChangeChanged
+			}
+			changes = append(changes, APIChange{Assembly: assembly, Type: typ, Member: member, Change: change})
+		}
+	}
+	return changes
+}
+
+// sortedSet returns the keys of a map in order.
+func sortedSet[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// packageAPI reads the public API of every assembly under lib/ and ref/ in a
+// package, keyed by entry path so each target framework is compared separately.
+func packageAPI(packagePath string) (map[string]apiSurface, error) {
+	r, err := zip.OpenReader(packagePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open package: %w", err)
+	}
+	defer func() { _ = r.Close() }()
+
+	apis := make(map[string]apiSurface)
+	for _, f := range r.File {
+		if !isAssemblyEntry(f.Name) || f.UncompressedSize64 > maxAssemblySize {
+			continue
+		}
+		data, err := readZipEntry(f)
+		if err != nil {
+			return nil, err
+		}
+		_, md, err := openAssembly(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", f.Name, err)
+		}
+		if md == nil {
+			continue
+		}
+		if apis[f.Name], err = md.publicAPI(); err != nil {
+			return nil, fmt.Errorf("failed to read the public API of %s: %w", f.Name, err)
+		}
+	}
+	return apis, nil
+}
+
+// comparePackageAPI lists the breaking changes between two packages.
+// Assemblies of the previous release that are no longer shipped for a target
+// framework are reported as removed.
+func comparePackageAPI(previousPath, currentPath string) ([]APIChange, error) {
+	previous, err := packageAPI(previousPath)
+	if err != nil {
+		return nil, fmt.Errorf("previous release: %w", err)
+	}
+	current, err := packageAPI(currentPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []APIChange
+	for _, assembly := range sortedSet(previous) {
+		cur, ok := current[assembly]
+		if !ok {
+			changes = append(changes, APIChange{Assembly: assembly, Change: apiChangeRemoved})
+			continue
+		}
+		changes = append(changes, compareAPI(assembly, previous[assembly], cur)...)
+	}
+	return changes, nil
+}
+
+// apiBaseline returns the latest stable version on the feed below the
+// version being released, or false if there is none.
+func apiBaseline(versions []string, current nugetVersion) (nugetVersion, bool) {
+	var best nugetVersion
+	found := false
+	for _, s := range versions {
+		v, err := parseNuGetVersion(s)
+		if err != nil || len(v.Release) > 0 || v.Compare(current) >= 0 {
+			continue
+		}
+		if !found || v.Compare(best) > 0 {
+			best, found = v, true
+		}
+	}
+	return best, found
+}
+
+// breakingAllowed reports whether SemVer allows breaking changes between two
+// versions: a new major version, or a new minor version before 1.0.
+func breakingAllowed(previous, current nugetVersion) bool {
+	return previous.Major != current.Major || (current.Major == 0 && previous.Minor != current.Minor)
+}
+
+// checkPublicAPI compares the public API of each pending package with the
+// latest stable release on the feed and fails non-major releases that remove
+// or change public API (would-fail in a dry run), returning them. A package
+// whose baseline cannot be downloaded fails as well.
+func (p *NuGetPlugin) checkPublicAPI(ctx context.Context, cfg *Config, results []PackageResult, dryRun bool) []string {
+	if !cfg.CheckPublicAPI {
+		return nil
+	}
+
+	client, baseAddress, err := p.packageBaseAddress(ctx, cfg)
+
+	var breaking []string
+	for i := range results {
+		result := &results[i]
+		if result.Status != StatusNotAttempted || isSymbolsPackage(result.Path) {
+			continue
+		}
+		if err != nil {
+			baselineUnavailable(result, ErrorKindBreakingChange, "public API", err, dryRun)
+			breaking = append(breaking, packageLabel(*result))
+			continue
+		}
+
+		changes, baseline, checkErr := p.publicAPIChanges(ctx, client, baseAddress, result)
+		if checkErr != nil {
+			baselineUnavailable(result, ErrorKindBreakingChange, "public API", checkErr, dryRun)
+			breaking = append(breaking, packageLabel(*result))
+			continue
+		}
+		result.APIBaseline = baseline
+		result.BreakingChanges = changes
+		if len(changes) == 0 {
+			continue
+		}
+
+		descriptions := make([]string, 0, len(changes))
+		for _, c := range changes {
+			descriptions = append(descriptions, c.String())
+		}
+		msg := fmt.Sprintf("breaking public API changes since %s: %s", baseline, strings.Join(descriptions, "; "))
+		result.Status = StatusFailed
+		if dryRun {
+			result.Status = StatusWouldFail
+		}
+		result.setError(ErrorKindBreakingChange, msg)
+		breaking = append(breaking, packageLabel(*result))
+	}
+	return breaking
+}
+
+// baselineUnavailable fails a package whose previous release could not be
+// fetched for comparison: a check that cannot run must not pass silently.
+func baselineUnavailable(result *PackageResult, kind PushErrorKind, check string, err error, dryRun bool) {
+	result.Status = StatusFailed
+	if dryRun {
+		result.Status = StatusWouldFail
+	}
+	result.setError(kind, fmt.Sprintf("%s check could not compare with the previous release: %v", check, err))
+}
+
+// publicAPIChanges downloads the baseline release of a package and compares
+// its public API. It returns no changes when there is no baseline or the
+// release is allowed to break API.
+func (p *NuGetPlugin) publicAPIChanges(ctx context.Context, client *feedClient, baseAddress string, result *PackageResult) ([]APIChange, string, error) {
+	dir, err := os.MkdirTemp("", "nuget-api-*")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	path, baseline, err := downloadBaseline(ctx, client, baseAddress, result, dir)
+	if err != nil || path == "" {
+		return nil, "", err
+	}
+	changes, err := comparePackageAPI(path, result.Path)
+	return changes, baseline, err
+}
+
+// packageBaseAddress resolves the feed's PackageBaseAddress resource.
+func (p *NuGetPlugin) packageBaseAddress(ctx context.Context, cfg *Config) (*feedClient, string, error) {
+	client := p.newFeedClient(cfg)
+	index, err := client.serviceIndex(ctx)
+	if err != nil {
+		return client, "", err
+	}
+	baseAddress := index.resourceURL(resourcePackageBaseAddress)
+	if baseAddress == "" {
+		return client, "", fmt.Errorf("feed does not advertise a PackageBaseAddress resource")
+	}
+	return client, baseAddress, nil
+}
+
+// downloadBaseline downloads the apiBaseline release of a package into dir and
+// returns its path and version. It returns an empty path when the package has
+// no earlier stable release or the release is a major version bump.
+func downloadBaseline(ctx context.Context, client *feedClient, baseAddress string, result *PackageResult, dir string) (string, string, error) {
+	current, err := parseNuGetVersion(result.Version)
+	if err != nil || result.ID == "" {
+		return "", "", fmt.Errorf("package identity is unknown")
+	}
+	versions, err := client.packageVersions(ctx, baseAddress, result.ID)
+	if errors.Is(err, errPackageNotFound) {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to list versions of %s: %w", result.ID, err)
+	}
+	baseline, ok := apiBaseline(versions, current)
+	if !ok || breakingAllowed(baseline, current) {
+		return "", "", nil
+	}
+
+	id := packageIdentity{ID: result.ID, Version: baseline.String()}
+	path := filepath.Join(dir, filepath.Base(result.ID+"."+id.Version+".nupkg"))
+	if _, err := downloadWithHash(ctx, client, baseAddress, id, path); err != nil {
+		return "", "", fmt.Errorf("failed to download %s: %w", id, err)
+	}
+	return path, id.Version, nil
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// Signatures shared by the public API tests.
+var (
+	sigFieldInt    = []byte{0x06, 0x08}
+	sigFieldLong   = []byte{0x06, 0x0A}
+	sigFieldString = []byte{0x06, 0x0E}
+	sigVoid        = []byte{0x20, 0x00, 0x01}
+	sigRenderInt   = []byte{0x20, 0x01, 0x0E, 0x08}
+	sigRenderFlag  = []byte{0x20, 0x02, 0x0E, 0x08, 0x02}
+	// Load(List<string>): Uri, referencing TypeRefs 1 and 2.
+	sigLoad = []byte{0x20, 0x01, 0x12, 0x05, 0x15, 0x12, 0x09, 0x01, 0x0E}
+	// static Create<T>(T): T
+	sigCreate = []byte{0x10, 0x01, 0x01, 0x1E, 0x00, 0x1E, 0x00}
+)
+
+// widgetAssembly builds the Contoso.Core test assembly. Changes to the
+// Widget type are passed as its fields and methods.
+func widgetAssembly(t *testing.T, fields, methods []testMember) []byte {
+	t.Helper()
+	return buildTestAssembly(t, testAssembly{
+		Version:  [4]uint16{1, 0, 0, 0},
+		TypeRefs: []string{"System.Uri", "System.Collections.Generic.List`1"},
+		Types: []testType{
+			{Namespace: "Contoso.Core", Name: "Widget", Flags: typePublic, Fields: fields, Methods: methods},
+			{Namespace: "Contoso.Core", Name: "Cache", Methods: []testMember{{Name: "Clear", Flags: memberPublic, Sig: sigVoid}}},
+			{Name: "Options", Flags: typeNestedPublic, Enclosing: 1, Fields: []testMember{{Name: "Name", Flags: memberPublic, Sig: sigFieldString}}},
+			{Name: "<>c", Flags: 3, Enclosing: 1, Methods: []testMember{{Name: "Run", Flags: memberPublic, Sig: sigVoid}}},
+		},
+	})
+}
+
+func TestPublicAPI(t *testing.T) {
+	dll := widgetAssembly(t,
+		[]testMember{{Name: "Count", Flags: memberPublic, Sig: sigFieldInt}, {Name: "_cache", Flags: 1, Sig: sigFieldInt}},
+		[]testMember{
+			{Name: "Render", Flags: memberPublic, Sig: sigRenderInt},
+			{Name: "Load", Flags: memberPublic, Sig: sigLoad},
+			{Name: "OnChanged", Flags: memberFamily, Sig: sigVoid},
+			{Name: "Helper", Flags: 1, Sig: sigVoid},
+			{Name: "Create", Flags: memberPublic | memberStatic, Sig: sigCreate},
+		})
+
+	_, md, err := openAssembly(dll)
+	if err != nil || md == nil {
+		t.Fatalf("failed to open assembly: %v", err)
+	}
+	got, err := md.publicAPI()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := apiSurface{
+		"Contoso.Core.Widget": {
+			"Count: int":          true,
+			"Render(int): string": true,
+			"Load(System.Collections.Generic.List<string>): System.Uri": true,
+			"OnChanged(): void":          true,
+			"static Create<1>(!!0): !!0": true,
+		},
+		"Contoso.Core.Widget+Options": {"Name: string": true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("publicAPI() = %v, want %v", got, want)
+	}
+}
+
+func TestCompareAPI(t *testing.T) {
+	old := apiSurface{
+		"Contoso.Core.Widget": {"Count: int": true, "Render(int): string": true, "Reset(): void": true, "OnChanged(): void": true},
+		"Contoso.Core.Legacy": {"Run(): void": true},
+	}
+	cur := apiSurface{
+		"Contoso.Core.Widget": {"Count: long": true, "Render(int, bool): string": true, "OnChanged(): void": true, "Added(): void": true},
+		"Contoso.Core.Fresh":  {},
+	}
+
+	var got []string
+	for _, c := range compareAPI("lib/net8.0/Contoso.Core.dll", old, cur) {
+		got = append(got, c.String())
+	}
+	want := []string{
+		"lib/net8.0/Contoso.Core.dll: removed type Contoso.Core.Legacy",
+		"lib/net8.0/Contoso.Core.dll: changed Contoso.Core.Widget.Count: int",
+		"lib/net8.0/Contoso.Core.dll: changed Contoso.Core.Widget.Render(int): string",
+		"lib/net8.0/Contoso.Core.dll: removed Contoso.Core.Widget.Reset(): void",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected changes:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestAPIBaseline(t *testing.T) {
+	versions := []string{"0.9.0", "1.0.0", "1.1.0", "1.2.0-beta.1", "1.2.0", "2.0.0-rc.1", "not-a-version"}
+	tests := []struct {
+		current     string
+		want        string
+		wantAllowed bool
+	}{
+		{current: "1.2.1", want: "1.2.0"},
+		{current: "1.2.0", want: "1.1.0"},
+		{current: "1.2.0-beta.2", want: "1.1.0"},
+		{current: "2.0.0", want: "1.2.0", wantAllowed: true},
+		{current: "1.0.0", want: "0.9.0", wantAllowed: true},
+		{current: "0.9.0", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.current, func(t *testing.T) {
+			current, _ := parseNuGetVersion(tt.current)
+			baseline, ok := apiBaseline(versions, current)
+			if got := map[bool]string{true: baseline.String()}[ok]; got != tt.want {
+				t.Fatalf("apiBaseline() = %q, want %q", got, tt.want)
+			}
+			if ok && breakingAllowed(baseline, current) != tt.wantAllowed {
+				t.Errorf("breakingAllowed(%s, %s) = %v, want %v", baseline, current, !tt.wantAllowed, tt.wantAllowed)
+			}
+		})
+	}
+}
+
+func TestExecutePublicAPICheck(t *testing.T) {
+	fields := []testMember{{Name: "Count", Flags: memberPublic, Sig: sigFieldInt}}
+	methods := []testMember{{Name: "Render", Flags: memberPublic, Sig: sigRenderInt}, {Name: "Reset", Flags: memberPublic, Sig: sigVoid}}
+	published, err := os.ReadFile(writeTestPackage(t, t.TempDir(), "Contoso.Core", "1.1.0", map[string]string{
+		"lib/net8.0/Contoso.Core.dll":         string(widgetAssembly(t, fields, methods)),
+		"lib/netstandard2.0/Contoso.Core.dll": string(widgetAssembly(t, fields, methods)),
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	compatible := widgetAssembly(t, fields, append(methods, testMember{Name: "Added", Flags: memberPublic, Sig: sigVoid}))
+	breaking := widgetAssembly(t, []testMember{{Name: "Count", Flags: memberPublic, Sig: sigFieldLong}}, []testMember{{Name: "Render", Flags: memberPublic, Sig: sigRenderFlag}})
+
+	tests := []struct {
+		name      string
+		version   string
+		dll       []byte
+		disabled  bool
+		dropTFM   bool
+		missing   bool
+		wantErr   string
+		wantDiffs []string
+	}{
+		{
+			name:    "minor release breaks API",
+			version: "1.2.0",
+			dll:     breaking,
+			dropTFM: true,
+			wantErr: "public API check failed for: Contoso.Core 1.2.0",
+			wantDiffs: []string{
+				"lib/net8.0/Contoso.Core.dll: changed Contoso.Core.Widget.Count: int",
+				"lib/net8.0/Contoso.Core.dll: changed Contoso.Core.Widget.Render(int): string",
+				"lib/net8.0/Contoso.Core.dll: removed Contoso.Core.Widget.Reset(): void",
+				"lib/netstandard2.0/Contoso.Core.dll: removed assembly",
+			},
+		},
+		{name: "major release may break API", version: "2.0.0", dll: breaking},
+		{name: "compatible minor release", version: "1.2.0", dll: compatible},
+		{name: "check disabled", version: "1.2.0", dll: breaking, disabled: true},
+		{name: "baseline unavailable", version: "1.2.0", dll: compatible, missing: true, wantErr: "public API check failed for: Contoso.Core 1.2.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feed := newTestFeed(t)
+			feed.AddVersion("Contoso.Core", "1.0.0")
+			if tt.missing {
+				feed.AddVersion("Contoso.Core", "1.1.0")
+			} else {
+				feed.AddPackage("Contoso.Core", "1.1.0", published)
+			}
+			files := map[string]string{"lib/net8.0/Contoso.Core.dll": string(tt.dll)}
+			if !tt.dropTFM {
+				files["lib/netstandard2.0/Contoso.Core.dll"] = string(tt.dll)
+			}
+			pkg := writeTestPackage(t, t.TempDir(), "Contoso.Core", tt.version, files)
+
+			mockExec := &MockCommandExecutor{}
+			p := &NuGetPlugin{cmdExecutor: mockExec, httpClient: feed.server.Client()}
+			resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+				Hook: plugin.HookPostPublish,
+				Config: map[string]any{
+					"api_key":          "test-key",
+					"source":           feed.SourceURL(),
+					"package_path":     pkg,
+					"check_public_api": !tt.disabled,
+				},
+				Context: plugin.ReleaseContext{Version: "v" + tt.version},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tt.wantErr == "" {
+				if !resp.Success || len(mockExec.Calls) != 1 {
+					t.Errorf("expected the push to proceed, got success=%v error=%s", resp.Success, resp.Error)
+				}
+				return
+			}
+			if resp.Success || !strings.Contains(resp.Error, tt.wantErr) {
+				t.Fatalf("expected error containing %q, got success=%v error=%s", tt.wantErr, resp.Success, resp.Error)
+			}
+			if len(mockExec.Calls) != 0 {
+				t.Errorf("expected nothing to be pushed, got %d pushes", len(mockExec.Calls))
+			}
+			result := resp.Outputs["packages"].([]PackageResult)[0]
+			if tt.missing {
+				if result.ErrorClass != string(ErrorKindBreakingChange) || !strings.Contains(result.Error, "could not compare with the previous release") {
+					t.Errorf("unexpected result: %+v", result)
+				}
+				return
+			}
+			var got []string
+			for _, c := range result.BreakingChanges {
+				got = append(got, c.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.wantDiffs, "\n") || result.APIBaseline != "1.1.0" || result.ErrorClass != string(ErrorKindBreakingChange) {
+				t.Errorf("unexpected report against %s (%s):\n%s", result.APIBaseline, result.ErrorClass, strings.Join(got, "\n"))
+			}
+		})
+	}
+}
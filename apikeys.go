@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// APIKeyMapping selects the API key for packages whose ID matches a glob pattern.
+// The key itself is read from the named environment variable so it never appears in config.
+type APIKeyMapping struct {
+	// Pattern is a package ID glob such as "Contoso.*"; matching is case-insensitive.
+	Pattern string
+	// KeyEnv is the environment variable holding the API key.
+	KeyEnv string
+}
+
+// parseAPIKeyMappings reads the api_keys config list.
+// Malformed entries are returned as problems for validation.
+func parseAPIKeyMappings(raw any) ([]APIKeyMapping, []string) {
+	if raw == nil {
+		return nil, nil
+	}
+	list, ok := raw.([]any)
+	if !ok {
+		return nil, []string{"must be a list of {pattern, key_env} entries"}
+	}
+
+	var mappings []APIKeyMapping
+	var problems []string
+	for i, item := range list {
+		entry, ok := item.(map[string]any)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("entry %d must be an object with pattern and key_env", i))
+			continue
+		}
+		pattern, _ := entry["pattern"].(string)
+		keyEnv, _ := entry["key_env"].(string)
+		if strings.TrimSpace(pattern) == "" {
+			problems = append(problems, fmt.Sprintf("entry %d is missing pattern", i))
+		}
+		if strings.TrimSpace(keyEnv) == "" {
+			problems = append(problems, fmt.Sprintf("entry %d is missing key_env", i))
+		}
+		mappings = append(mappings, APIKeyMapping{Pattern: strings.TrimSpace(pattern), KeyEnv: strings.TrimSpace(keyEnv)})
+	}
+	return mappings, problems
+}
+
+// matchPackageID reports whether a package ID matches a glob pattern in which
+// '*' stands for any run of characters, as in nuget.org key scopes.
+func matchPackageID(pattern, id string) bool {
+	pattern, id = strings.ToLower(pattern), strings.ToLower(id)
+
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == id
+	}
+	if !strings.HasPrefix(id, parts[0]) {
+		return false
+	}
+	id = id[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(id, part)
+		if i < 0 {
+			return false
+		}
+		id = id[i+len(part):]
+	}
+	return strings.HasSuffix(id, parts[len(parts)-1])
+}
+
+// patternSpecificity ranks patterns so the narrowest match wins: an exact ID
+// beats any glob, and among globs more literal characters win.
+func patternSpecificity(pattern string) int {
+	literal := len(strings.ReplaceAll(pattern, "*", ""))
+	if !strings.Contains(pattern, "*") {
+		return literal + 1<<16
+	}
+	return literal
+}
+
+// apiKeyFor returns the API key for a package ID and the pattern that selected it.
+// Without api_keys every package uses api_key.
+func (c *Config) apiKeyFor(id string) (key, pattern string, err error) {
+	if len(c.APIKeys) == 0 {
+		return c.APIKey, "", nil
+	}
+	if id == "" {
+		return "", "", fmt.Errorf("package identity is unknown, so no api_keys entry can be matched")
+	}
+
+	var matches []APIKeyMapping
+	for _, m := range c.APIKeys {
+		if matchPackageID(m.Pattern, id) {
+			matches = append(matches, m)
+		}
+	}
+	if len(matches) == 0 {
+		return "", "", fmt.Errorf("no api_keys entry matches package %s", id)
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return patternSpecificity(matches[i].Pattern) > patternSpecificity(matches[j].Pattern)
+	})
+
+	best := matches[0]
+	key = os.Getenv(best.KeyEnv)
+	if key == "" {
+		return "", best.Pattern, fmt.Errorf("environment variable %s for api_keys pattern %q is not set", best.KeyEnv, best.Pattern)
+	}
+	return key, best.Pattern, nil
+}
+
+// assignAPIKeys selects the API key for every pending package. Packages no key
+// covers are marked failed (or would-fail in a dry run); their labels are returned.
+func assignAPIKeys(cfg *Config, results []PackageResult, dryRun bool) []string {
+	var uncovered []string
+	for i := range results {
+		result := &results[i]
+		if result.Status != StatusNotAttempted {
+			continue
+		}
+		key, pattern, err := cfg.apiKeyFor(result.ID)
+		result.KeyPattern = pattern
+		if err != nil {
+			result.Status = StatusFailed
+			if dryRun {
+				result.Status = StatusWouldFail
+			}
+			result.setError(ErrorKindAuth, err.Error())
+			uncovered = append(uncovered, packageLabel(*result))
+			continue
+		}
+		result.apiKey = key
+	}
+	return uncovered
+}
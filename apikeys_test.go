@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestMatchPackageID(t *testing.T) {
+	tests := []struct {
+		pattern string
+		id      string
+		want    bool
+	}{
+		{pattern: "Contoso.*", id: "Contoso.Core", want: true},
+		{pattern: "contoso.*", id: "Contoso.Core", want: true},
+		{pattern: "Contoso.*", id: "Contoso", want: false},
+		{pattern: "Contoso.Core", id: "Contoso.Core", want: true},
+		{pattern: "Contoso.Core", id: "Contoso.Core.Tests", want: false},
+		{pattern: "*.Tools", id: "Fabrikam.Tools", want: true},
+		{pattern: "Contoso.*.Abstractions", id: "Contoso.Data.Abstractions", want: true},
+		{pattern: "Contoso.*.Abstractions", id: "Contoso.Data", want: false},
+		{pattern: "*", id: "Anything", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.id, func(t *testing.T) {
+			if got := matchPackageID(tt.pattern, tt.id); got != tt.want {
+				t.Errorf("matchPackageID(%q, %q) = %v, want %v", tt.pattern, tt.id, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIKeyFor(t *testing.T) {
+	t.Setenv("KEY_ALL", "all")
+	t.Setenv("KEY_CONTOSO", "contoso")
+	t.Setenv("KEY_CORE", "core")
+
+	cfg := &Config{APIKeys: []APIKeyMapping{
+		{Pattern: "*", KeyEnv: "KEY_ALL"},
+		{Pattern: "Contoso.*", KeyEnv: "KEY_CONTOSO"},
+		{Pattern: "Contoso.Core", KeyEnv: "KEY_CORE"},
+		{Pattern: "Fabrikam.*", KeyEnv: "KEY_UNSET"},
+	}}
+
+	tests := []struct {
+		id      string
+		wantKey string
+		wantErr string
+	}{
+		{id: "Contoso.Core", wantKey: "core"},
+		{id: "Contoso.Data", wantKey: "contoso"},
+		{id: "Other.Package", wantKey: "all"},
+		{id: "Fabrikam.Tools", wantErr: "KEY_UNSET"},
+		{id: "", wantErr: "identity is unknown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			key, _, err := cfg.apiKeyFor(tt.id)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if key != tt.wantKey {
+				t.Errorf("expected key %q, got %q", tt.wantKey, key)
+			}
+		})
+	}
+}
+
+func TestExecutePerPackageAPIKeys(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTestPackage(t, tmpDir, "Contoso.Core", "1.0.0", nil)
+	writeTestPackage(t, tmpDir, "Contoso.Data", "1.0.0", nil)
+	feed := newTestFeed(t)
+
+	t.Setenv("CONTOSO_KEY", "contoso-key")
+	t.Setenv("CORE_KEY", "core-key")
+
+	run := func(mappings []any) (*plugin.ExecuteResponse, *MockCommandExecutor) {
+		t.Helper()
+		mockExec := &MockCommandExecutor{}
+		p := &NuGetPlugin{cmdExecutor: mockExec, httpClient: feed.server.Client()}
+		resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+			Hook: plugin.HookPostPublish,
+			Config: map[string]any{
+				"source":       feed.SourceURL(),
+				"package_path": filepath.Join(tmpDir, "*.nupkg"),
+				"api_keys":     mappings,
+			},
+			Context: plugin.ReleaseContext{Version: "v1.0.0"},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return resp, mockExec
+	}
+
+	resp, mockExec := run([]any{
+		map[string]any{"pattern": "Contoso.*", "key_env": "CONTOSO_KEY"},
+		map[string]any{"pattern": "Contoso.Core", "key_env": "CORE_KEY"},
+	})
+	if !resp.Success {
+		t.Fatalf("expected success, got: %s", resp.Error)
+	}
+	keys := map[string]string{}
+	for _, call := range mockExec.Calls {
+		keys[filepath.Base(call.Args[2])] = call.Args[4]
+	}
+	if keys["Contoso.Core.1.0.0.nupkg"] != "core-key" || keys["Contoso.Data.1.0.0.nupkg"] != "contoso-key" {
+		t.Errorf("expected each package to use its narrowest key, got %v", keys)
+	}
+
+	resp, mockExec = run([]any{
+		map[string]any{"pattern": "Contoso.Core", "key_env": "CORE_KEY"},
+	})
+	if resp.Success {
+		t.Fatal("expected failure when a package has no matching key")
+	}
+	if !strings.Contains(resp.Error, "Contoso.Data") {
+		t.Errorf("expected error to name the uncovered package, got: %s", resp.Error)
+	}
+	if len(mockExec.Calls) != 0 {
+		t.Errorf("expected nothing to be pushed, got %d pushes", len(mockExec.Calls))
+	}
+}
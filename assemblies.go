@@ -0,0 +1,234 @@
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"strings"
+)
+
+// Rules comparing an assembly's versions with the package version.
+const (
+	// AssemblyRuleExact requires the same numeric version and prerelease label;
+	// build metadata is ignored.
+	AssemblyRuleExact = "exact"
+	// AssemblyRuleNumeric requires the same major.minor.patch.
+	AssemblyRuleNumeric = "numeric"
+	// AssemblyRuleMajorMinor requires the same major.minor.
+	AssemblyRuleMajorMinor = "major_minor"
+	// AssemblyRuleMajor requires the same major version.
+	AssemblyRuleMajor = "major"
+	// AssemblyRuleIgnore skips the comparison.
+	AssemblyRuleIgnore = "ignore"
+)
+
+// Assembly versions checked against the package version, by their
+// assembly_version_rules key.
+const (
+	assemblyAttrInformational = "informational"
+	assemblyAttrFile          = "file"
+	assemblyAttrAssembly      = "assembly"
+)
+
+// maxAssemblySize bounds how large an assembly is read for its metadata.
+const maxAssemblySize = 256 << 20
+
+// assemblyAttributeNames are the attribute names reported for each version.
+var assemblyAttributeNames = map[string]string{
+	assemblyAttrInformational: "AssemblyInformationalVersion",
+	assemblyAttrFile:          "AssemblyFileVersion",
+	assemblyAttrAssembly:      "AssemblyVersion",
+}
+
+// defaultAssemblyVersionRules returns the rules used when none are configured.
+// AssemblyVersion is often pinned to major.0.0.0 for strong-named libraries,
+// so only its major version is compared.
+func defaultAssemblyVersionRules() map[string]string {
+	return map[string]string{
+		assemblyAttrInformational: AssemblyRuleExact,
+		assemblyAttrFile:          AssemblyRuleNumeric,
+		assemblyAttrAssembly:      AssemblyRuleMajor,
+	}
+}
+
+// parseAssemblyVersionRules merges the assembly_version_rules map over the
+// defaults, returning unknown keys and rules as problems.
+func parseAssemblyVersionRules(raw map[string]any) (map[string]string, []string) {
+	rules := defaultAssemblyVersionRules()
+	var problems []string
+	for _, name := range sortedPhaseNames(raw) {
+		if _, known := rules[name]; !known {
+			problems = append(problems, fmt.Sprintf("unknown version %q (use informational, file or assembly)", name))
+			continue
+		}
+		rule, _ := raw[name].(string)
+		switch rule {
+		case AssemblyRuleExact, AssemblyRuleNumeric, AssemblyRuleMajorMinor, AssemblyRuleMajor, AssemblyRuleIgnore:
+			rules[name] = rule
+		default:
+			problems = append(problems, fmt.Sprintf("%s rule must be one of exact, numeric, major_minor, major or ignore", name))
+		}
+	}
+	return rules, problems
+}
+
+// AssemblyMismatch is an assembly version that disagrees with the package version.
+type AssemblyMismatch struct {
+	File      string `json:"file"`
+	Attribute string `json:"attribute"`
+	Found     string `json:"found"`
+	Expected  string `json:"expected"`
+	Rule      string `json:"rule"`
+}
+
+// String describes the mismatch in one line.
+func (m AssemblyMismatch) String() string {
+	return fmt.Sprintf("%s has %s %s, expected %s (%s)", m.File, m.Attribute, m.Found, m.Expected, m.Rule)
+}
+
+// assemblyVersionMatches reports whether an assembly version satisfies a rule
+// for the package version.
+func assemblyVersionMatches(rule, found string, pkg nugetVersion) bool {
+	if rule == AssemblyRuleIgnore {
+		return true
+	}
+	v, err := parseNuGetVersion(found)
+	if err != nil {
+		return false
+	}
+	switch rule {
+	case AssemblyRuleExact:
+		return v.Major == pkg.Major && v.Minor == pkg.Minor && v.Patch == pkg.Patch && v.Revision == pkg.Revision &&
+			strings.EqualFold(strings.Join(v.Release, "."), strings.Join(pkg.Release, "."))
+	case AssemblyRuleNumeric:
+		return v.Major == pkg.Major && v.Minor == pkg.Minor && v.Patch == pkg.Patch
+	case AssemblyRuleMajorMinor:
+		return v.Major == pkg.Major && v.Minor == pkg.Minor
+	default:
+		return v.Major == pkg.Major
+	}
+}
+
+// expectedAssemblyVersion describes the versions a rule accepts.
+func expectedAssemblyVersion(rule string, pkg nugetVersion) string {
+	switch rule {
+	case AssemblyRuleExact:
+		return pkg.String()
+	case AssemblyRuleNumeric:
+		return fmt.Sprintf("%d.%d.%d", pkg.Major, pkg.Minor, pkg.Patch)
+	case AssemblyRuleMajorMinor:
+		return fmt.Sprintf("%d.%d.x", pkg.Major, pkg.Minor)
+	default:
+		return fmt.Sprintf("%d.x", pkg.Major)
+	}
+}
+
+// isAssemblyEntry reports whether a package entry is an assembly under lib/ or ref/.
+func isAssemblyEntry(name string) bool {
+	lower := strings.ToLower(name)
+	return (strings.HasPrefix(lower, "lib/") || strings.HasPrefix(lower, "ref/")) &&
+		(strings.HasSuffix(lower, ".dll") || strings.HasSuffix(lower, ".exe"))
+}
+
+// assemblyMismatches reads the assemblies of a package and compares their
+// versions with the package version. Native images are skipped.
+func assemblyMismatches(packagePath string, pkg nugetVersion, rules map[string]string) ([]AssemblyMismatch, error) {
+	r, err := zip.OpenReader(packagePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open package: %w", err)
+	}
+	defer func() { _ = r.Close() }()
+
+	var mismatches []AssemblyMismatch
+	for _, f := range r.File {
+		if !isAssemblyEntry(f.Name) || f.UncompressedSize64 > maxAssemblySize {
+			continue
+		}
+		info, managed, err := readZipAssembly(f)
+		if err != nil {
+			return nil, err
+		}
+		if !managed {
+			continue
+		}
+
+		versions := map[string]string{
+			assemblyAttrInformational: info.InformationalVersion,
+			assemblyAttrFile:          info.FileVersion,
+			assemblyAttrAssembly:      info.AssemblyVersion,
+		}
+		for _, attr := range []string{assemblyAttrInformational, assemblyAttrFile, assemblyAttrAssembly} {
+			found, rule := versions[attr], rules[attr]
+			if found == "" || assemblyVersionMatches(rule, found, pkg) {
+				continue
+			}
+			mismatches = append(mismatches, AssemblyMismatch{
+				File:      f.Name,
+				Attribute: assemblyAttributeNames[attr],
+				Found:     found,
+				Expected:  expectedAssemblyVersion(rule, pkg),
+				Rule:      rule,
+			})
+		}
+	}
+	return mismatches, nil
+}
+
+// readZipAssembly reads the versions of an assembly inside a package.
+func readZipAssembly(f *zip.File) (assemblyInfo, bool, error) {
+	data, err := readZipEntry(f)
+	if err != nil {
+		return assemblyInfo{}, false, err
+	}
+	info, managed, err := readAssemblyInfo(data)
+	if err != nil {
+		return info, managed, fmt.Errorf("failed to read %s: %w", f.Name, err)
+	}
+	return info, managed, nil
+}
+
+// checkAssemblyVersions compares the assemblies in every pending package with
+// the package version and fails packages with mismatches (would-fail in a dry
+// run), returning them.
+func checkAssemblyVersions(cfg *Config, results []PackageResult, dryRun bool) []string {
+	if !cfg.CheckAssemblyVersions {
+		return nil
+	}
+	rules := cfg.AssemblyVersionRules
+	if rules == nil {
+		rules = defaultAssemblyVersionRules()
+	}
+
+	var mismatched []string
+	for i := range results {
+		result := &results[i]
+		if result.Status != StatusNotAttempted || isSymbolsPackage(result.Path) {
+			continue
+		}
+		pkg, err := parseNuGetVersion(result.Version)
+		if err != nil {
+			continue
+		}
+		mismatches, err := assemblyMismatches(result.Path, pkg, rules)
+		if err != nil {
+			result.Message = joinReasons(result.Message, "assembly version check skipped: "+err.Error())
+			continue
+		}
+		result.AssemblyMismatches = mismatches
+		if len(mismatches) == 0 {
+			continue
+		}
+
+		descriptions := make([]string, 0, len(mismatches))
+		for _, m := range mismatches {
+			descriptions = append(descriptions, m.String())
+		}
+		msg := "assembly versions do not match the package version: " + strings.Join(descriptions, "; ")
+		result.Status = StatusFailed
+		if dryRun {
+			result.Status = StatusWouldFail
+		}
+		result.setError(ErrorKindAssemblyVersion, msg)
+		mismatched = append(mismatched, packageLabel(*result))
+	}
+	return mismatched
+}
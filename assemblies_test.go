@@ -0,0 +1,390 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"debug/pe"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// testAssembly describes a minimal .NET assembly built by buildTestAssembly.
+type testAssembly struct {
+	Version              [4]uint16
+	FileVersion          string
+	InformationalVersion string
+	// TypeRefs are referenced types as namespace-qualified names; TypeRef n
+	// (1-based) is encoded in signatures as the token n<<2|1.
+	TypeRefs []string
+	Types    []testType
+	// Native leaves out the CLI header, as in a native image.
+	Native bool
+}
+
+// testType is a TypeDef row with its fields and methods.
+type testType struct {
+	Namespace string
+	Name      string
+	Flags     uint32
+	// Enclosing is the 1-based index in Types of the enclosing type.
+	Enclosing int
+	Fields    []testMember
+	Methods   []testMember
+}
+
+// testMember is a field or method with its flags and raw signature blob.
+type testMember struct {
+	Name  string
+	Flags uint16
+	Sig   []byte
+}
+
+// buildTestAssembly writes a PE32 image with a single section holding the CLI
+// header, metadata tables and heaps, and a version resource.
+func buildTestAssembly(t *testing.T, a testAssembly) []byte {
+	t.Helper()
+
+	const (
+		sectionRVA    = 0x2000
+		sectionOffset = 0x200
+		metadataOff   = 0x50
+	)
+	write := func(b *bytes.Buffer, vs ...any) {
+		for _, v := range vs {
+			if err := binary.Write(b, binary.LittleEndian, v); err != nil {
+				t.Fatalf("failed to write: %v", err)
+			}
+		}
+	}
+
+	// Heaps
+	strs := []byte{0}
+	addString := func(s string) uint16 {
+		if s == "" {
+			return 0
+		}
+		strs = append(append(strs, s...), 0)
+		return uint16(len(strs) - len(s) - 1)
+	}
+	blobs := []byte{0}
+	addBlob := func(b []byte) uint16 {
+		blobs = append(append(blobs, byte(len(b))), b...)
+		return uint16(len(blobs) - len(b) - 1)
+	}
+
+	// Tables: Module, TypeRef, TypeDef, Field, MethodDef, Assembly, NestedClass
+	var typeRefs, typeDefs, fields, methods, nested bytes.Buffer
+	for _, name := range a.TypeRefs {
+		ns, n := "", name
+		if i := strings.LastIndexByte(name, '.'); i >= 0 {
+			ns, n = name[:i], name[i+1:]
+		}
+		write(&typeRefs, uint16(0), addString(n), addString(ns))
+	}
+	write(&typeDefs, uint32(0), addString("<Module>"), uint16(0), uint16(0), uint16(1), uint16(1))
+	fieldCount, methodCount := 0, 0
+	for i, typ := range a.Types {
+		write(&typeDefs, typ.Flags, addString(typ.Name), addString(typ.Namespace), uint16(0), uint16(fieldCount+1), uint16(methodCount+1))
+		for _, f := range typ.Fields {
+			write(&fields, f.Flags, addString(f.Name), addBlob(f.Sig))
+			fieldCount++
+		}
+		for _, m := range typ.Methods {
+			write(&methods, uint32(0), uint16(0), m.Flags, addString(m.Name), addBlob(m.Sig), uint16(1))
+			methodCount++
+		}
+		if typ.Enclosing > 0 {
+			// TypeDef rows are offset by the <Module> type.
+			write(&nested, uint16(i+2), uint16(typ.Enclosing+1))
+		}
+	}
+
+	var tables bytes.Buffer
+	write(&tables, uint32(0), uint8(2), uint8(0), uint8(0), uint8(1))
+	write(&tables, uint64(1<<tableTypeRef|1<<tableTypeDef|1<<tableField|1<<tableMethodDef|1<<tableAssembly|1<<tableNestedClass|1), uint64(0))
+	write(&tables, uint32(1), uint32(len(a.TypeRefs)), uint32(len(a.Types)+1), uint32(fieldCount), uint32(methodCount), uint32(1), uint32(nested.Len()/4))
+	tables.Write(make([]byte, 10)) // Module
+	for _, b := range []*bytes.Buffer{&typeRefs, &typeDefs, &fields, &methods} {
+		tables.Write(b.Bytes())
+	}
+	write(&tables, uint32(0x8004), a.Version, uint32(0), uint16(0), addString("Contoso"), uint16(0))
+	tables.Write(nested.Bytes())
+
+	// Metadata root with the #~, #Strings and #Blob streams
+	streams := []struct {
+		name string
+		data []byte
+	}{{"#~", tables.Bytes()}, {"#Strings", strs}, {"#Blob", blobs}}
+	var md bytes.Buffer
+	write(&md, uint32(metadataSignature), uint16(1), uint16(1), uint32(0), uint32(12))
+	md.WriteString("v4.0.30319\x00\x00")
+	write(&md, uint16(0), uint16(len(streams)))
+	offset := 32 + 12 + 20 + 16
+	for _, stream := range streams {
+		write(&md, uint32(offset), uint32(len(stream.data)))
+		name := []byte(stream.name + "\x00")
+		for len(name)%4 != 0 {
+			name = append(name, 0)
+		}
+		md.Write(name)
+		offset += align4(len(stream.data))
+	}
+	for _, stream := range streams {
+		md.Write(stream.data)
+		md.Write(make([]byte, align4(len(stream.data))-len(stream.data)))
+	}
+
+	// CLI header
+	section := make([]byte, metadataOff)
+	le.PutUint32(section[0:], 72)
+	le.PutUint32(section[8:], sectionRVA+metadataOff)
+	le.PutUint32(section[12:], uint32(md.Len()))
+	section = append(section, md.Bytes()...)
+	resourceOff := len(section)
+
+	// Resource tree: RT_VERSION -> 1 -> language 0 -> data entry
+	rsrc := make([]byte, 0x58)
+	entries := []struct{ dir, id, target uint32 }{
+		{0x00, rtVersion, 0x80000000 | 0x18},
+		{0x18, 1, 0x80000000 | 0x30},
+		{0x30, 0, 0x48},
+	}
+	for _, e := range entries {
+		le.PutUint16(rsrc[e.dir+14:], 1)
+		le.PutUint32(rsrc[e.dir+16:], e.id)
+		le.PutUint32(rsrc[e.dir+20:], e.target)
+	}
+	info := buildVersionInfo(a.FileVersion, a.InformationalVersion)
+	le.PutUint32(rsrc[0x48:], uint32(sectionRVA+resourceOff+0x58))
+	le.PutUint32(rsrc[0x4C:], uint32(len(info)))
+	rsrc = append(rsrc, info...)
+	section = append(section, rsrc...)
+
+	var opt pe.OptionalHeader32
+	opt.Magic = 0x10b
+	opt.NumberOfRvaAndSizes = 16
+	opt.SectionAlignment = 0x2000
+	opt.FileAlignment = 0x200
+	opt.DataDirectory[peResourceDirectory] = pe.DataDirectory{VirtualAddress: uint32(sectionRVA + resourceOff), Size: uint32(len(rsrc))}
+	if !a.Native {
+		opt.DataDirectory[peCLRDirectory] = pe.DataDirectory{VirtualAddress: sectionRVA, Size: 72}
+	}
+
+	var img bytes.Buffer
+	dos := make([]byte, 0x80)
+	copy(dos, "MZ")
+	le.PutUint32(dos[0x3c:], 0x80)
+	img.Write(dos)
+	img.WriteString("PE\x00\x00")
+	write(&img, pe.FileHeader{Machine: pe.IMAGE_FILE_MACHINE_I386, NumberOfSections: 1, SizeOfOptionalHeader: uint16(binary.Size(opt)), Characteristics: 0x2102})
+	write(&img, opt)
+	var name [8]uint8
+	copy(name[:], ".text")
+	write(&img, pe.SectionHeader32{Name: name, VirtualSize: uint32(len(section)), VirtualAddress: sectionRVA, SizeOfRawData: uint32(len(section)), PointerToRawData: sectionOffset})
+	img.Write(make([]byte, sectionOffset-img.Len()))
+	img.Write(section)
+	return img.Bytes()
+}
+
+// buildVersionInfo builds a VS_VERSIONINFO resource with a fixed file version
+// of 9.9.9.9 and the given strings, omitting empty ones.
+func buildVersionInfo(fileVersion, informationalVersion string) []byte {
+	utf16z := func(s string) []byte {
+		var b []byte
+		for _, u := range append(utf16.Encode([]rune(s)), 0) {
+			b = le.AppendUint16(b, u)
+		}
+		return b
+	}
+	pad := func(b []byte) []byte {
+		for len(b)%4 != 0 {
+			b = append(b, 0)
+		}
+		return b
+	}
+	block := func(key string, value []byte, valueLength uint16, text bool, children ...[]byte) []byte {
+		b := make([]byte, 6)
+		b = pad(append(b, utf16z(key)...))
+		b = pad(append(b, value...))
+		for _, child := range children {
+			b = pad(append(b, child...))
+		}
+		le.PutUint16(b, uint16(len(b)))
+		le.PutUint16(b[2:], valueLength)
+		if text {
+			le.PutUint16(b[4:], 1)
+		}
+		return b
+	}
+	str := func(key, value string) []byte {
+		return block(key, utf16z(value), uint16(len(utf16.Encode([]rune(value)))+1), true)
+	}
+
+	var strs [][]byte
+	if fileVersion != "" {
+		strs = append(strs, str("FileVersion", fileVersion))
+	}
+	if informationalVersion != "" {
+		strs = append(strs, str("ProductVersion", informationalVersion))
+	}
+	fixed := make([]byte, 52)
+	le.PutUint32(fixed, 0xFEEF04BD)
+	le.PutUint32(fixed[8:], 9<<16|9)
+	le.PutUint32(fixed[12:], 9<<16|9)
+
+	table := block("000004b0", nil, 0, true, strs...)
+	return block("VS_VERSION_INFO", fixed, 52, false, block("StringFileInfo", nil, 0, true, table))
+}
+
+func TestReadAssemblyInfo(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        []byte
+		wantManaged bool
+		want        assemblyInfo
+	}{
+		{
+			name:        "managed",
+			data:        buildTestAssembly(t, testAssembly{Version: [4]uint16{2, 0, 0, 0}, FileVersion: "2.1.0.0", InformationalVersion: "2.1.0-beta.1+8f2c1a"}),
+			wantManaged: true,
+			want:        assemblyInfo{AssemblyVersion: "2.0.0.0", FileVersion: "2.1.0.0", InformationalVersion: "2.1.0-beta.1+8f2c1a"},
+		},
+		{
+			name:        "fixed file version fallback",
+			data:        buildTestAssembly(t, testAssembly{Version: [4]uint16{1, 2, 3, 4}}),
+			wantManaged: true,
+			want:        assemblyInfo{AssemblyVersion: "1.2.3.4", FileVersion: "9.9.9.9"},
+		},
+		{name: "native image", data: buildTestAssembly(t, testAssembly{Native: true, FileVersion: "1.0.0.0"})},
+		{name: "not a PE file", data: []byte("MZ\x00\x00not really")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, managed, err := readAssemblyInfo(tt.data)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if managed != tt.wantManaged || info != tt.want {
+				t.Errorf("readAssemblyInfo() = %+v, %v; want %+v, %v", info, managed, tt.want, tt.wantManaged)
+			}
+		})
+	}
+}
+
+func TestAssemblyVersionMatches(t *testing.T) {
+	pkg, _ := parseNuGetVersion("2.1.0-beta.1")
+	tests := []struct {
+		rule  string
+		found string
+		want  bool
+	}{
+		{rule: AssemblyRuleExact, found: "2.1.0-beta.1+8f2c1a", want: true},
+		{rule: AssemblyRuleExact, found: "2.1.0-BETA.1", want: true},
+		{rule: AssemblyRuleExact, found: "2.1.0", want: false},
+		{rule: AssemblyRuleExact, found: "2.1.0-beta.2", want: false},
+		{rule: AssemblyRuleNumeric, found: "2.1.0.0", want: true},
+		{rule: AssemblyRuleNumeric, found: "2.1.1.0", want: false},
+		{rule: AssemblyRuleMajorMinor, found: "2.1.9.0", want: true},
+		{rule: AssemblyRuleMajor, found: "2.0.0.0", want: true},
+		{rule: AssemblyRuleMajor, found: "1.0.0.0", want: false},
+		{rule: AssemblyRuleNumeric, found: "2.1.0 (local build)", want: false},
+		{rule: AssemblyRuleIgnore, found: "0.0.0.0", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule+" "+tt.found, func(t *testing.T) {
+			if got := assemblyVersionMatches(tt.rule, tt.found, pkg); got != tt.want {
+				t.Errorf("assemblyVersionMatches(%q, %q) = %v, want %v", tt.rule, tt.found, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAssemblyVersionRules(t *testing.T) {
+	rules, problems := parseAssemblyVersionRules(map[string]any{"assembly": "ignore", "file": "strict", "product": "exact"})
+	if rules[assemblyAttrAssembly] != AssemblyRuleIgnore || rules[assemblyAttrInformational] != AssemblyRuleExact || rules[assemblyAttrFile] != AssemblyRuleNumeric {
+		t.Errorf("unexpected rules: %v", rules)
+	}
+	if len(problems) != 2 {
+		t.Errorf("expected problems for the unknown rule and version, got %v", problems)
+	}
+}
+
+func TestExecuteAssemblyVersionCheck(t *testing.T) {
+	tmpDir := t.TempDir()
+	dll := string(buildTestAssembly(t, testAssembly{Version: [4]uint16{1, 0, 0, 0}, FileVersion: "1.2.0.0", InformationalVersion: "1.2.0+8f2c1a"}))
+	pkg := writeTestPackage(t, tmpDir, "Contoso.Core", "1.3.0", map[string]string{
+		"lib/net8.0/Contoso.Core.dll":    dll,
+		"content/tools/Unrelated.dll":    dll,
+		"lib/net8.0/runtimes/native.dll": string(buildTestAssembly(t, testAssembly{Native: true})),
+	})
+
+	run := func(config map[string]any) (*plugin.ExecuteResponse, *MockCommandExecutor) {
+		t.Helper()
+		mockExec := &MockCommandExecutor{}
+		config["api_key"] = "test-key"
+		config["source"] = "https://127.0.0.1/v3/index.json"
+		config["package_path"] = pkg
+		if _, ok := config["check_assembly_versions"]; !ok {
+			config["check_assembly_versions"] = true
+		}
+		resp, err := (&NuGetPlugin{cmdExecutor: mockExec}).Execute(context.Background(), plugin.ExecuteRequest{
+			Hook:    plugin.HookPostPublish,
+			Config:  config,
+			Context: plugin.ReleaseContext{Version: "v1.3.0"},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return resp, mockExec
+	}
+
+	resp, mockExec := run(map[string]any{})
+	if resp.Success || !strings.Contains(resp.Error, "assembly versions do not match package version in: Contoso.Core 1.3.0") {
+		t.Errorf("expected the mismatch to fail the push, got success=%v error=%s", resp.Success, resp.Error)
+	}
+	if len(mockExec.Calls) != 0 {
+		t.Errorf("expected nothing to be pushed, got %d pushes", len(mockExec.Calls))
+	}
+	result := resp.Outputs["packages"].([]PackageResult)[0]
+	want := []string{
+		"lib/net8.0/Contoso.Core.dll has AssemblyInformationalVersion 1.2.0+8f2c1a, expected 1.3.0 (exact)",
+		"lib/net8.0/Contoso.Core.dll has AssemblyFileVersion 1.2.0.0, expected 1.3.0 (numeric)",
+	}
+	var got []string
+	for _, m := range result.AssemblyMismatches {
+		got = append(got, m.String())
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") || result.ErrorClass != string(ErrorKindAssemblyVersion) {
+		t.Errorf("unexpected mismatches (%s):\n%s", result.ErrorClass, strings.Join(got, "\n"))
+	}
+
+	resp, mockExec = run(map[string]any{"assembly_version_rules": map[string]any{"informational": "major", "file": "major_minor"}})
+	if resp.Success || len(mockExec.Calls) != 0 {
+		t.Errorf("expected major_minor to still reject 1.2 for 1.3, got success=%v", resp.Success)
+	}
+
+	resp, mockExec = run(map[string]any{"assembly_version_rules": map[string]any{"informational": "ignore", "file": "major"}})
+	if !resp.Success || len(mockExec.Calls) != 1 {
+		t.Errorf("expected relaxed rules to allow the push, got success=%v error=%s", resp.Success, resp.Error)
+	}
+
+	resp, mockExec = run(map[string]any{"check_assembly_versions": false})
+	if !resp.Success || len(mockExec.Calls) != 1 {
+		t.Errorf("expected the push to proceed with the check disabled, got success=%v error=%s", resp.Success, resp.Error)
+	}
+
+	resp, err := (&NuGetPlugin{cmdExecutor: &MockCommandExecutor{}}).Execute(context.Background(), plugin.ExecuteRequest{
+		Hook:    plugin.HookPostPublish,
+		Config:  map[string]any{"api_key": "test-key", "source": "https://127.0.0.1/v3/index.json", "package_path": pkg},
+		Context: plugin.ReleaseContext{Version: "v1.3.0"},
+	})
+	if err != nil || !resp.Success {
+		t.Errorf("expected the check to be off by default, got success=%v error=%s (%v)", resp.Success, resp.Error, err)
+	}
+}
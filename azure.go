@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultAzureDevOpsURL is the Azure DevOps Services packaging host.
+const DefaultAzureDevOpsURL = "https://pkgs.dev.azure.com"
+
+// azurePackagingAPIVersion is the Azure DevOps packaging REST API version used for promotion.
+const azurePackagingAPIVersion = "7.1-preview.1"
+
+// azureAPIKeyPlaceholder is passed as the API key; Azure Artifacts ignores it and
+// authenticates the push through the credential provider instead.
+const azureAPIKeyPlaceholder = "az"
+
+// azureFeedBase returns the organization or project scope of the feed,
+// e.g. https://pkgs.dev.azure.com/contoso/platform.
+func (c *Config) azureFeedBase() string {
+	base := strings.TrimSuffix(c.AzureURL, "/") + "/" + url.PathEscape(c.AzureOrganization)
+	if c.AzureProject != "" {
+		base += "/" + url.PathEscape(c.AzureProject)
+	}
+	return base
+}
+
+// azureArtifactsSource returns the NuGet V3 service index of the configured feed.
+func (c *Config) azureArtifactsSource() string {
+	return c.azureFeedBase() + "/_packaging/" + url.PathEscape(c.AzureFeed) + "/nuget/v3/index.json"
+}
+
+// azureView returns the configured view name without a leading "@".
+func (c *Config) azureView() string {
+	return strings.TrimPrefix(c.AzureView, "@")
+}
+
+// pushEnv returns environment variables the push command needs.
+// For Azure Artifacts the PAT is handed to the Azure Artifacts Credential
+// Provider through VSS_NUGET_EXTERNAL_FEED_ENDPOINTS.
+func (c *Config) pushEnv() []string {
+	if c.Target != TargetAzureArtifacts || c.AzurePAT == "" {
+		return nil
+	}
+	endpoints := map[string]any{
+		"endpointCredentials": []map[string]string{
+			{"endpoint": c.Source, "username": azureAPIKeyPlaceholder, "password": c.AzurePAT},
+		},
+	}
+	data, err := json.Marshal(endpoints)
+	if err != nil {
+		return nil
+	}
+	return []string{"VSS_NUGET_EXTERNAL_FEED_ENDPOINTS=" + string(data)}
+}
+
+// promotePackage adds a package version to a feed view such as "Release"
+// through the Azure DevOps packaging REST API.
+func (c *feedClient) promotePackage(ctx context.Context, cfg *Config, id packageIdentity, view string) error {
+	endpoint := fmt.Sprintf("%s/_apis/packaging/feeds/%s/nuget/packages/%s/versions/%s?api-version=%s",
+		cfg.azureFeedBase(), url.PathEscape(cfg.AzureFeed), url.PathEscape(id.ID), url.PathEscape(normalizedVersion(id.Version)), azurePackagingAPIVersion)
+
+	body, err := json.Marshal(map[string]any{
+		"views": map[string]string{"op": "add", "path": "/views/-", "value": view},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode promotion request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// azure_url is configured and validated, unlike hosts the service index
+	// advertises, so the PAT is sent to it directly.
+	if c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("promotion request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("unexpected status %d promoting %s to @%s: %s", resp.StatusCode, id, view, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// promoteToView promotes every pushed or skipped package to the configured view.
+// Promotion failures are recorded on the results and returned.
+func (p *NuGetPlugin) promoteToView(ctx context.Context, cfg *Config, results []PackageResult) []string {
+	client := p.newFeedClient(cfg)
+	view := cfg.azureView()
+
+	var failed []string
+	for i := range results {
+		result := &results[i]
+		if result.Status != StatusPushed && result.Status != StatusSkipped {
+			continue
+		}
+		err := client.promotePackage(ctx, cfg, packageIdentity{ID: result.ID, Version: result.Version}, view)
+		if err != nil {
+			result.Message = joinReasons(result.Message, err.Error())
+			failed = append(failed, packageLabel(*result))
+			continue
+		}
+		result.View = view
+	}
+	return failed
+}
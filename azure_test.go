@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestAzureArtifactsSource(t *testing.T) {
+	tests := []struct {
+		name    string
+		project string
+		want    string
+	}{
+		{name: "organization feed", want: "https://pkgs.dev.azure.com/contoso/_packaging/internal/nuget/v3/index.json"},
+		{name: "project feed", project: "platform", want: "https://pkgs.dev.azure.com/contoso/platform/_packaging/internal/nuget/v3/index.json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := (&NuGetPlugin{}).parseConfig(map[string]any{
+				"target":             "azure_artifacts",
+				"azure_organization": "contoso",
+				"azure_project":      tt.project,
+				"azure_feed":         "internal",
+			})
+			cfg.applyTarget(plugin.ReleaseContext{})
+			if cfg.Source != tt.want {
+				t.Errorf("expected source %s, got %s", tt.want, cfg.Source)
+			}
+			if cfg.APIKey != azureAPIKeyPlaceholder {
+				t.Errorf("expected placeholder API key, got %q", cfg.APIKey)
+			}
+		})
+	}
+}
+
+func TestExecuteAzureArtifacts(t *testing.T) {
+	tmpDir := t.TempDir()
+	pkg := writeTestPackage(t, tmpDir, "Contoso.Core", "1.0.0", nil)
+	feed := newTestFeed(t)
+	wantAuth := "Basic " + base64.StdEncoding.EncodeToString([]byte("az:azure-pat"))
+
+	var mu sync.Mutex
+	var promotions []string
+	promoteStatus := http.StatusAccepted
+	devops := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		promotions = append(promotions, r.Method+" "+r.URL.Path+" "+r.Header.Get("Authorization")+" "+string(body))
+		mu.Unlock()
+		w.WriteHeader(promoteStatus)
+	}))
+	defer devops.Close()
+
+	run := func(dryRun bool) (*plugin.ExecuteResponse, *MockCommandExecutor) {
+		t.Helper()
+		mockExec := &MockCommandExecutor{}
+		p := &NuGetPlugin{cmdExecutor: mockExec, httpClient: feed.server.Client()}
+		resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+			Hook: plugin.HookPostPublish,
+			Config: map[string]any{
+				"target":             "azure_artifacts",
+				"azure_organization": "contoso",
+				"azure_project":      "platform",
+				"azure_feed":         "internal",
+				"azure_view":         "@Release",
+				"azure_pat":          "azure-pat",
+				"azure_url":          devops.URL,
+				"source":             feed.SourceURL(),
+				"package_path":       pkg,
+			},
+			Context: plugin.ReleaseContext{Version: "v1.0.0"},
+			DryRun:  dryRun,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return resp, mockExec
+	}
+
+	resp, _ := run(true)
+	if !resp.Success || !strings.Contains(resp.Message, "promote to @Release") {
+		t.Errorf("expected dry run to announce promotion, got %q (%s)", resp.Message, resp.Error)
+	}
+	if len(feed.authorizations) == 0 || feed.authorizations[0] != wantAuth {
+		t.Errorf("expected feed reads to authenticate with the PAT, got %v", feed.authorizations)
+	}
+	if len(promotions) != 0 {
+		t.Errorf("expected dry run not to promote, got %v", promotions)
+	}
+
+	resp, mockExec := run(false)
+	if !resp.Success {
+		t.Fatalf("expected success, got: %s", resp.Error)
+	}
+	call := mockExec.Calls[0]
+	if call.Args[4] != azureAPIKeyPlaceholder {
+		t.Errorf("expected placeholder API key, got %v", call.Args)
+	}
+	if len(call.Env) != 1 || !strings.HasPrefix(call.Env[0], "VSS_NUGET_EXTERNAL_FEED_ENDPOINTS=") {
+		t.Fatalf("expected credential provider endpoints in env, got %v", call.Env)
+	}
+	var endpoints struct {
+		EndpointCredentials []struct {
+			Endpoint string `json:"endpoint"`
+			Password string `json:"password"`
+		} `json:"endpointCredentials"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(call.Env[0], "VSS_NUGET_EXTERNAL_FEED_ENDPOINTS=")), &endpoints); err != nil {
+		t.Fatalf("invalid endpoints JSON: %v", err)
+	}
+	if endpoints.EndpointCredentials[0].Endpoint != feed.SourceURL() || endpoints.EndpointCredentials[0].Password != "azure-pat" {
+		t.Errorf("unexpected endpoint credentials: %+v", endpoints)
+	}
+
+	wantPromotion := "PATCH /contoso/platform/_apis/packaging/feeds/internal/nuget/packages/Contoso.Core/versions/1.0.0 " + wantAuth
+	if len(promotions) != 1 || !strings.HasPrefix(promotions[0], wantPromotion) || !strings.Contains(promotions[0], `"value":"Release"`) {
+		t.Errorf("unexpected promotion requests: %v", promotions)
+	}
+	if results := resp.Outputs["packages"].([]PackageResult); results[0].View != "Release" {
+		t.Errorf("expected result to record the view, got %+v", results[0])
+	}
+
+	promoteStatus = http.StatusForbidden
+	resp, _ = run(false)
+	if resp.Success || !strings.Contains(resp.Error, "failed to promote Contoso.Core 1.0.0 to @Release") {
+		t.Errorf("expected promotion failure, got success=%v error=%s", resp.Success, resp.Error)
+	}
+}
+
+func TestExecuteAzureArtifactsRejectsUnsafeURL(t *testing.T) {
+	tmpDir := t.TempDir()
+	pkg := writeTestPackage(t, tmpDir, "Contoso.Core", "1.0.0", nil)
+	feed := newTestFeed(t)
+
+	mockExec := &MockCommandExecutor{}
+	p := &NuGetPlugin{cmdExecutor: mockExec, httpClient: feed.server.Client()}
+	resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+		Hook: plugin.HookPostPublish,
+		Config: map[string]any{
+			"target":             "azure_artifacts",
+			"azure_organization": "contoso",
+			"azure_feed":         "internal",
+			"azure_view":         "@Release",
+			"azure_pat":          "azure-pat",
+			"azure_url":          "http://devops.example.com",
+			"source":             feed.SourceURL(),
+			"package_path":       pkg,
+		},
+		Context: plugin.ReleaseContext{Version: "v1.0.0"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Success || !strings.Contains(resp.Error, "invalid azure_url") {
+		t.Errorf("expected azure_url to be rejected, got success=%v error=%s", resp.Success, resp.Error)
+	}
+	if len(mockExec.Calls) != 0 {
+		t.Errorf("expected no pushes, got %d", len(mockExec.Calls))
+	}
+}
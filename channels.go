@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// channelStable is the channel match that selects versions without a prerelease label.
+const channelStable = "stable"
+
+// channelDefault names the channel used when no channels rule matches the release.
+const channelDefault = "default"
+
+// ChannelRule routes releases by the prerelease label of their version and can
+// override where and how packages are pushed. Credentials are read from the
+// named environment variables so they never appear in config.
+type ChannelRule struct {
+	// Name identifies the channel in outputs, e.g. "preview".
+	Name string
+	// Match is a glob over the prerelease label such as "beta.*"; "stable"
+	// matches versions without a label. Matching is case-insensitive.
+	Match string
+	// Push is false for channels whose releases are not published.
+	Push bool
+	// Source, when set, replaces the configured source.
+	Source string
+	// APIKeyEnv, when set, names the environment variable holding the API key.
+	APIKeyEnv string
+	// NoSymbols, when set, overrides no_symbols.
+	NoSymbols *bool
+	// SymbolSource, when set, replaces symbol_source.
+	SymbolSource string
+	// SymbolAPIKeyEnv, when set, names the environment variable holding the symbol API key.
+	SymbolAPIKeyEnv string
+}
+
+// releaseChannel is the channel chosen for a release, reported in outputs.
+type releaseChannel struct {
+	Name       string `json:"name"`
+	Prerelease string `json:"prerelease,omitempty"`
+	Source     string `json:"source"`
+	Push       bool   `json:"push"`
+}
+
+// parseChannels reads the channels config list.
+// Malformed entries are returned as problems for validation.
+func parseChannels(raw any) ([]ChannelRule, []string) {
+	if raw == nil {
+		return nil, nil
+	}
+	list, ok := raw.([]any)
+	if !ok {
+		return nil, []string{"must be a list of {name, match, ...} entries"}
+	}
+
+	var rules []ChannelRule
+	var problems []string
+	for i, item := range list {
+		entry, ok := item.(map[string]any)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("entry %d must be an object with name and match", i))
+			continue
+		}
+		rule := ChannelRule{Push: true}
+		rule.Name, _ = entry["name"].(string)
+		rule.Match, _ = entry["match"].(string)
+		rule.Source, _ = entry["source"].(string)
+		rule.APIKeyEnv, _ = entry["api_key_env"].(string)
+		rule.SymbolSource, _ = entry["symbol_source"].(string)
+		rule.SymbolAPIKeyEnv, _ = entry["symbol_api_key_env"].(string)
+		if push, ok := entry["push"].(bool); ok {
+			rule.Push = push
+		}
+		if noSymbols, ok := entry["no_symbols"].(bool); ok {
+			rule.NoSymbols = &noSymbols
+		}
+
+		rule.Name, rule.Match = strings.TrimSpace(rule.Name), strings.TrimSpace(rule.Match)
+		if rule.Name == "" {
+			problems = append(problems, fmt.Sprintf("entry %d is missing name", i))
+		}
+		if rule.Match == "" {
+			problems = append(problems, fmt.Sprintf("entry %d is missing match", i))
+		}
+		rules = append(rules, rule)
+	}
+	return rules, problems
+}
+
+// channelURLProblems checks the source and symbol_source of each channel like
+// the top-level URLs. It resolves hosts, so it is only called during validation.
+func channelURLProblems(ctx context.Context, rules []ChannelRule) []string {
+	var problems []string
+	for i, rule := range rules {
+		if rule.Source != "" {
+			if err := validateSourceURL(ctx, rule.Source); err != nil {
+				problems = append(problems, fmt.Sprintf("entry %d has an invalid source: %v", i, err))
+			}
+		}
+		if rule.SymbolSource != "" {
+			if err := validateSourceURL(ctx, rule.SymbolSource); err != nil {
+				problems = append(problems, fmt.Sprintf("entry %d has an invalid symbol_source: %v", i, err))
+			}
+		}
+	}
+	return problems
+}
+
+// prereleaseLabel returns the prerelease label of a version, e.g. "beta.3" for
+// "v2.1.0-beta.3+build.5", or "" for a stable version.
+func prereleaseLabel(version string) string {
+	version = strings.TrimPrefix(version, "v")
+	if i := strings.Index(version, "+"); i >= 0 {
+		version = version[:i]
+	}
+	if i := strings.Index(version, "-"); i >= 0 {
+		return version[i+1:]
+	}
+	return ""
+}
+
+// matchChannel reports whether a channel match selects a prerelease label.
+func matchChannel(match, label string) bool {
+	if strings.EqualFold(match, channelStable) {
+		return label == ""
+	}
+	return label != "" && matchPackageID(match, label)
+}
+
+// applyChannel selects the first channels rule matching the release version and
+// applies its overrides. Without channels it returns nil; when no rule matches,
+// the top-level settings are used and reported as the default channel.
+func (c *Config) applyChannel(version string) (*releaseChannel, error) {
+	if len(c.Channels) == 0 {
+		return nil, nil
+	}
+
+	label := prereleaseLabel(version)
+	for _, rule := range c.Channels {
+		if !matchChannel(rule.Match, label) {
+			continue
+		}
+
+		if rule.Source != "" {
+			c.Source = rule.Source
+		}
+		if rule.APIKeyEnv != "" {
+			c.APIKey = os.Getenv(rule.APIKeyEnv)
+			c.APIKeys = nil
+			if c.APIKey == "" && rule.Push {
+				return nil, fmt.Errorf("environment variable %s for channel %s is not set", rule.APIKeyEnv, rule.Name)
+			}
+		}
+		if rule.NoSymbols != nil {
+			c.NoSymbols = *rule.NoSymbols
+		}
+		if rule.SymbolSource != "" {
+			c.SymbolSource = rule.SymbolSource
+		}
+		if rule.SymbolAPIKeyEnv != "" {
+			c.SymbolAPIKey = os.Getenv(rule.SymbolAPIKeyEnv)
+		}
+		return &releaseChannel{Name: rule.Name, Prerelease: label, Source: c.Source, Push: rule.Push}, nil
+	}
+
+	return &releaseChannel{Name: channelDefault, Prerelease: label, Source: c.Source, Push: true}, nil
+}
+
+// channelSkippedResponse reports that a hook did nothing because the release's
+// channel is not pushed.
+func channelSkippedResponse(hook plugin.Hook, channel *releaseChannel) *plugin.ExecuteResponse {
+	return &plugin.ExecuteResponse{
+		Success: true,
+		Message: fmt.Sprintf("Hook %s skipped: channel %s is not pushed", hook, channel.Name),
+		Outputs: map[string]any{"channel": channel},
+	}
+}
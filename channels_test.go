@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestPrereleaseLabel(t *testing.T) {
+	tests := []struct {
+		version string
+		want    string
+	}{
+		{version: "2.1.0", want: ""},
+		{version: "v2.1.0", want: ""},
+		{version: "2.1.0-beta.3", want: "beta.3"},
+		{version: "v2.1.0-rc.1+build.7", want: "rc.1"},
+		{version: "2.1.0+build.7", want: ""},
+		{version: "2.1.0-alpha-2", want: "alpha-2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			if got := prereleaseLabel(tt.version); got != tt.want {
+				t.Errorf("prereleaseLabel(%q) = %q, want %q", tt.version, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyChannel(t *testing.T) {
+	t.Setenv("PREVIEW_KEY", "preview-key")
+
+	noSymbols := true
+	rules := []ChannelRule{
+		{Name: "preview", Match: "beta.*", Push: true, Source: "https://preview.example.com/v3/index.json", APIKeyEnv: "PREVIEW_KEY", NoSymbols: &noSymbols},
+		{Name: "nightly", Match: "alpha*", Push: false},
+		{Name: "public", Match: "stable", Push: true},
+	}
+
+	tests := []struct {
+		version       string
+		wantChannel   string
+		wantSource    string
+		wantKey       string
+		wantPush      bool
+		wantNoSymbols bool
+	}{
+		{version: "v2.1.0-beta.3", wantChannel: "preview", wantSource: "https://preview.example.com/v3/index.json", wantKey: "preview-key", wantPush: true, wantNoSymbols: true},
+		{version: "v2.1.0-alpha.1", wantChannel: "nightly", wantSource: DefaultSource, wantKey: "top-level", wantPush: false},
+		{version: "v2.1.0", wantChannel: "public", wantSource: DefaultSource, wantKey: "top-level", wantPush: true},
+		{version: "v2.1.0-rc.1", wantChannel: channelDefault, wantSource: DefaultSource, wantKey: "top-level", wantPush: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			cfg := &Config{APIKey: "top-level", Source: DefaultSource, Channels: rules}
+			channel, err := cfg.applyChannel(tt.version)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if channel.Name != tt.wantChannel || channel.Push != tt.wantPush || channel.Source != tt.wantSource {
+				t.Errorf("unexpected channel: %+v", channel)
+			}
+			if cfg.Source != tt.wantSource || cfg.APIKey != tt.wantKey || cfg.NoSymbols != tt.wantNoSymbols {
+				t.Errorf("unexpected config: source=%s key=%s no_symbols=%v", cfg.Source, cfg.APIKey, cfg.NoSymbols)
+			}
+		})
+	}
+
+	cfg := &Config{Channels: []ChannelRule{{Name: "preview", Match: "beta.*", Push: true, APIKeyEnv: "UNSET_CHANNEL_KEY"}}}
+	if _, err := cfg.applyChannel("2.1.0-beta.1"); err == nil || !strings.Contains(err.Error(), "UNSET_CHANNEL_KEY") {
+		t.Errorf("expected missing key error, got %v", err)
+	}
+}
+
+func TestExecuteChannels(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTestPackage(t, tmpDir, "Contoso.Core", "2.1.0-beta.3", nil)
+	preview := newTestFeed(t)
+	t.Setenv("PREVIEW_KEY", "preview-key")
+	t.Setenv("PREVIEW_SYMBOL_KEY", "symbol-key")
+
+	run := func(hook plugin.Hook, version string) (*plugin.ExecuteResponse, *MockCommandExecutor) {
+		t.Helper()
+		mockExec := &MockCommandExecutor{}
+		p := &NuGetPlugin{cmdExecutor: mockExec, httpClient: preview.server.Client()}
+		resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+			Hook: hook,
+			Config: map[string]any{
+				"api_key":      "nuget-key",
+				"package_path": tmpDir + "/*.nupkg",
+				"channels": []any{
+					map[string]any{
+						"name":               "preview",
+						"match":              "beta.*",
+						"source":             preview.SourceURL(),
+						"api_key_env":        "PREVIEW_KEY",
+						"symbol_source":      preview.server.URL + "/symbols",
+						"symbol_api_key_env": "PREVIEW_SYMBOL_KEY",
+					},
+					map[string]any{"name": "nightly", "match": "alpha*", "push": false},
+					map[string]any{"name": "rc", "match": "rc.*", "api_key_env": "RC_KEY"},
+				},
+			},
+			Context: plugin.ReleaseContext{Version: version},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return resp, mockExec
+	}
+
+	resp, mockExec := run(plugin.HookPostPublish, "v2.1.0-beta.3")
+	if !resp.Success {
+		t.Fatalf("expected success, got: %s", resp.Error)
+	}
+	args := strings.Join(mockExec.Calls[0].Args, " ")
+	for _, want := range []string{"--source " + preview.SourceURL(), "--api-key preview-key", "--symbol-source " + preview.server.URL + "/symbols", "--symbol-api-key symbol-key"} {
+		if !strings.Contains(args, want) {
+			t.Errorf("expected push args to contain %q, got %s", want, args)
+		}
+	}
+	if channel := resp.Outputs["channel"].(*releaseChannel); channel.Name != "preview" || channel.Prerelease != "beta.3" {
+		t.Errorf("unexpected channel output: %+v", channel)
+	}
+
+	resp, mockExec = run(plugin.HookPostPublish, "v2.1.0-alpha.1")
+	if !resp.Success || !strings.Contains(resp.Message, "channel nightly is not pushed") {
+		t.Errorf("expected alpha release to be skipped, got %q (%s)", resp.Message, resp.Error)
+	}
+	if len(mockExec.Calls) != 0 {
+		t.Errorf("expected nothing to be pushed, got %d pushes", len(mockExec.Calls))
+	}
+	if channel := resp.Outputs["channel"].(*releaseChannel); channel.Name != "nightly" || channel.Push {
+		t.Errorf("unexpected channel output: %+v", channel)
+	}
+
+	resp, _ = run(plugin.HookOnSuccess, "v2.1.0-alpha.1")
+	notification := resp.Outputs["notification"].(releaseNotification)
+	if !resp.Success || !strings.Contains(notification.Text, "not pushed (channel nightly)") {
+		t.Errorf("expected notification to report the unpushed channel, got %q (%s)", notification.Text, resp.Error)
+	}
+
+	resp, _ = run(plugin.HookPostPublish, "v2.1.0-rc.1")
+	if resp.Success || !strings.Contains(resp.Error, "RC_KEY") {
+		t.Errorf("expected push to fail without the channel key, got success=%v error=%s", resp.Success, resp.Error)
+	}
+	resp, _ = run(plugin.HookOnError, "v2.1.0-rc.1")
+	if !resp.Success || !strings.Contains(resp.Message, "channel not applied") {
+		t.Errorf("expected notify to report despite the channel error, got %q (%s)", resp.Message, resp.Error)
+	}
+	if _, ok := resp.Outputs["notification"].(releaseNotification); !ok {
+		t.Errorf("expected a notification, got %v", resp.Outputs)
+	}
+}
+
+func TestValidateChannels(t *testing.T) {
+	tests := []struct {
+		name     string
+		channels any
+		wantErr  string
+	}{
+		{name: "valid", channels: []any{map[string]any{"name": "preview", "match": "beta.*"}}},
+		{name: "not a list", channels: "beta", wantErr: "must be a list"},
+		{name: "missing match", channels: []any{map[string]any{"name": "preview"}}, wantErr: "entry 0 is missing match"},
+		{name: "unsafe source", channels: []any{map[string]any{"name": "preview", "match": "beta.*", "source": "http://preview.example.com"}}, wantErr: "invalid source"},
+		{name: "unsafe symbol source", channels: []any{map[string]any{"name": "preview", "match": "beta.*", "symbol_source": "http://symbols.example.com"}}, wantErr: "entry 0 has an invalid symbol_source"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := (&NuGetPlugin{}).Validate(context.Background(), map[string]any{"channels": tt.channels})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got []string
+			for _, e := range resp.Errors {
+				if e.Field == "channels" {
+					got = append(got, e.Message)
+				}
+			}
+			if tt.wantErr == "" && len(got) > 0 {
+				t.Errorf("unexpected errors: %v", got)
+			}
+			if tt.wantErr != "" && !strings.Contains(strings.Join(got, "; "), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, got)
+			}
+		})
+	}
+}
+
+func TestValidateConfigChannelURLs(t *testing.T) {
+	raw := []any{map[string]any{"name": "preview", "match": "beta.*", "symbol_source": "http://symbols.example.com"}}
+	rules, problems := parseChannels(raw)
+	if len(problems) > 0 {
+		t.Fatalf("parsing should not check URLs, got %v", problems)
+	}
+
+	cfg := &Config{
+		APIKey:      "key",
+		Source:      "https://127.0.0.1/v3/index.json",
+		PackagePath: DefaultPackagePath,
+		Timeout:     DefaultTimeout,
+		Channels:    rules,
+	}
+	err := (&NuGetPlugin{}).validateConfig(context.Background(), cfg)
+	if err == nil || !strings.Contains(err.Error(), "entry 0 has an invalid symbol_source") {
+		t.Errorf("expected the channel symbol_source to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,560 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// metadataSignature starts the .NET metadata root ("BSJB").
+const metadataSignature = 0x424A5342
+
+// Metadata tables read by the plugin (ECMA-335 II.22).
+const (
+	tableTypeRef     = 0x01
+	tableTypeDef     = 0x02
+	tableFieldPtr    = 0x03
+	tableField       = 0x04
+	tableMethodPtr   = 0x05
+	tableMethodDef   = 0x06
+	tableTypeSpec    = 0x1B
+	tableAssembly    = 0x20
+	tableNestedClass = 0x29
+)
+
+// maxSignatureDepth bounds nesting while decoding signatures and type specs.
+const maxSignatureDepth = 64
+
+// metadataStreams splits the metadata root into its named streams.
+func metadataStreams(md []byte) (map[string][]byte, error) {
+	if len(md) < 16 || le.Uint32(md) != metadataSignature {
+		return nil, fmt.Errorf("invalid metadata signature")
+	}
+	off := 16 + uint64(le.Uint32(md[12:]))
+	if off+4 > uint64(len(md)) {
+		return nil, errTruncatedImage
+	}
+	count := int(le.Uint16(md[off+2:]))
+	off += 4
+
+	streams := make(map[string][]byte, count)
+	for i := 0; i < count; i++ {
+		if off+8 > uint64(len(md)) {
+			return nil, errTruncatedImage
+		}
+		start, size := uint64(le.Uint32(md[off:])), uint64(le.Uint32(md[off+4:]))
+		off += 8
+		end := bytes.IndexByte(md[off:], 0)
+		if end < 0 {
+			return nil, errTruncatedImage
+		}
+		name := string(md[off : off+uint64(end)])
+		// Names are null-terminated and padded to a multiple of 4 bytes.
+		off += uint64(end+4) &^ 3
+		if start+size > uint64(len(md)) {
+			return nil, errTruncatedImage
+		}
+		streams[name] = md[start : start+size]
+	}
+	return streams, nil
+}
+
+// metadataTables reads rows of the metadata tables and the heaps they point into.
+type metadataTables struct {
+	data    []byte
+	strings []byte
+	blobs   []byte
+	rows    [64]uint32
+	offsets [tableNestedClass + 1]uint64
+	sizes   [tableNestedClass + 1]int
+	widths  metadataWidths
+	// enclosing maps nested TypeDef rows to the row of their enclosing type.
+	enclosing map[uint32]uint32
+}
+
+// metadataWidths are the sizes in bytes of heap and coded indexes, which grow
+// from 2 to 4 bytes with the size of what they point into.
+type metadataWidths struct {
+	str, guid, blob int
+	typeDefOrRef    int
+	resolutionScope int
+}
+
+// newMetadataTables parses the table stream header and locates every table
+// up to NestedClass.
+func newMetadataTables(streams map[string][]byte) (*metadataTables, error) {
+	t, ok := streams["#~"]
+	if !ok {
+		t, ok = streams["#-"]
+	}
+	if !ok {
+		return nil, fmt.Errorf("metadata has no table stream")
+	}
+	if len(t) < 24 {
+		return nil, errTruncatedImage
+	}
+
+	m := &metadataTables{data: t, strings: streams["#Strings"], blobs: streams["#Blob"]}
+	heapSizes := t[6]
+	valid := le.Uint64(t[8:])
+	off := uint64(24)
+	for i := range m.rows {
+		if valid&(1<<i) == 0 {
+			continue
+		}
+		if off+4 > uint64(len(t)) {
+			return nil, errTruncatedImage
+		}
+		m.rows[i] = le.Uint32(t[off:])
+		off += 4
+	}
+	// Some compilers add four bytes of extra data after the row counts.
+	if heapSizes&0x40 != 0 {
+		off += 4
+	}
+
+	m.sizes, m.widths = tableRowSizes(heapSizes, &m.rows)
+	for i, size := range m.sizes {
+		m.offsets[i] = off
+		off += uint64(m.rows[i]) * uint64(size)
+	}
+	return m, nil
+}
+
+// tableRowSizes returns the row size of each metadata table up to
+// NestedClass (ECMA-335 II.22) and the widths of the indexes they use.
+func tableRowSizes(heapSizes byte, rows *[64]uint32) ([tableNestedClass + 1]int, metadataWidths) {
+	w := metadataWidths{str: 2, guid: 2, blob: 2}
+	if heapSizes&0x01 != 0 {
+		w.str = 4
+	}
+	if heapSizes&0x02 != 0 {
+		w.guid = 4
+	}
+	if heapSizes&0x04 != 0 {
+		w.blob = 4
+	}
+	idx := func(table int) int {
+		if rows[table] < 1<<16 {
+			return 2
+		}
+		return 4
+	}
+	coded := func(tagBits uint, tables ...int) int {
+		for _, table := range tables {
+			if rows[table] >= 1<<(16-tagBits) {
+				return 4
+			}
+		}
+		return 2
+	}
+
+	w.typeDefOrRef = coded(2, 0x02, 0x01, 0x1B)
+	w.resolutionScope = coded(2, 0x00, 0x1A, 0x23, 0x01)
+	hasConstant := coded(2, 0x04, 0x08, 0x17)
+	hasCustomAttribute := coded(5, 0x06, 0x04, 0x01, 0x02, 0x08, 0x09, 0x0A, 0x00, 0x0E, 0x17, 0x14,
+		0x11, 0x1A, 0x1B, 0x20, 0x23, 0x26, 0x27, 0x28, 0x2A, 0x2C, 0x2B)
+	hasFieldMarshal := coded(1, 0x04, 0x08)
+	hasDeclSecurity := coded(2, 0x02, 0x06, 0x20)
+	memberRefParent := coded(3, 0x02, 0x01, 0x1A, 0x06, 0x1B)
+	hasSemantics := coded(1, 0x14, 0x17)
+	methodDefOrRef := coded(1, 0x06, 0x0A)
+	memberForwarded := coded(1, 0x04, 0x06)
+	customAttributeType := coded(3, 0x06, 0x0A)
+	implementation := coded(2, 0x26, 0x23, 0x27)
+	str, guid, blob, typeDefOrRef := w.str, w.guid, w.blob, w.typeDefOrRef
+
+	return [tableNestedClass + 1]int{
+		0x00: 2 + str + 3*guid,                                 // Module
+		0x01: w.resolutionScope + 2*str,                        // TypeRef
+		0x02: 4 + 2*str + typeDefOrRef + idx(0x04) + idx(0x06), // TypeDef
+		0x03: idx(0x04),                                        // FieldPtr
+		0x04: 2 + str + blob,                                   // Field
+		0x05: idx(0x06),                                        // MethodPtr
+		0x06: 4 + 2 + 2 + str + blob + idx(0x08),               // MethodDef
+		0x07: idx(0x08),                                        // ParamPtr
+		0x08: 2 + 2 + str,                                      // Param
+		0x09: idx(0x02) + typeDefOrRef,                         // InterfaceImpl
+		0x0A: memberRefParent + str + blob,                     // MemberRef
+		0x0B: 2 + hasConstant + blob,                           // Constant
+		0x0C: hasCustomAttribute + customAttributeType + blob,  // CustomAttribute
+		0x0D: hasFieldMarshal + blob,                           // FieldMarshal
+		0x0E: 2 + hasDeclSecurity + blob,                       // DeclSecurity
+		0x0F: 2 + 4 + idx(0x02),                                // ClassLayout
+		0x10: 4 + idx(0x04),                                    // FieldLayout
+		0x11: blob,                                             // StandAloneSig
+		0x12: idx(0x02) + idx(0x14),                            // EventMap
+		0x13: idx(0x14),                                        // EventPtr
+		0x14: 2 + str + typeDefOrRef,                           // Event
+		0x15: idx(0x02) + idx(0x17),                            // PropertyMap
+		0x16: idx(0x17),                                        // PropertyPtr
+		0x17: 2 + str + blob,                                   // Property
+		0x18: 2 + idx(0x06) + hasSemantics,                     // MethodSemantics
+		0x19: idx(0x02) + 2*methodDefOrRef,                     // MethodImpl
+		0x1A: str,                                              // ModuleRef
+		0x1B: blob,                                             // TypeSpec
+		0x1C: 2 + memberForwarded + str + idx(0x1A),            // ImplMap
+		0x1D: 4 + idx(0x04),                                    // FieldRVA
+		0x1E: 4 + 4,                                            // EncLog
+		0x1F: 4,                                                // EncMap
+		0x20: 4 + 4*2 + 4 + blob + 2*str,                       // Assembly
+		0x21: 4,                                                // AssemblyProcessor
+		0x22: 4 + 4 + 4,                                        // AssemblyOS
+		0x23: 4*2 + 4 + blob + 2*str + blob,                    // AssemblyRef
+		0x24: 4 + idx(0x23),                                    // AssemblyRefProcessor
+		0x25: 4 + 4 + 4 + idx(0x23),                            // AssemblyRefOS
+		0x26: 4 + str + blob,                                   // File
+		0x27: 4 + 4 + 2*str + implementation,                   // ExportedType
+		0x28: 4 + 4 + str + implementation,                     // ManifestResource
+		0x29: 2 * idx(0x02),                                    // NestedClass
+	}, w
+}
+
+// row returns row i (1-based) of a table.
+func (m *metadataTables) row(table int, i uint32) ([]byte, error) {
+	if i == 0 || i > m.rows[table] {
+		return nil, fmt.Errorf("row %d of table %#x does not exist", i, table)
+	}
+	start := m.offsets[table] + uint64(i-1)*uint64(m.sizes[table])
+	end := start + uint64(m.sizes[table])
+	if end > uint64(len(m.data)) {
+		return nil, errTruncatedImage
+	}
+	return m.data[start:end], nil
+}
+
+// index returns the width of a simple index into a table.
+func (m *metadataTables) index(table int) int {
+	if m.rows[table] < 1<<16 {
+		return 2
+	}
+	return 4
+}
+
+// str returns a string from the #Strings heap.
+func (m *metadataTables) str(i uint32) string {
+	if uint64(i) >= uint64(len(m.strings)) {
+		return ""
+	}
+	s := m.strings[i:]
+	if end := bytes.IndexByte(s, 0); end >= 0 {
+		s = s[:end]
+	}
+	return string(s)
+}
+
+// blob returns a length-prefixed entry of the #Blob heap.
+func (m *metadataTables) blob(i uint32) ([]byte, error) {
+	if uint64(i) >= uint64(len(m.blobs)) {
+		return nil, errTruncatedImage
+	}
+	r := &sigReader{b: m.blobs[i:]}
+	n := r.uint()
+	if r.err != nil || uint64(r.off)+uint64(n) > uint64(len(r.b)) {
+		return nil, errTruncatedImage
+	}
+	return r.b[r.off : r.off+int(n)], nil
+}
+
+// columns reads the fixed-width columns of a row in order.
+type columns struct {
+	b   []byte
+	off int
+}
+
+// next reads a column of the given width.
+func (c *columns) next(width int) uint32 {
+	var v uint32
+	switch width {
+	case 2:
+		v = uint32(le.Uint16(c.b[c.off:]))
+	case 4:
+		v = le.Uint32(c.b[c.off:])
+	}
+	c.off += width
+	return v
+}
+
+// assemblyVersion reads the version of the assembly manifest. Modules without
+// a manifest return an empty version.
+func (m *metadataTables) assemblyVersion() (string, error) {
+	if m.rows[tableAssembly] == 0 {
+		return "", nil
+	}
+	// An Assembly row starts with HashAlgId followed by four 2-byte version parts.
+	row, err := m.row(tableAssembly, 1)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d.%d.%d.%d", le.Uint16(row[4:]), le.Uint16(row[6:]), le.Uint16(row[8:]), le.Uint16(row[10:])), nil
+}
+
+// typeDef is a row of the TypeDef table.
+type typeDef struct {
+	flags      uint32
+	name       string
+	namespace  string
+	fieldList  uint32
+	methodList uint32
+}
+
+// typeDef reads TypeDef row i.
+func (m *metadataTables) typeDef(i uint32) (typeDef, error) {
+	row, err := m.row(tableTypeDef, i)
+	if err != nil {
+		return typeDef{}, err
+	}
+	c := columns{b: row}
+	td := typeDef{flags: c.next(4)}
+	td.name = m.str(c.next(m.widths.str))
+	td.namespace = m.str(c.next(m.widths.str))
+	c.next(m.widths.typeDefOrRef)
+	td.fieldList = c.next(m.index(tableField))
+	td.methodList = c.next(m.index(tableMethodDef))
+	return td, nil
+}
+
+// typeDefName returns the full name of a TypeDef, joining nested types to
+// their enclosing type with "+".
+func (m *metadataTables) typeDefName(i uint32, depth int) (string, error) {
+	if depth > maxSignatureDepth {
+		return "", fmt.Errorf("type nesting is too deep")
+	}
+	td, err := m.typeDef(i)
+	if err != nil {
+		return "", err
+	}
+	if m.enclosing == nil {
+		if err := m.readNestedClasses(); err != nil {
+			return "", err
+		}
+	}
+	if outer, ok := m.enclosing[i]; ok {
+		outerName, err := m.typeDefName(outer, depth+1)
+		if err != nil {
+			return "", err
+		}
+		return outerName + "+" + td.name, nil
+	}
+	return qualifiedName(td.namespace, td.name), nil
+}
+
+// readNestedClasses loads the NestedClass table.
+func (m *metadataTables) readNestedClasses() error {
+	m.enclosing = make(map[uint32]uint32, m.rows[tableNestedClass])
+	width := m.index(tableTypeDef)
+	for i := uint32(1); i <= m.rows[tableNestedClass]; i++ {
+		row, err := m.row(tableNestedClass, i)
+		if err != nil {
+			return err
+		}
+		c := columns{b: row}
+		nested := c.next(width)
+		m.enclosing[nested] = c.next(width)
+	}
+	return nil
+}
+
+// typeRefName returns the full name of a TypeRef.
+func (m *metadataTables) typeRefName(i uint32, depth int) (string, error) {
+	if depth > maxSignatureDepth {
+		return "", fmt.Errorf("type nesting is too deep")
+	}
+	row, err := m.row(tableTypeRef, i)
+	if err != nil {
+		return "", err
+	}
+	c := columns{b: row}
+	scope := c.next(m.widths.resolutionScope)
+	name := m.str(c.next(m.widths.str))
+	namespace := m.str(c.next(m.widths.str))
+	// A TypeRef resolved through another TypeRef is a nested type.
+	if scope&3 == 3 {
+		outer, err := m.typeRefName(scope>>2, depth+1)
+		if err != nil {
+			return "", err
+		}
+		return outer + "+" + name, nil
+	}
+	return qualifiedName(namespace, name), nil
+}
+
+// qualifiedName joins a namespace and a type name.
+func qualifiedName(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "." + name
+}
+
+// sigReader decodes signature blobs (ECMA-335 II.23.2) into readable type
+// names. Tokens are resolved to names so signatures compare across builds.
+type sigReader struct {
+	m     *metadataTables
+	b     []byte
+	off   int
+	depth int
+	err   error
+}
+
+// byte reads one byte.
+func (r *sigReader) byte() byte {
+	if r.err != nil || r.off >= len(r.b) {
+		r.fail(errTruncatedImage)
+		return 0
+	}
+	r.off++
+	return r.b[r.off-1]
+}
+
+// uint reads a compressed unsigned integer.
+func (r *sigReader) uint() uint32 {
+	b0 := uint32(r.byte())
+	switch {
+	case b0&0x80 == 0:
+		return b0
+	case b0&0xC0 == 0x80:
+		return (b0&0x3F)<<8 | uint32(r.byte())
+	default:
+		b1, b2, b3 := uint32(r.byte()), uint32(r.byte()), uint32(r.byte())
+		return (b0&0x1F)<<24 | b1<<16 | b2<<8 | b3
+	}
+}
+
+// fail records the first decoding error.
+func (r *sigReader) fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+// primitiveTypes names the built-in element types.
+var primitiveTypes = map[byte]string{
+	0x01: "void", 0x02: "bool", 0x03: "char", 0x04: "sbyte", 0x05: "byte",
+	0x06: "short", 0x07: "ushort", 0x08: "int", 0x09: "uint", 0x0A: "long",
+	0x0B: "ulong", 0x0C: "float", 0x0D: "double", 0x0E: "string",
+	0x16: "typedref", 0x18: "nint", 0x19: "nuint", 0x1C: "object",
+}
+
+// typ decodes a type.
+func (r *sigReader) typ() string {
+	if r.depth++; r.depth > maxSignatureDepth {
+		r.fail(fmt.Errorf("signature is too deeply nested"))
+	}
+	defer func() { r.depth-- }()
+
+	e := r.byte()
+	if r.err != nil {
+		return ""
+	}
+	if name, ok := primitiveTypes[e]; ok {
+		return name
+	}
+	switch e {
+	case 0x0F: // PTR
+		return r.typ() + "*"
+	case 0x10: // BYREF
+		return "ref " + r.typ()
+	case 0x11, 0x12: // VALUETYPE, CLASS
+		return r.typeToken()
+	case 0x13: // VAR
+		return fmt.Sprintf("!%d", r.uint())
+	case 0x1E: // MVAR
+		return fmt.Sprintf("!!%d", r.uint())
+	case 0x1D: // SZARRAY
+		return r.typ() + "[]"
+	case 0x14: // ARRAY
+		elem := r.typ()
+		rank := r.uint()
+		for n := r.uint(); n > 0 && r.err == nil; n-- {
+			r.uint()
+		}
+		for n := r.uint(); n > 0 && r.err == nil; n-- {
+			r.uint()
+		}
+		return elem + "[" + strings.Repeat(",", int(min(max(rank, 1), 32))-1) + "]"
+	case 0x15: // GENERICINST
+		r.byte()
+		name := r.typeToken()
+		if i := strings.LastIndexByte(name, '`'); i > strings.LastIndexByte(name, '+') {
+			name = name[:i]
+		}
+		var args []string
+		for n := r.uint(); n > 0 && r.err == nil; n-- {
+			args = append(args, r.typ())
+		}
+		return name + "<" + strings.Join(args, ", ") + ">"
+	case 0x1B: // FNPTR
+		generic, params, ret := r.methodSig()
+		return "method " + formatMethod("*", generic, params, ret)
+	case 0x1F, 0x20: // CMOD_REQD, CMOD_OPT
+		r.uint()
+		return r.typ()
+	case 0x41, 0x45: // SENTINEL, PINNED
+		return r.typ()
+	default:
+		r.fail(fmt.Errorf("unknown element type %#x", e))
+		return ""
+	}
+}
+
+// typeToken decodes a TypeDefOrRefOrSpec token into a type name.
+func (r *sigReader) typeToken() string {
+	token := r.uint()
+	if r.err != nil {
+		return ""
+	}
+	var name string
+	var err error
+	switch token & 3 {
+	case 0:
+		name, err = r.m.typeDefName(token>>2, 0)
+	case 1:
+		name, err = r.m.typeRefName(token>>2, 0)
+	case 2:
+		name, err = r.typeSpec(token >> 2)
+	default:
+		err = fmt.Errorf("invalid type token %#x", token)
+	}
+	r.fail(err)
+	return name
+}
+
+// typeSpec decodes a TypeSpec row, such as a generic instantiation.
+func (r *sigReader) typeSpec(i uint32) (string, error) {
+	row, err := r.m.row(tableTypeSpec, i)
+	if err != nil {
+		return "", err
+	}
+	sig, err := r.m.blob((&columns{b: row}).next(r.m.widths.blob))
+	if err != nil {
+		return "", err
+	}
+	spec := &sigReader{m: r.m, b: sig, depth: r.depth}
+	name := spec.typ()
+	return name, spec.err
+}
+
+// methodSig decodes a method signature into its generic arity, parameter
+// types and return type.
+func (r *sigReader) methodSig() (int, []string, string) {
+	generic := 0
+	if r.byte()&0x10 != 0 {
+		generic = int(r.uint())
+	}
+	count := r.uint()
+	ret := r.typ()
+	var params []string
+	for ; count > 0 && r.err == nil; count-- {
+		params = append(params, r.typ())
+	}
+	return generic, params, ret
+}
+
+// formatMethod renders a method as Name<arity>(params): ret.
+func formatMethod(name string, generic int, params []string, ret string) string {
+	if generic > 0 {
+		name += fmt.Sprintf("<%d>", generic)
+	}
+	return fmt.Sprintf("%s(%s): %s", name, strings.Join(params, ", "), ret)
+}
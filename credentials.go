@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultGitHubAPIURL is the GitHub REST API used to check GitHub Packages tokens.
+const DefaultGitHubAPIURL = "https://api.github.com"
+
+// githubPackagesScope is the classic token scope required to push packages.
+const githubPackagesScope = "write:packages"
+
+// tokenExpiryWarning is how close to expiry a token must be for preflight to warn about it.
+const tokenExpiryWarning = 7 * 24 * time.Hour
+
+// githubExpirationLayout is the format of the GitHub-Authentication-Token-Expiration header.
+const githubExpirationLayout = "2006-01-02 15:04:05 MST"
+
+// tokenInfo describes what a feed revealed about a credential.
+type tokenInfo struct {
+	Status  apiKeyStatus
+	Scopes  []string
+	Expires time.Time
+}
+
+// checkGitHubToken asks the GitHub API about the token used for GitHub Packages.
+// Classic tokens report their scopes, which must include write:packages; tokens
+// with an expiry report it in a response header. Tokens that cannot call /user,
+// such as the Actions GITHUB_TOKEN, are reported as unverified.
+func (c *feedClient) checkGitHubToken(ctx context.Context) (tokenInfo, error) {
+	info := tokenInfo{Status: apiKeyUnverified}
+
+	endpoint := strings.TrimSuffix(c.githubAPI, "/") + "/user"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return info, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return info, fmt.Errorf("failed to check GitHub token: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxFeedResponseSize))
+
+	if expires := resp.Header.Get("GitHub-Authentication-Token-Expiration"); expires != "" {
+		if t, err := time.Parse(githubExpirationLayout, expires); err == nil {
+			info.Expires = t
+		}
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized:
+		info.Status = apiKeyInvalid
+		return info, nil
+	default:
+		return info, nil
+	}
+
+	scopes, classic := resp.Header["X-Oauth-Scopes"]
+	if !classic {
+		// Fine-grained and app tokens do not report scopes.
+		info.Status = apiKeyValid
+		return info, nil
+	}
+	for _, scope := range strings.Split(strings.Join(scopes, ","), ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			info.Scopes = append(info.Scopes, scope)
+		}
+	}
+	info.Status = apiKeyOutOfScope
+	if stringInSlice(info.Scopes, githubPackagesScope) {
+		info.Status = apiKeyValid
+	}
+	return info, nil
+}
+
+// checkCredentials checks the credential of each package against the feed where
+// the feed exposes a way to do so, recording the outcome on check. It returns an
+// error describing why a credential cannot push, and a warning for credentials
+// that work but are about to expire.
+func (p *NuGetPlugin) checkCredentials(ctx context.Context, cfg *Config, client *feedClient, check *feedCheck, packages []string) (warning string, err error) {
+	type credential struct {
+		id  packageIdentity
+		key string
+	}
+	var credentials []credential
+	for _, pkg := range packages {
+		id, _ := readPackageIdentity(pkg)
+		key, _, err := cfg.apiKeyFor(id.ID)
+		if err != nil {
+			return "", err
+		}
+		credentials = append(credentials, credential{id: id, key: key})
+	}
+
+	switch client.kind {
+	case feedKindGitHub:
+		checked := map[string]bool{}
+		var warnings []string
+		for _, c := range credentials {
+			if checked[c.key] {
+				continue
+			}
+			checked[c.key] = true
+			warning, err := checkGitHubCredential(ctx, client.withAPIKey(c.key), check)
+			if err != nil {
+				return "", err
+			}
+			if warning != "" {
+				warnings = append(warnings, warning)
+			}
+		}
+		return strings.Join(warnings, "; "), nil
+
+	case feedKindNuGetOrg:
+		for _, c := range credentials {
+			if c.id.ID == "" {
+				continue
+			}
+			status, err := client.withAPIKey(c.key).checkAPIKey(ctx, check.PublishURL, c.id)
+			if err != nil || status == apiKeyUnverified {
+				continue
+			}
+			check.APIKey = status
+			switch status {
+			case apiKeyInvalid:
+				return "", fmt.Errorf("API key was rejected for %s; it may be expired or revoked", c.id)
+			case apiKeyOutOfScope:
+				return "", fmt.Errorf("API key is not scoped to push %s", c.id)
+			}
+		}
+	}
+	return "", nil
+}
+
+// checkGitHubCredential checks a GitHub Packages token and records what GitHub reports about it.
+func checkGitHubCredential(ctx context.Context, client *feedClient, check *feedCheck) (warning string, err error) {
+	info, err := client.checkGitHubToken(ctx)
+	if err != nil {
+		return fmt.Sprintf("could not check GitHub token: %v", err), nil
+	}
+	check.APIKey = info.Status
+	check.Scopes = info.Scopes
+	if !info.Expires.IsZero() {
+		check.Expires = info.Expires.UTC().Format(time.RFC3339)
+	}
+
+	switch info.Status {
+	case apiKeyInvalid:
+		return "", fmt.Errorf("GitHub token was rejected; it may be expired or revoked")
+	case apiKeyOutOfScope:
+		return "", fmt.Errorf("GitHub token lacks the %s scope (has: %s)", githubPackagesScope, strings.Join(info.Scopes, ", "))
+	}
+	if !info.Expires.IsZero() && time.Until(info.Expires) < tokenExpiryWarning {
+		return fmt.Sprintf("GitHub token expires at %s", check.Expires), nil
+	}
+	return "", nil
+}
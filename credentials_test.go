@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckGitHubToken(t *testing.T) {
+	soon := time.Now().Add(48 * time.Hour).UTC().Format(githubExpirationLayout)
+	later := time.Now().Add(90 * 24 * time.Hour).UTC().Format(githubExpirationLayout)
+	packages := []string{writeTestPackage(t, t.TempDir(), "Contoso.Core", "1.0.0", nil)}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/user" {
+			http.NotFound(w, r)
+			return
+		}
+		switch strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ") {
+		case "classic":
+			w.Header().Set("X-OAuth-Scopes", "repo, write:packages")
+			w.Header().Set("GitHub-Authentication-Token-Expiration", later)
+		case "expiring":
+			w.Header().Set("X-OAuth-Scopes", "write:packages")
+			w.Header().Set("GitHub-Authentication-Token-Expiration", soon)
+		case "read-only":
+			w.Header().Set("X-OAuth-Scopes", "read:packages")
+		case "fine-grained":
+		case "actions":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		token       string
+		wantStatus  apiKeyStatus
+		wantErr     string
+		wantWarning string
+	}{
+		{name: "classic token with package scope", token: "classic", wantStatus: apiKeyValid},
+		{name: "token about to expire", token: "expiring", wantStatus: apiKeyValid, wantWarning: "GitHub token expires at"},
+		{name: "token without package scope", token: "read-only", wantStatus: apiKeyOutOfScope, wantErr: "lacks the write:packages scope"},
+		{name: "fine-grained token", token: "fine-grained", wantStatus: apiKeyValid},
+		{name: "actions token cannot be checked", token: "actions", wantStatus: apiKeyUnverified},
+		{name: "revoked token", token: "revoked", wantStatus: apiKeyInvalid, wantErr: "rejected"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &feedClient{httpClient: server.Client(), kind: feedKindGitHub, githubAPI: server.URL}
+			check := feedCheck{APIKey: apiKeyUnverified}
+
+			warning, err := (&NuGetPlugin{}).checkCredentials(context.Background(), &Config{APIKey: tt.token}, client, &check, packages)
+			if check.APIKey != tt.wantStatus {
+				t.Errorf("expected status %s, got %s", tt.wantStatus, check.APIKey)
+			}
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+			if !strings.Contains(warning, tt.wantWarning) || (tt.wantWarning == "" && warning != "") {
+				t.Errorf("expected warning %q, got %q", tt.wantWarning, warning)
+			}
+		})
+	}
+}
+
+func TestCheckCredentialsNuGetOrgScopes(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTestPackage(t, tmpDir, "Contoso.Core", "1.0.0", nil)
+	writeTestPackage(t, tmpDir, "Fabrikam.Tools", "1.0.0", nil)
+	packages, err := filepath.Glob(filepath.Join(tmpDir, "*.nupkg"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The key is scoped to Contoso.* only.
+		if strings.Contains(r.URL.Path, "/verifykey/Contoso.") {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	client := &feedClient{httpClient: server.Client(), kind: feedKindNuGetOrg}
+	check := feedCheck{PublishURL: server.URL + "/api/v2/package", APIKey: apiKeyUnverified}
+
+	_, err = (&NuGetPlugin{}).checkCredentials(context.Background(), &Config{APIKey: "scoped"}, client, &check, packages)
+	if err == nil || !strings.Contains(err.Error(), "not scoped to push Fabrikam.Tools") {
+		t.Errorf("expected scope error for Fabrikam.Tools, got %v", err)
+	}
+	if check.APIKey != apiKeyOutOfScope {
+		t.Errorf("expected out_of_scope status, got %s", check.APIKey)
+	}
+}
+
+func TestCheckCredentialsNuGetOrgUnpublishedVersion(t *testing.T) {
+	packages := []string{writeTestPackage(t, t.TempDir(), "Contoso.Core", "2.0.0", nil)}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The version being pushed is not on the feed yet.
+		if strings.HasSuffix(r.URL.Path, "/2.0.0") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("X-NuGet-ApiKey") != "good" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		apiKey     string
+		wantStatus apiKeyStatus
+		wantErr    string
+	}{
+		{name: "valid key", apiKey: "good", wantStatus: apiKeyValid},
+		{name: "revoked key", apiKey: "revoked", wantStatus: apiKeyInvalid, wantErr: "API key was rejected for Contoso.Core 2.0.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &feedClient{httpClient: server.Client(), kind: feedKindNuGetOrg}
+			check := feedCheck{PublishURL: server.URL + "/api/v2/package", APIKey: apiKeyUnverified}
+
+			_, err := (&NuGetPlugin{}).checkCredentials(context.Background(), &Config{APIKey: tt.apiKey}, client, &check, packages)
+			if check.APIKey != tt.wantStatus {
+				t.Errorf("expected status %s, got %s", tt.wantStatus, check.APIKey)
+			}
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
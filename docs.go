@@ -0,0 +1,289 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Policies for readme content that nuget.org will not render as written.
+const (
+	// ReadmeIssueFail fails the package.
+	ReadmeIssueFail = "fail"
+	// ReadmeIssueWarn publishes the package with a warning.
+	ReadmeIssueWarn = "warn"
+)
+
+// Kinds of package documentation issues.
+const (
+	// docMissingFile is a readme or license file the nuspec references but the package lacks.
+	docMissingFile = "missing_file"
+	// docReadmeFormat is a readme that is not a Markdown file.
+	docReadmeFormat = "readme_format"
+	// docLicenseExpression is a license expression that is not valid SPDX.
+	docLicenseExpression = "license_expression"
+	// docRelativeLink is a readme link that does not resolve on nuget.org.
+	docRelativeLink = "relative_link"
+	// docRelativeImage is a readme image that does not resolve on nuget.org.
+	docRelativeImage = "relative_image"
+	// docInsecureImage is a readme image that is not served over HTTPS.
+	docInsecureImage = "insecure_image"
+	// docHTML is raw HTML that nuget.org strips from the readme.
+	docHTML = "html"
+)
+
+// maxReadmeSize is the largest readme nuget.org accepts.
+const maxReadmeSize = 1 << 20
+
+// Patterns locating links, images and HTML in a Markdown readme.
+var (
+	markdownLink       = regexp.MustCompile(`(!?)\[((?:[^\[\]]|!\[[^\[\]]*\]\([^)]*\))*)\]\(\s*<?([^)\s>]*)>?(?:\s+["'(][^)]*)?\)`)
+	markdownReference  = regexp.MustCompile(`^\s{0,3}\[[^\]]+\]:\s*<?([^\s>]+)>?`)
+	markdownFence      = regexp.MustCompile("^\\s{0,3}(```|~~~)")
+	markdownCodeSpan   = regexp.MustCompile("`+[^`]*`+")
+	markdownHTMLTag    = regexp.MustCompile(`<([A-Za-z][A-Za-z0-9-]*)(\s[^<>]*)?/?>`)
+	absoluteLinkScheme = regexp.MustCompile(`(?i)^(?:https?|mailto):`)
+)
+
+// htmlElements are the HTML element names reported in readmes. Other names in
+// angle brackets, such as the T in List<T>, are text rather than HTML.
+var htmlElements = map[string]bool{
+	"a": true, "abbr": true, "address": true, "article": true, "aside": true, "audio": true,
+	"b": true, "bdi": true, "bdo": true, "blockquote": true, "br": true, "button": true,
+	"caption": true, "center": true, "cite": true, "code": true, "col": true, "colgroup": true,
+	"dd": true, "del": true, "details": true, "dfn": true, "div": true, "dl": true, "dt": true,
+	"em": true, "embed": true, "figcaption": true, "figure": true, "font": true, "footer": true, "form": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "header": true, "hr": true,
+	"i": true, "iframe": true, "img": true, "input": true, "ins": true, "kbd": true, "li": true,
+	"mark": true, "nav": true, "object": true, "ol": true, "p": true, "picture": true, "pre": true,
+	"q": true, "s": true, "samp": true, "script": true, "section": true, "small": true, "source": true,
+	"span": true, "strike": true, "strong": true, "style": true, "sub": true, "summary": true, "sup": true,
+	"svg": true, "table": true, "tbody": true, "td": true, "tfoot": true, "th": true, "thead": true,
+	"tr": true, "tt": true, "u": true, "ul": true, "var": true, "video": true,
+}
+
+// isHTMLTag reports whether a tag found in a readme line is HTML: a known
+// element written in lower case, or with attributes. Generic type arguments
+// such as Task<Input> are usually capitalized and have no attributes.
+func isHTMLTag(name, attributes string) bool {
+	if !htmlElements[strings.ToLower(name)] {
+		return false
+	}
+	return name == strings.ToLower(name) || strings.TrimSpace(attributes) != ""
+}
+
+// DocIssue is a problem with the readme or license a package ships.
+type DocIssue struct {
+	File   string `json:"file"`
+	Line   int    `json:"line,omitempty"`
+	Kind   string `json:"kind"`
+	Detail string `json:"detail"`
+}
+
+// String describes the issue in one line.
+func (i DocIssue) String() string {
+	if i.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", i.File, i.Line, i.Detail)
+	}
+	return fmt.Sprintf("%s: %s", i.File, i.Detail)
+}
+
+// rendering reports whether the issue only affects how nuget.org displays the
+// readme, as opposed to a package nuget.org rejects.
+func (i DocIssue) rendering() bool {
+	switch i.Kind {
+	case docRelativeLink, docRelativeImage, docInsecureImage, docHTML:
+		return true
+	}
+	return false
+}
+
+// nuspecFileEntry normalizes a nuspec file reference to a package entry name.
+func nuspecFileEntry(ref string) string {
+	ref = strings.ReplaceAll(strings.TrimSpace(ref), `\`, "/")
+	return strings.TrimPrefix(path.Clean("/"+ref), "/")
+}
+
+// packageDocIssues checks the readme and license a package's nuspec references.
+// nuget.org additionally rejects custom LicenseRef- license identifiers.
+func packageDocIssues(packagePath string, nugetOrg bool) ([]DocIssue, error) {
+	r, err := zip.OpenReader(packagePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open package: %w", err)
+	}
+	defer func() { _ = r.Close() }()
+
+	doc, err := readNuspec(packagePath)
+	if err != nil {
+		return nil, err
+	}
+	nuspecName := findNuspecEntry(&r.Reader).Name
+	entries := map[string]*zip.File{}
+	for _, f := range r.File {
+		entries[f.Name] = f
+	}
+
+	var issues []DocIssue
+	meta := doc.Metadata
+	if ref := strings.TrimSpace(meta.Readme); ref != "" {
+		readme := entries[nuspecFileEntry(ref)]
+		switch {
+		case readme == nil:
+			issues = append(issues, DocIssue{File: nuspecName, Kind: docMissingFile, Detail: fmt.Sprintf("readme %s is not in the package", ref)})
+		case !strings.EqualFold(path.Ext(readme.Name), ".md"):
+			issues = append(issues, DocIssue{File: readme.Name, Kind: docReadmeFormat, Detail: "readme must be a Markdown (.md) file"})
+		case readme.UncompressedSize64 > maxReadmeSize:
+			issues = append(issues, DocIssue{File: readme.Name, Kind: docReadmeFormat, Detail: "readme is larger than 1 MB"})
+		default:
+			data, err := readZipEntry(readme)
+			if err != nil {
+				return nil, err
+			}
+			issues = append(issues, readmeIssues(readme.Name, data)...)
+		}
+	}
+
+	license := strings.TrimSpace(meta.License.Value)
+	switch meta.License.Type {
+	case "file":
+		if entries[nuspecFileEntry(license)] == nil {
+			issues = append(issues, DocIssue{File: nuspecName, Kind: docMissingFile, Detail: fmt.Sprintf("license file %s is not in the package", license)})
+		}
+	case "expression":
+		parsed, err := parseLicenseExpression(license)
+		switch {
+		case err != nil:
+			issues = append(issues, DocIssue{File: nuspecName, Kind: docLicenseExpression, Detail: err.Error()})
+		case nugetOrg && len(parsed.licenseRefs()) > 0:
+			issues = append(issues, DocIssue{File: nuspecName, Kind: docLicenseExpression,
+				Detail: fmt.Sprintf("nuget.org does not accept custom license identifiers (%s); ship the license as a file instead", strings.Join(parsed.licenseRefs(), ", "))})
+		}
+	}
+	return issues, nil
+}
+
+// readmeIssues finds links, images and HTML in a Markdown readme that
+// nuget.org will not render as written. Code blocks and code spans are ignored.
+func readmeIssues(name string, data []byte) []DocIssue {
+	var issues []DocIssue
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64<<10), maxReadmeSize)
+	inFence := false
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if markdownFence.MatchString(text) {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		text = markdownCodeSpan.ReplaceAllString(text, "")
+
+		for _, issue := range markdownLinkIssues(text) {
+			issue.File, issue.Line = name, line
+			issues = append(issues, issue)
+		}
+		if m := markdownReference.FindStringSubmatch(text); m != nil {
+			if issue, ok := linkIssue(m[1], false); ok {
+				issue.File, issue.Line = name, line
+				issues = append(issues, issue)
+			}
+		}
+		for _, m := range markdownHTMLTag.FindAllStringSubmatch(text, -1) {
+			if !isHTMLTag(m[1], m[2]) {
+				continue
+			}
+			tag := strings.ToLower(m[1])
+			detail := fmt.Sprintf("HTML tag <%s> is stripped by nuget.org", tag)
+			if tag == "img" {
+				detail += "; use Markdown image syntax instead"
+			}
+			issues = append(issues, DocIssue{File: name, Line: line, Kind: docHTML, Detail: detail})
+		}
+	}
+	return issues
+}
+
+// markdownLinkIssues checks the inline links and images on a line, including
+// images used as link text such as badges.
+func markdownLinkIssues(text string) []DocIssue {
+	var issues []DocIssue
+	for _, m := range markdownLink.FindAllStringSubmatch(text, -1) {
+		if m[1] == "" {
+			issues = append(issues, markdownLinkIssues(m[2])...)
+		}
+		if issue, ok := linkIssue(m[3], m[1] == "!"); ok {
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+// linkIssue reports a link or image target that does not resolve on nuget.org.
+// Absolute http(s) and mailto links and in-page anchors are fine; images must
+// use HTTPS.
+func linkIssue(target string, image bool) (DocIssue, bool) {
+	switch {
+	case target == "" || (!image && strings.HasPrefix(target, "#")):
+		return DocIssue{}, false
+	case !absoluteLinkScheme.MatchString(target):
+		if image {
+			return DocIssue{Kind: docRelativeImage, Detail: fmt.Sprintf("relative image %s will not resolve on nuget.org", target)}, true
+		}
+		return DocIssue{Kind: docRelativeLink, Detail: fmt.Sprintf("relative link %s will not resolve on nuget.org", target)}, true
+	case image && !strings.HasPrefix(strings.ToLower(target), "https:"):
+		return DocIssue{Kind: docInsecureImage, Detail: fmt.Sprintf("image %s is not served over HTTPS and will not be shown on nuget.org", target)}, true
+	}
+	return DocIssue{}, false
+}
+
+// checkPackageDocs checks the readme and license of every pending package.
+// Missing files and invalid license expressions fail the package (would-fail
+// in a dry run), as nuget.org rejects them; rendering issues fail it only when
+// on_readme_issue is fail and are added as warnings otherwise. Failed packages
+// are returned.
+func checkPackageDocs(cfg *Config, results []PackageResult, dryRun bool) []string {
+	if !cfg.CheckPackageDocs {
+		return nil
+	}
+
+	var flagged []string
+	for i := range results {
+		result := &results[i]
+		if result.Status != StatusNotAttempted || isSymbolsPackage(result.Path) {
+			continue
+		}
+		issues, err := packageDocIssues(result.Path, cfg.feedKind() == feedKindNuGetOrg)
+		if err != nil {
+			result.Message = joinReasons(result.Message, "readme and license check skipped: "+err.Error())
+			continue
+		}
+		result.DocIssues = issues
+
+		var failing []string
+		for _, issue := range issues {
+			if issue.rendering() && cfg.OnReadmeIssue != ReadmeIssueFail {
+				result.Warnings = append(result.Warnings, issue.String())
+				continue
+			}
+			failing = append(failing, issue.String())
+		}
+		if len(failing) == 0 {
+			continue
+		}
+
+		msg := "package readme or license is invalid: " + strings.Join(failing, "; ")
+		result.Status = StatusFailed
+		if dryRun {
+			result.Status = StatusWouldFail
+		}
+		result.setError(ErrorKindPackageDocs, msg)
+		flagged = append(flagged, packageLabel(*result))
+	}
+	return flagged
+}
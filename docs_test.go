@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// docsNuspec returns a nuspec with the given readme and license elements.
+func docsNuspec(id, version, readme, license string) string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://schemas.microsoft.com/packaging/2013/05/nuspec.xsd">
+  <metadata>
+    <id>%s</id>
+    <version>%s</version>
+    %s
+    %s
+  </metadata>
+</package>`, id, version, readme, license)
+}
+
+func TestReadmeIssues(t *testing.T) {
+	readme := strings.Join([]string{
+		"# Contoso.Core",
+		"[![Build](https://img.shields.io/badge/build-passing-green)](https://github.com/contoso/core)",
+		"![Logo](docs/logo.png) and ![Old](http://contoso.com/old.png)",
+		"See the [guide](docs/guide.md), the [API](https://contoso.com/api) and [below](#usage).",
+		"[![Badge](badge.svg)](CONTRIBUTING.md)",
+		"<details><summary>More</summary>",
+		"<img src=\"https://contoso.com/a.png\"> and <https://contoso.com> and `<b>` in code",
+		"```xml",
+		"<PackageReference Include=\"Contoso.Core\" />",
+		"[skipped](relative.md)",
+		"```",
+		"[changelog]: CHANGELOG.md",
+		"Returns a List<T>, a Task<Input> or a Dictionary<string, Option>.",
+		"<IMG SRC=\"https://contoso.com/b.png\">",
+	}, "\n")
+
+	var got []string
+	for _, issue := range readmeIssues("README.md", []byte(readme)) {
+		got = append(got, issue.Kind+" "+issue.String())
+	}
+	want := []string{
+		"relative_image README.md:3: relative image docs/logo.png will not resolve on nuget.org",
+		"insecure_image README.md:3: image http://contoso.com/old.png is not served over HTTPS and will not be shown on nuget.org",
+		"relative_link README.md:4: relative link docs/guide.md will not resolve on nuget.org",
+		"relative_image README.md:5: relative image badge.svg will not resolve on nuget.org",
+		"relative_link README.md:5: relative link CONTRIBUTING.md will not resolve on nuget.org",
+		"html README.md:6: HTML tag <details> is stripped by nuget.org",
+		"html README.md:6: HTML tag <summary> is stripped by nuget.org",
+		"html README.md:7: HTML tag <img> is stripped by nuget.org; use Markdown image syntax instead",
+		"relative_link README.md:12: relative link CHANGELOG.md will not resolve on nuget.org",
+		"html README.md:14: HTML tag <img> is stripped by nuget.org; use Markdown image syntax instead",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected issues:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestPackageDocIssues(t *testing.T) {
+	tests := []struct {
+		name     string
+		readme   string
+		license  string
+		files    map[string]string
+		nugetOrg bool
+		want     []string
+	}{
+		{
+			name:    "valid readme and license file",
+			readme:  `<readme>docs\README.md</readme>`,
+			license: `<license type="file">LICENSE.txt</license>`,
+			files:   map[string]string{"docs/README.md": "# Contoso", "LICENSE.txt": "MIT"},
+		},
+		{
+			name:    "missing files",
+			readme:  `<readme>README.md</readme>`,
+			license: `<license type="file">LICENSE.txt</license>`,
+			want: []string{
+				"missing_file Contoso.Core.nuspec: readme README.md is not in the package",
+				"missing_file Contoso.Core.nuspec: license file LICENSE.txt is not in the package",
+			},
+		},
+		{
+			name:   "readme is not markdown",
+			readme: `<readme>README.txt</readme>`,
+			files:  map[string]string{"README.txt": "Contoso"},
+			want:   []string{"readme_format README.txt: readme must be a Markdown (.md) file"},
+		},
+		{
+			name:    "invalid license expression",
+			license: `<license type="expression">MIT and Apache-2.0</license>`,
+			want:    []string{`license_expression Contoso.Core.nuspec: unexpected "and" in license expression`},
+		},
+		{
+			name:    "valid license expression",
+			license: `<license type="expression">MIT OR Apache-2.0</license>`,
+		},
+		{
+			name:    "custom license identifier on another feed",
+			license: `<license type="expression">MIT OR LicenseRef-Contoso</license>`,
+		},
+		{
+			name:     "custom license identifier on nuget.org",
+			license:  `<license type="expression">MIT OR LicenseRef-Contoso</license>`,
+			nugetOrg: true,
+			want:     []string{"license_expression Contoso.Core.nuspec: nuget.org does not accept custom license identifiers (LicenseRef-Contoso); ship the license as a file instead"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg := writeTestPackageWithNuspec(t, t.TempDir(), "Contoso.Core", "1.0.0",
+				docsNuspec("Contoso.Core", "1.0.0", tt.readme, tt.license), tt.files)
+			issues, err := packageDocIssues(pkg, tt.nugetOrg)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got []string
+			for _, issue := range issues {
+				got = append(got, issue.Kind+" "+issue.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("unexpected issues:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestExecutePackageDocsCheck(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      map[string]any
+		license     string
+		wantErr     string
+		wantWarning bool
+		wantPushes  int
+	}{
+		{name: "readme issues warn by default", license: "MIT", config: map[string]any{"check_package_docs": true}, wantWarning: true, wantPushes: 1},
+		{name: "readme issues fail when configured", license: "MIT", config: map[string]any{"check_package_docs": true, "on_readme_issue": "fail"}, wantErr: "relative image logo.png will not resolve"},
+		{name: "invalid license fails", license: "MIT/Apache", config: map[string]any{"check_package_docs": true}, wantErr: `"MIT/Apache" is not a valid license identifier`},
+		{name: "check off by default", license: "MIT/Apache", wantPushes: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg := writeTestPackageWithNuspec(t, t.TempDir(), "Contoso.Core", "1.0.0",
+				docsNuspec("Contoso.Core", "1.0.0", "<readme>README.md</readme>", `<license type="expression">`+tt.license+`</license>`),
+				map[string]string{"README.md": "![Logo](logo.png)"})
+
+			config := map[string]any{
+				"api_key":      "test-key",
+				"source":       "https://127.0.0.1/v3/index.json",
+				"package_path": pkg,
+			}
+			for k, v := range tt.config {
+				config[k] = v
+			}
+			mockExec := &MockCommandExecutor{}
+			p := &NuGetPlugin{cmdExecutor: mockExec}
+			resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+				Hook:    plugin.HookPostPublish,
+				Config:  config,
+				Context: plugin.ReleaseContext{Version: "v1.0.0"},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(mockExec.Calls) != tt.wantPushes {
+				t.Errorf("expected %d pushes, got %d", tt.wantPushes, len(mockExec.Calls))
+			}
+			if tt.wantErr != "" {
+				if resp.Success || !strings.Contains(resp.Error, "readme or license check failed for package(s): Contoso.Core 1.0.0") {
+					t.Fatalf("expected the readme and license check to fail, got success=%v error=%s", resp.Success, resp.Error)
+				}
+				result := resp.Outputs["packages"].([]PackageResult)[0]
+				if result.ErrorClass != string(ErrorKindPackageDocs) || !strings.Contains(result.Error, tt.wantErr) {
+					t.Errorf("expected %s error containing %q, got %s: %s", ErrorKindPackageDocs, tt.wantErr, result.ErrorClass, result.Error)
+				}
+				return
+			}
+			if !resp.Success {
+				t.Fatalf("expected success, got error: %s", resp.Error)
+			}
+			warned := strings.Contains(resp.Message, "README.md:1: relative image logo.png will not resolve on nuget.org")
+			if warned != tt.wantWarning {
+				t.Errorf("warning in message = %v, want %v: %s", warned, tt.wantWarning, resp.Message)
+			}
+		})
+	}
+}
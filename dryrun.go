@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// feedCheck summarizes the feed-level checks performed during a dry run.
+type feedCheck struct {
+	Reachable  bool         `json:"reachable"`
+	PublishURL string       `json:"publish_url,omitempty"`
+	APIKey     apiKeyStatus `json:"api_key"`
+	Scopes     []string     `json:"scopes,omitempty"`
+	Expires    string       `json:"expires,omitempty"`
+	Error      string       `json:"error,omitempty"`
+}
+
+// planPush checks each package against the feed without pushing anything,
+// recording the predicted outcome in the package results.
+func (p *NuGetPlugin) planPush(ctx context.Context, cfg *Config, results []PackageResult) feedCheck {
+	client := p.newFeedClient(cfg)
+	check := feedCheck{APIKey: apiKeyUnverified}
+
+	for i := range results {
+		if results[i].Status != StatusNotAttempted {
+			continue
+		}
+		results[i].Status = StatusWouldPush
+		if results[i].ID == "" {
+			_, err := readPackageIdentity(results[i].Path)
+			if err == nil {
+				err = fmt.Errorf("failed to read package identity from %s: package ID is empty", results[i].Path)
+			}
+			results[i].Status = StatusWouldFail
+			results[i].setError(ErrorKindInvalidPackage, err.Error())
+		}
+	}
+
+	index, err := client.serviceIndex(ctx)
+	if err != nil {
+		check.Error = err.Error()
+		for i := range results {
+			if results[i].Status == StatusWouldPush {
+				results[i].Status = StatusWouldFail
+				results[i].setError(ErrorKindNetwork, fmt.Sprintf("feed unreachable: %v", err))
+			}
+		}
+		return check
+	}
+	check.Reachable = true
+	check.PublishURL = index.resourceURL(resourcePackagePublish)
+
+	if check.PublishURL == "" {
+		check.Error = "service index does not advertise a PackagePublish resource"
+	}
+
+	baseAddress := index.resourceURL(resourcePackageBaseAddress)
+	for i := range results {
+		result := &results[i]
+		if result.Status != StatusWouldPush {
+			continue
+		}
+		id := packageIdentity{ID: result.ID, Version: result.Version}
+
+		if check.PublishURL == "" {
+			result.Status = StatusWouldFail
+			result.setError(ErrorKindFeed, check.Error)
+			continue
+		}
+
+		if baseAddress != "" {
+			exists, err := client.versionExists(ctx, baseAddress, id)
+			switch {
+			case err != nil:
+				result.Message = fmt.Sprintf("could not check for existing version: %v", err)
+			case exists:
+				p.planDuplicate(ctx, cfg, client, baseAddress, result)
+				continue
+			}
+		}
+
+		status, err := client.withAPIKey(result.apiKey).checkAPIKey(ctx, check.PublishURL, id)
+		if err != nil {
+			result.Message = joinReasons(result.Message, fmt.Sprintf("could not verify API key: %v", err))
+			continue
+		}
+		if status != apiKeyUnverified {
+			check.APIKey = status
+		}
+		switch status {
+		case apiKeyInvalid:
+			result.Status = StatusWouldFail
+			result.setError(ErrorKindAuth, "API key was rejected for this package")
+		case apiKeyOutOfScope:
+			result.Status = StatusWouldFail
+			result.setError(ErrorKindAuth, "API key is not scoped to push this package")
+		}
+	}
+
+	return check
+}
+
+// planDuplicate predicts how the on_duplicate policy treats a version that already exists.
+func (p *NuGetPlugin) planDuplicate(ctx context.Context, cfg *Config, client *feedClient, baseAddress string, result *PackageResult) {
+	switch cfg.duplicatePolicy() {
+	case OnDuplicateSkip:
+		result.Status = StatusWouldSkip
+		result.Message = "version already exists on feed"
+	case OnDuplicateSkipIfIdentical:
+		id := packageIdentity{ID: result.ID, Version: result.Version}
+		identical, err := client.compareWithPublished(ctx, baseAddress, id, result.pushPath())
+		switch {
+		case err != nil:
+			result.Status = StatusWouldFail
+			result.setError(ErrorKindDuplicate, fmt.Sprintf("version already exists and could not be compared: %v", err))
+		case identical:
+			result.Status = StatusWouldSkip
+			result.Message = "identical package already published"
+		default:
+			result.Status = StatusWouldFail
+			result.setError(ErrorKindDuplicate, "a different package is already published under this version")
+		}
+	default:
+		result.Status = StatusWouldFail
+		result.setError(ErrorKindDuplicate, "version already exists on feed (set on_duplicate to skip it)")
+	}
+}
+
+// dryRunMessage builds the human-readable summary of a dry run.
+func dryRunMessage(summary RunSummary) string {
+	msg := fmt.Sprintf("Would push %d package(s) to NuGet", summary.Pushed)
+	var extra []string
+	if summary.Skipped > 0 {
+		extra = append(extra, fmt.Sprintf("%d skipped as duplicate", summary.Skipped))
+	}
+	if summary.Failed > 0 {
+		extra = append(extra, fmt.Sprintf("%d would fail", summary.Failed))
+	}
+	if len(extra) > 0 {
+		msg += " (" + strings.Join(extra, ", ") + ")"
+	}
+	return msg
+}
+
+// joinReasons concatenates non-empty reason strings.
+func joinReasons(a, b string) string {
+	if a == "" {
+		return b
+	}
+	return a + "; " + b
+}
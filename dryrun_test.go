@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestDryRunChecksFeed(t *testing.T) {
+	tests := []struct {
+		name          string
+		skipDuplicate bool
+		published     []packageIdentity
+		noPublish     bool
+		wantMsg       string
+		wantStatuses  map[string]PackageStatus
+	}{
+		{
+			name:         "new versions would be pushed",
+			wantMsg:      "Would push 2 package(s) to NuGet",
+			wantStatuses: map[string]PackageStatus{"Contoso.Core": StatusWouldPush, "Contoso.Utils": StatusWouldPush},
+		},
+		{
+			name:         "existing version would fail",
+			published:    []packageIdentity{{ID: "Contoso.Core", Version: "1.0.0"}},
+			wantMsg:      "Would push 1 package(s) to NuGet (1 would fail)",
+			wantStatuses: map[string]PackageStatus{"Contoso.Core": StatusWouldFail, "Contoso.Utils": StatusWouldPush},
+		},
+		{
+			name:          "existing version is skipped with skip_duplicate",
+			skipDuplicate: true,
+			published:     []packageIdentity{{ID: "Contoso.Core", Version: "1.0.0"}},
+			wantMsg:       "Would push 1 package(s) to NuGet (1 skipped as duplicate)",
+			wantStatuses:  map[string]PackageStatus{"Contoso.Core": StatusWouldSkip, "Contoso.Utils": StatusWouldPush},
+		},
+		{
+			name:         "feed without publish resource",
+			noPublish:    true,
+			wantMsg:      "Would push 0 package(s) to NuGet (2 would fail)",
+			wantStatuses: map[string]PackageStatus{"Contoso.Core": StatusWouldFail, "Contoso.Utils": StatusWouldFail},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			writeTestPackage(t, tmpDir, "Contoso.Core", "1.0.0", nil)
+			writeTestPackage(t, tmpDir, "Contoso.Utils", "1.0.0", nil)
+
+			feed := newTestFeed(t)
+			feed.noPublish = tt.noPublish
+			for _, id := range tt.published {
+				feed.AddVersion(id.ID, id.Version)
+			}
+
+			mockExec := &MockCommandExecutor{}
+			p := &NuGetPlugin{cmdExecutor: mockExec, httpClient: feed.server.Client()}
+
+			resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+				Hook: plugin.HookPostPublish,
+				Config: map[string]any{
+					"api_key":        "test-key",
+					"source":         feed.SourceURL(),
+					"package_path":   tmpDir + "/*.nupkg",
+					"skip_duplicate": tt.skipDuplicate,
+				},
+				Context: plugin.ReleaseContext{Version: "v1.0.0"},
+				DryRun:  true,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !resp.Success {
+				t.Fatalf("expected success, got error: %s", resp.Error)
+			}
+			if resp.Message != tt.wantMsg {
+				t.Errorf("expected message '%s', got '%s'", tt.wantMsg, resp.Message)
+			}
+			if len(mockExec.Calls) != 0 {
+				t.Errorf("expected no push calls during dry run, got %d", len(mockExec.Calls))
+			}
+
+			results, ok := resp.Outputs["packages"].([]PackageResult)
+			if !ok {
+				t.Fatalf("expected package results, got %T", resp.Outputs["packages"])
+			}
+			for _, r := range results {
+				if want := tt.wantStatuses[r.ID]; r.Status != want {
+					t.Errorf("%s: expected status %s, got %s (%s)", r.ID, want, r.Status, r.Error)
+				}
+			}
+		})
+	}
+}
+
+func TestDryRunUnreachableFeed(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTestPackage(t, tmpDir, "Contoso.Core", "1.0.0", nil)
+
+	p := &NuGetPlugin{}
+	resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+		Hook: plugin.HookPostPublish,
+		Config: map[string]any{
+			"api_key":      "test-key",
+			"source":       "http://127.0.0.1:1/v3/index.json",
+			"package_path": tmpDir + "/*.nupkg",
+		},
+		Context: plugin.ReleaseContext{Version: "v1.0.0"},
+		DryRun:  true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	check, ok := resp.Outputs["feed"].(feedCheck)
+	if !ok {
+		t.Fatalf("expected feed output, got %T", resp.Outputs["feed"])
+	}
+	if check.Reachable || check.Error == "" {
+		t.Errorf("expected unreachable feed with error, got %+v", check)
+	}
+	if resp.Message != "Would push 0 package(s) to NuGet (1 would fail)" {
+		t.Errorf("unexpected message: %s", resp.Message)
+	}
+}
+
+func TestDryRunEmptyPackageID(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, ".1.0.0.nupkg"), []byte("not a zip"), 0o644); err != nil {
+		t.Fatalf("failed to write package: %v", err)
+	}
+
+	p := &NuGetPlugin{}
+	resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+		Hook: plugin.HookPostPublish,
+		Config: map[string]any{
+			"api_key":      "test-key",
+			"source":       "http://127.0.0.1:1/v3/index.json",
+			"package_path": filepath.Join(tmpDir, ".1.0.0.nupkg"),
+		},
+		Context: plugin.ReleaseContext{Version: "v1.0.0"},
+		DryRun:  true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result := resp.Outputs["packages"].([]PackageResult)[0]
+	if result.Status != StatusWouldFail || result.ErrorClass != string(ErrorKindInvalidPackage) ||
+		!strings.Contains(result.Error, "failed to read package identity") {
+		t.Errorf("expected an invalid package result, got %+v", result)
+	}
+}
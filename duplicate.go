@@ -0,0 +1,172 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Policies for packages whose version already exists on the feed.
+const (
+	// OnDuplicateFail fails the push when the version already exists.
+	OnDuplicateFail = "fail"
+	// OnDuplicateSkip skips the package when the version already exists.
+	OnDuplicateSkip = "skip"
+	// OnDuplicateSkipIfIdentical skips the package only if the published copy
+	// has the same contents as the local one, and fails otherwise.
+	OnDuplicateSkipIfIdentical = "skip_if_identical"
+)
+
+// signatureEntry is the zip entry holding a package's author or repository signature.
+const signatureEntry = ".signature.p7s"
+
+// downloadPackage streams a package from the flat container into w, failing
+// once the download exceeds the client's download limit.
+func (c *feedClient) downloadPackage(ctx context.Context, baseAddress string, id packageIdentity, w io.Writer) error {
+	lowerID := strings.ToLower(id.ID)
+	lowerVersion := comparableVersion(id.Version)
+	endpoint := fmt.Sprintf("%s/%s/%s/%s.%s.nupkg",
+		strings.TrimSuffix(baseAddress, "/"),
+		url.PathEscape(lowerID), url.PathEscape(lowerVersion),
+		url.PathEscape(lowerID), url.PathEscape(lowerVersion))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	c.authorize(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request to %s failed: %w", endpoint, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return errPackageNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, endpoint)
+	}
+
+	limit := c.maxDownload
+	if limit <= 0 {
+		limit = MaxDownloadSize
+	}
+	n, err := io.Copy(w, io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", id, err)
+	}
+	if n > limit {
+		return fmt.Errorf("failed to download %s: package exceeds the download limit of %s", id, formatSize(limit))
+	}
+	return nil
+}
+
+// compareWithPublished downloads the published copy of a package and reports
+// whether it matches the local file. Packages are considered identical when
+// their bytes match, or when they differ only by a repository signature that
+// the feed added on ingestion (as nuget.org does).
+func (c *feedClient) compareWithPublished(ctx context.Context, baseAddress string, id packageIdentity, localPath string) (bool, error) {
+	tmp, err := os.CreateTemp("", "nuget-published-*.nupkg")
+	if err != nil {
+		return false, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if err := c.downloadPackage(ctx, baseAddress, id, tmp); err != nil {
+		return false, fmt.Errorf("failed to download published package: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return false, fmt.Errorf("failed to write published package: %w", err)
+	}
+
+	return packagesIdentical(localPath, tmp.Name())
+}
+
+// packagesIdentical compares two package files by hash, falling back to a
+// comparison of their contents that ignores the signature entry.
+func packagesIdentical(a, b string) (bool, error) {
+	hashA, err := fileSHA512(a)
+	if err != nil {
+		return false, err
+	}
+	hashB, err := fileSHA512(b)
+	if err != nil {
+		return false, err
+	}
+	if hashA == hashB {
+		return true, nil
+	}
+
+	digestA, err := contentDigest(a)
+	if err != nil {
+		return false, err
+	}
+	digestB, err := contentDigest(b)
+	if err != nil {
+		return false, err
+	}
+	return digestA == digestB, nil
+}
+
+// fileSHA512 returns the hex-encoded SHA-512 hash of a file.
+func fileSHA512(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	h := sha512.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// contentDigest hashes the names and uncompressed contents of every entry in a
+// package except its signature, so re-signed copies of a package compare equal.
+func contentDigest(path string) (string, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open package %s: %w", path, err)
+	}
+	defer func() { _ = r.Close() }()
+
+	files := make([]*zip.File, 0, len(r.File))
+	for _, f := range r.File {
+		if f.Name == signatureEntry {
+			continue
+		}
+		files = append(files, f)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+
+	h := sha512.New()
+	for _, f := range files {
+		rc, err := f.Open()
+		if err != nil {
+			return "", fmt.Errorf("failed to open %s in %s: %w", f.Name, path, err)
+		}
+		entry := sha512.New()
+		_, err = io.Copy(entry, rc)
+		_ = rc.Close()
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s in %s: %w", f.Name, path, err)
+		}
+		_, _ = fmt.Fprintf(h, "%s\x00%x\n", f.Name, entry.Sum(nil))
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// withSignature returns a copy of the package with a signature entry added,
+// mimicking the repository signature nuget.org applies on ingestion.
+func withSignature(t *testing.T, path string) []byte {
+	t.Helper()
+
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("failed to open package: %v", err)
+	}
+	defer func() { _ = r.Close() }()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range r.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("failed to open %s: %v", f.Name, err)
+		}
+		w, err := zw.Create(f.Name)
+		if err != nil {
+			t.Fatalf("failed to add %s: %v", f.Name, err)
+		}
+		if _, err := io.Copy(w, rc); err != nil {
+			t.Fatalf("failed to copy %s: %v", f.Name, err)
+		}
+		_ = rc.Close()
+	}
+	w, err := zw.Create(signatureEntry)
+	if err != nil {
+		t.Fatalf("failed to add signature: %v", err)
+	}
+	_, _ = w.Write([]byte("repository signature"))
+	if err := zw.Close(); err != nil {
+		t.Fatalf("failed to finalize package: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestPackagesIdentical(t *testing.T) {
+	dirA, dirB, dirC := t.TempDir(), t.TempDir(), t.TempDir()
+	a := writeTestPackage(t, dirA, "Contoso.Core", "1.0.0", map[string]string{"lib/net8.0/Contoso.Core.dll": "binary-a"})
+	same := writeTestPackage(t, dirB, "Contoso.Core", "1.0.0", map[string]string{"lib/net8.0/Contoso.Core.dll": "binary-a"})
+	different := writeTestPackage(t, dirC, "Contoso.Core", "1.0.0", map[string]string{"lib/net8.0/Contoso.Core.dll": "binary-b"})
+
+	signed := filepath.Join(t.TempDir(), "signed.nupkg")
+	if err := os.WriteFile(signed, withSignature(t, a), 0644); err != nil {
+		t.Fatalf("failed to write signed package: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		b    string
+		want bool
+	}{
+		{name: "same bytes", b: same, want: true},
+		{name: "repository signed copy", b: signed, want: true},
+		{name: "different contents", b: different, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := packagesIdentical(a, tt.b)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("packagesIdentical() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDownloadPackageLimit(t *testing.T) {
+	feed := newTestFeed(t)
+	feed.AddPackage("Contoso.Core", "1.0.0", bytes.Repeat([]byte("x"), 100))
+	baseAddress := feed.server.URL + "/v3-flatcontainer/"
+
+	tests := []struct {
+		name    string
+		limit   int64
+		wantErr bool
+	}{
+		{name: "within limit", limit: 100},
+		{name: "over limit", limit: 99, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &feedClient{httpClient: feed.server.Client(), source: feed.SourceURL(), maxDownload: tt.limit}
+			var buf bytes.Buffer
+			err := client.downloadPackage(context.Background(), baseAddress, packageIdentity{ID: "Contoso.Core", Version: "1.0.0"}, &buf)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "exceeds the download limit") {
+					t.Errorf("expected download limit error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if buf.Len() != 100 {
+				t.Errorf("expected 100 bytes, got %d", buf.Len())
+			}
+		})
+	}
+}
+
+func TestOnDuplicatePolicy(t *testing.T) {
+	conflict := []byte("error: Response status code does not indicate success: 409 (Conflict).")
+
+	tests := []struct {
+		name          string
+		onDuplicate   string
+		publishedBody string
+		dryRun        bool
+		wantSuccess   bool
+		wantStatus    PackageStatus
+	}{
+		{name: "fail", onDuplicate: OnDuplicateFail, publishedBody: "binary", wantSuccess: false, wantStatus: StatusFailed},
+		{name: "skip", onDuplicate: OnDuplicateSkip, publishedBody: "other", wantSuccess: true, wantStatus: StatusSkipped},
+		{name: "skip_if_identical with same contents", onDuplicate: OnDuplicateSkipIfIdentical, publishedBody: "binary", wantSuccess: true, wantStatus: StatusSkipped},
+		{name: "skip_if_identical with different contents", onDuplicate: OnDuplicateSkipIfIdentical, publishedBody: "other", wantSuccess: false, wantStatus: StatusFailed},
+		{name: "dry run skip_if_identical with same contents", onDuplicate: OnDuplicateSkipIfIdentical, publishedBody: "binary", dryRun: true, wantSuccess: true, wantStatus: StatusWouldSkip},
+		{name: "dry run skip_if_identical with different contents", onDuplicate: OnDuplicateSkipIfIdentical, publishedBody: "other", dryRun: true, wantSuccess: true, wantStatus: StatusWouldFail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			local := writeTestPackage(t, tmpDir, "Contoso.Core", "1.0.0", map[string]string{"lib/net8.0/Contoso.Core.dll": "binary"})
+			published := writeTestPackage(t, t.TempDir(), "Contoso.Core", "1.0.0", map[string]string{"lib/net8.0/Contoso.Core.dll": tt.publishedBody})
+
+			feed := newTestFeed(t)
+			feed.AddPackage("Contoso.Core", "1.0.0", withSignature(t, published))
+
+			mockExec := &MockCommandExecutor{
+				RunFunc: func(_ context.Context, _ string, _ ...string) ([]byte, error) {
+					return conflict, errors.New("exit status 1")
+				},
+			}
+			p := &NuGetPlugin{cmdExecutor: mockExec, httpClient: feed.server.Client()}
+
+			resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+				Hook: plugin.HookPostPublish,
+				Config: map[string]any{
+					"api_key":      "test-key",
+					"source":       feed.SourceURL(),
+					"package_path": local,
+					"on_duplicate": tt.onDuplicate,
+				},
+				Context: plugin.ReleaseContext{Version: "v1.0.0"},
+				DryRun:  tt.dryRun,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if resp.Success != tt.wantSuccess {
+				t.Errorf("expected success=%v, got success=%v, error: %s", tt.wantSuccess, resp.Success, resp.Error)
+			}
+			results := resp.Outputs["packages"].([]PackageResult)
+			if results[0].Status != tt.wantStatus {
+				t.Errorf("expected status %s, got %s (%s)", tt.wantStatus, results[0].Status, results[0].Error)
+			}
+			if !tt.dryRun {
+				wantArgs := []string{"nuget", "push", local, "--api-key", "test-key", "--source", feed.SourceURL(), "--timeout", "300"}
+				if got := mockExec.Calls[0].Args; !reflect.DeepEqual(got, wantArgs) {
+					t.Errorf("expected args %v, got %v", wantArgs, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// PushErrorKind classifies why a push failed.
+type PushErrorKind string
+
+const (
+	// ErrorKindAuth means the feed rejected the credentials (401/403).
+	ErrorKindAuth PushErrorKind = "auth"
+	// ErrorKindDuplicate means the package version already exists (409).
+	ErrorKindDuplicate PushErrorKind = "duplicate"
+	// ErrorKindValidation means the feed rejected the package contents (400).
+	ErrorKindValidation PushErrorKind = "validation"
+	// ErrorKindRateLimited means the feed throttled or rejected the request by quota.
+	ErrorKindRateLimited PushErrorKind = "rate_limited"
+	// ErrorKindTooLarge means the package exceeds the feed's upload limit (413).
+	ErrorKindTooLarge PushErrorKind = "too_large"
+	// ErrorKindNetwork means the feed could not be reached.
+	ErrorKindNetwork PushErrorKind = "network"
+	// ErrorKindTimeout means the push did not complete in time.
+	ErrorKindTimeout PushErrorKind = "timeout"
+	// ErrorKindForbiddenContent means the package contains forbidden files or secrets.
+	ErrorKindForbiddenContent PushErrorKind = "forbidden_content"
+	// ErrorKindAssemblyVersion means assembly versions disagree with the package version.
+	ErrorKindAssemblyVersion PushErrorKind = "assembly_version"
+	// ErrorKindBreakingChange means a non-major release removes or changes public API.
+	ErrorKindBreakingChange PushErrorKind = "breaking_change"
+	// ErrorKindFrameworkRemoved means a non-major release drops a target framework.
+	ErrorKindFrameworkRemoved PushErrorKind = "framework_removed"
+	// ErrorKindPackageDocs means the package readme or license is missing or invalid.
+	ErrorKindPackageDocs PushErrorKind = "package_docs"
+	// ErrorKindLicense means the package license violates the license policy.
+	ErrorKindLicense PushErrorKind = "license"
+	// ErrorKindDeadline means the hook ran past total_timeout.
+	ErrorKindDeadline PushErrorKind = "deadline"
+	// ErrorKindInvalidPackage means the local package file could not be read.
+	ErrorKindInvalidPackage PushErrorKind = "invalid_package"
+	// ErrorKindFeed means the feed is reachable but cannot accept pushes.
+	ErrorKindFeed PushErrorKind = "feed"
+	// ErrorKindUnknown is used when the failure matches no known pattern.
+	ErrorKindUnknown PushErrorKind = "unknown"
+)
+
+// Hint returns a remediation hint for the error kind.
+func (k PushErrorKind) Hint() string {
+	switch k {
+	case ErrorKindAuth:
+		return "check that the API key is valid, not expired, and scoped to push this package ID"
+	case ErrorKindDuplicate:
+		return "this version is already published; bump the version or set skip_duplicate"
+	case ErrorKindValidation:
+		return "fix the package metadata or contents reported by the feed and rebuild the package"
+	case ErrorKindRateLimited:
+		return "the feed is throttling requests; retry later or reduce the number of packages per release"
+	case ErrorKindTooLarge:
+		return "reduce the package size (exclude unneeded files, move symbols to a .snupkg) or use a feed with a higher limit"
+	case ErrorKindNetwork:
+		return "check network connectivity, DNS and proxy settings for the feed"
+	case ErrorKindTimeout:
+		return "increase the timeout or check the feed's availability"
+	case ErrorKindForbiddenContent:
+		return "remove the flagged files from the package (check Pack and CopyToOutputDirectory items), rotate any leaked secret, or adjust forbidden_files and secret_patterns"
+	case ErrorKindAssemblyVersion:
+		return "align Version, FileVersion, AssemblyVersion and InformationalVersion in the project or Directory.Build.props and rebuild, or adjust assembly_version_rules"
+	case ErrorKindBreakingChange:
+		return "restore the removed API (obsolete it first and remove it in the next major version) or bump the major version"
+	case ErrorKindFrameworkRemoved:
+		return "restore the dropped target framework or bump the major version; set on_framework_removed to warn to publish anyway"
+	case ErrorKindPackageDocs:
+		return "include the files the nuspec <readme> and <license> reference, use a valid SPDX license expression, and use absolute https URLs for readme links and images"
+	case ErrorKindLicense:
+		return "declare an approved license with <license type=\"expression\"> using an SPDX identifier listed in allowed_licenses"
+	case ErrorKindDeadline:
+		return "increase total_timeout or publish fewer packages per release; packages not yet pushed can be resumed with resume"
+	case ErrorKindInvalidPackage:
+		return "make sure the file is a valid .nupkg produced by dotnet pack or nuget pack"
+	case ErrorKindFeed:
+		return "check that the source URL points to a NuGet V3 service index that supports publishing"
+	default:
+		return "inspect the dotnet output above for details"
+	}
+}
+
+// Retryable reports whether a failure of this kind may succeed on another attempt.
+func (k PushErrorKind) Retryable() bool {
+	switch k {
+	case ErrorKindNetwork, ErrorKindTimeout, ErrorKindRateLimited:
+		return true
+	default:
+		return false
+	}
+}
+
+// PushError is a classified failure of dotnet nuget push.
+type PushError struct {
+	Kind PushErrorKind
+	// Status is the HTTP status code reported by dotnet, if any.
+	Status int
+	// Reasons lists the feed's explanation, e.g. validation messages.
+	Reasons []string
+	// Output is the trimmed combined output of the command.
+	Output string
+	Err    error
+}
+
+// Error implements error.
+func (e *PushError) Error() string {
+	msg := e.Output
+	if msg == "" && e.Err != nil {
+		msg = e.Err.Error()
+	} else if e.Err != nil {
+		msg = fmt.Sprintf("%s: %v", msg, e.Err)
+	}
+	return fmt.Sprintf("[%s] %s", e.Kind, msg)
+}
+
+// Unwrap returns the underlying command error.
+func (e *PushError) Unwrap() error {
+	return e.Err
+}
+
+// Hint returns the remediation hint for the error.
+func (e *PushError) Hint() string {
+	return e.Kind.Hint()
+}
+
+// statusCodePattern matches dotnet's "Response status code does not indicate success: 400 (reason)".
+var statusCodePattern = regexp.MustCompile(`(?im)status code does not indicate success:\s*(\d{3})(?:\s*\((.*)\))?`)
+
+// Output fragments that identify failures which carry no HTTP status.
+var (
+	networkPatterns = []string{
+		"no such host",
+		"name or service not known",
+		"nodename nor servname",
+		"connection refused",
+		"connection reset",
+		"network is unreachable",
+		"an error occurred while sending the request",
+		"the ssl connection could not be established",
+		"unable to load the service index",
+	}
+	timeoutPatterns = []string{
+		"timed out",
+		"timeout",
+		"a task was canceled",
+		"the operation was canceled",
+	}
+	tooLargePatterns = []string{
+		"request entity too large",
+		"payload too large",
+		"exceeds the maximum",
+		"package is too large",
+	}
+	rateLimitPatterns = []string{
+		"too many requests",
+		"rate limit",
+		"quota",
+	}
+	authPatterns = []string{
+		"api key is invalid",
+		"api key is required",
+		"unauthorized",
+		"forbidden",
+		"authentication failed",
+	}
+	duplicatePatterns = []string{
+		"already exists",
+		"already contains",
+		"conflict",
+	}
+)
+
+// classifyPushFailure maps dotnet nuget push output to a classified PushError.
+func classifyPushFailure(ctx context.Context, output []byte, err error) *PushError {
+	text := strings.TrimSpace(string(output))
+	pe := &PushError{Kind: ErrorKindUnknown, Output: text, Err: err}
+
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		pe.Kind = ErrorKindTimeout
+		return pe
+	}
+
+	if m := statusCodePattern.FindStringSubmatch(text); m != nil {
+		pe.Status, _ = strconv.Atoi(m[1])
+		if m[2] != "" {
+			pe.Reasons = splitReasons(m[2])
+		}
+		if kind, ok := kindForStatus(pe.Status); ok {
+			pe.Kind = kind
+			return pe
+		}
+	}
+
+	lower := strings.ToLower(text)
+	switch {
+	case containsAny(lower, tooLargePatterns):
+		pe.Kind = ErrorKindTooLarge
+	case containsAny(lower, rateLimitPatterns):
+		pe.Kind = ErrorKindRateLimited
+	case containsAny(lower, authPatterns):
+		pe.Kind = ErrorKindAuth
+	case containsAny(lower, duplicatePatterns):
+		pe.Kind = ErrorKindDuplicate
+	case containsAny(lower, networkPatterns):
+		pe.Kind = ErrorKindNetwork
+	case containsAny(lower, timeoutPatterns):
+		pe.Kind = ErrorKindTimeout
+	}
+	return pe
+}
+
+// kindForStatus maps an HTTP status code to an error kind.
+func kindForStatus(status int) (PushErrorKind, bool) {
+	switch {
+	case status == 401 || status == 403:
+		return ErrorKindAuth, true
+	case status == 409:
+		return ErrorKindDuplicate, true
+	case status == 413:
+		return ErrorKindTooLarge, true
+	case status == 429:
+		return ErrorKindRateLimited, true
+	case status == 408 || status == 504:
+		return ErrorKindTimeout, true
+	case status == 400:
+		return ErrorKindValidation, true
+	case status == 502 || status == 503:
+		return ErrorKindNetwork, true
+	default:
+		return "", false
+	}
+}
+
+// splitReasons splits a feed's reason phrase into individual messages.
+func splitReasons(reason string) []string {
+	var reasons []string
+	for _, part := range strings.Split(reason, ". ") {
+		part = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(part), "."))
+		if part != "" {
+			reasons = append(reasons, part)
+		}
+	}
+	return reasons
+}
+
+// containsAny reports whether s contains any of the given substrings.
+func containsAny(s string, substrs []string) bool {
+	for _, sub := range substrs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestClassifyPushFailure(t *testing.T) {
+	exitErr := errors.New("exit status 1")
+
+	tests := []struct {
+		name        string
+		output      string
+		err         error
+		wantKind    PushErrorKind
+		wantStatus  int
+		wantReasons []string
+	}{
+		{
+			name:       "unauthorized",
+			output:     "Pushing Foo.1.0.0.nupkg to 'https://www.nuget.org/api/v2/package'...\nerror: Response status code does not indicate success: 401 (Unauthorized).",
+			wantKind:   ErrorKindAuth,
+			wantStatus: 401,
+		},
+		{
+			name:       "forbidden api key",
+			output:     "error: Response status code does not indicate success: 403 (The specified API key is invalid, has expired, or does not have permission to access the specified package.).",
+			wantKind:   ErrorKindAuth,
+			wantStatus: 403,
+		},
+		{
+			name:       "conflict",
+			output:     "error: Response status code does not indicate success: 409 (Conflict - The feed already contains 'Foo 1.0.0'.).",
+			wantKind:   ErrorKindDuplicate,
+			wantStatus: 409,
+		},
+		{
+			name:        "validation with reasons",
+			output:      "error: Response status code does not indicate success: 400 (The package manifest is missing a license. The id 'Foo' is reserved.).",
+			wantKind:    ErrorKindValidation,
+			wantStatus:  400,
+			wantReasons: []string{"The package manifest is missing a license", "The id 'Foo' is reserved"},
+		},
+		{
+			name:       "too large",
+			output:     "error: Response status code does not indicate success: 413 (Request Entity Too Large).",
+			wantKind:   ErrorKindTooLarge,
+			wantStatus: 413,
+		},
+		{
+			name:       "rate limited",
+			output:     "error: Response status code does not indicate success: 429 (Too Many Requests).",
+			wantKind:   ErrorKindRateLimited,
+			wantStatus: 429,
+		},
+		{
+			name:     "quota without status",
+			output:   "error: The package quota for this feed has been exceeded.",
+			wantKind: ErrorKindRateLimited,
+		},
+		{
+			name:     "dns failure",
+			output:   "error: Unable to load the service index for source https://nuget.example.com/v3/index.json.\n  No such host is known. (nuget.example.com:443)",
+			wantKind: ErrorKindNetwork,
+		},
+		{
+			name:     "dotnet timeout",
+			output:   "error: The operation has timed out.",
+			wantKind: ErrorKindTimeout,
+		},
+		{
+			name:     "context deadline",
+			err:      context.DeadlineExceeded,
+			wantKind: ErrorKindTimeout,
+		},
+		{
+			name:     "plain auth message",
+			output:   "401 Unauthorized",
+			wantKind: ErrorKindAuth,
+		},
+		{
+			name:     "unrecognized output",
+			output:   "something unexpected happened",
+			wantKind: ErrorKindUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.err
+			if err == nil {
+				err = exitErr
+			}
+			got := classifyPushFailure(context.Background(), []byte(tt.output), err)
+			if got.Kind != tt.wantKind {
+				t.Errorf("expected kind %s, got %s", tt.wantKind, got.Kind)
+			}
+			if got.Status != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, got.Status)
+			}
+			if tt.wantReasons != nil && !reflect.DeepEqual(got.Reasons, tt.wantReasons) {
+				t.Errorf("expected reasons %q, got %q", tt.wantReasons, got.Reasons)
+			}
+			if !errors.Is(got, err) {
+				t.Error("expected classified error to wrap the command error")
+			}
+			if got.Hint() == "" {
+				t.Error("expected a remediation hint")
+			}
+		})
+	}
+}
+
+func TestPushRetryBehaviour(t *testing.T) {
+	tests := []struct {
+		name          string
+		outputs       []string
+		skipDuplicate bool
+		retries       int
+		wantSuccess   bool
+		wantCalls     int
+		wantStatus    PackageStatus
+		wantClass     string
+	}{
+		{
+			name:        "network failure is retried",
+			outputs:     []string{"error: Connection refused", ""},
+			retries:     2,
+			wantSuccess: true,
+			wantCalls:   2,
+			wantStatus:  StatusPushed,
+		},
+		{
+			name:        "retries are bounded",
+			outputs:     []string{"error: 429 Too Many Requests", "error: 429 Too Many Requests", "error: 429 Too Many Requests"},
+			retries:     1,
+			wantSuccess: false,
+			wantCalls:   2,
+			wantStatus:  StatusFailed,
+			wantClass:   string(ErrorKindRateLimited),
+		},
+		{
+			name:        "auth failure is not retried",
+			outputs:     []string{"error: Response status code does not indicate success: 403 (Forbidden).", ""},
+			retries:     2,
+			wantSuccess: false,
+			wantCalls:   1,
+			wantStatus:  StatusFailed,
+			wantClass:   string(ErrorKindAuth),
+		},
+		{
+			name:          "duplicate is skipped with skip_duplicate",
+			outputs:       []string{"error: Response status code does not indicate success: 409 (Conflict)."},
+			skipDuplicate: true,
+			retries:       2,
+			wantSuccess:   true,
+			wantCalls:     1,
+			wantStatus:    StatusSkipped,
+		},
+		{
+			name:        "duplicate fails without skip_duplicate",
+			outputs:     []string{"error: Response status code does not indicate success: 409 (Conflict)."},
+			retries:     2,
+			wantSuccess: false,
+			wantCalls:   1,
+			wantStatus:  StatusFailed,
+			wantClass:   string(ErrorKindDuplicate),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			writeTestPackage(t, tmpDir, "Contoso.Core", "1.0.0", nil)
+			feed := newTestFeed(t)
+
+			call := 0
+			mockExec := &MockCommandExecutor{
+				RunFunc: func(_ context.Context, _ string, _ ...string) ([]byte, error) {
+					out := tt.outputs[call]
+					call++
+					if out == "" {
+						return []byte("Your package was pushed."), nil
+					}
+					return []byte(out), errors.New("exit status 1")
+				},
+			}
+			p := &NuGetPlugin{cmdExecutor: mockExec, httpClient: feed.server.Client()}
+
+			resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+				Hook: plugin.HookPostPublish,
+				Config: map[string]any{
+					"api_key":        "test-key",
+					"source":         feed.SourceURL(),
+					"package_path":   filepath.Join(tmpDir, "*.nupkg"),
+					"skip_duplicate": tt.skipDuplicate,
+					"retries":        tt.retries,
+					"retry_delay":    0,
+				},
+				Context: plugin.ReleaseContext{Version: "v1.0.0"},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if resp.Success != tt.wantSuccess {
+				t.Errorf("expected success=%v, got success=%v, error: %s", tt.wantSuccess, resp.Success, resp.Error)
+			}
+			if len(mockExec.Calls) != tt.wantCalls {
+				t.Errorf("expected %d calls, got %d", tt.wantCalls, len(mockExec.Calls))
+			}
+
+			results := resp.Outputs["packages"].([]PackageResult)
+			if results[0].Status != tt.wantStatus {
+				t.Errorf("expected status %s, got %s", tt.wantStatus, results[0].Status)
+			}
+			if results[0].Attempts != tt.wantCalls {
+				t.Errorf("expected %d attempts, got %d", tt.wantCalls, results[0].Attempts)
+			}
+			if results[0].ErrorClass != tt.wantClass {
+				t.Errorf("expected error class %q, got %q", tt.wantClass, results[0].ErrorClass)
+			}
+			if tt.wantClass != "" && !containsString(resp.Error, "hint:") {
+				t.Errorf("expected remediation hint in error, got: %s", resp.Error)
+			}
+		})
+	}
+}
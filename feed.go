@@ -0,0 +1,291 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// NuGet V3 service index resource types used by the plugin.
+const (
+	resourcePackageBaseAddress = "PackageBaseAddress/3.0.0"
+	resourcePackagePublish     = "PackagePublish/2.0.0"
+)
+
+// maxFeedResponseSize bounds how much of a feed JSON response is read.
+const maxFeedResponseSize = 16 << 20
+
+// errPackageNotFound is returned when the feed has no entry for a package.
+var errPackageNotFound = errors.New("package not found on feed")
+
+// feedKind identifies well-known NuGet feed implementations.
+type feedKind string
+
+const (
+	feedKindNuGetOrg feedKind = "nuget.org"
+	feedKindGitHub   feedKind = "github"
+	feedKindAzure    feedKind = "azure_artifacts"
+	feedKindGeneric  feedKind = "generic"
+)
+
+// detectFeedKind infers the feed implementation from the source URL.
+func detectFeedKind(source string) feedKind {
+	u, err := url.Parse(source)
+	if err != nil {
+		return feedKindGeneric
+	}
+	switch strings.ToLower(u.Hostname()) {
+	case "api.nuget.org":
+		return feedKindNuGetOrg
+	case "nuget.pkg.github.com":
+		return feedKindGitHub
+	case "pkgs.dev.azure.com":
+		return feedKindAzure
+	}
+	if strings.HasSuffix(strings.ToLower(u.Hostname()), ".pkgs.visualstudio.com") {
+		return feedKindAzure
+	}
+	return feedKindGeneric
+}
+
+// serviceIndex is a NuGet V3 service index document.
+type serviceIndex struct {
+	Version   string            `json:"version"`
+	Resources []serviceResource `json:"resources"`
+}
+
+// serviceResource is a single entry in the service index.
+type serviceResource struct {
+	ID   string `json:"@id"`
+	Type string `json:"@type"`
+}
+
+// resourceURL returns the URL of the first resource matching the given type.
+// A bare type also matches versioned variants (e.g. "PackagePublish/2.0.0").
+func (s *serviceIndex) resourceURL(resourceType string) string {
+	base := strings.SplitN(resourceType, "/", 2)[0]
+	for _, r := range s.Resources {
+		if r.Type == resourceType {
+			return r.ID
+		}
+	}
+	for _, r := range s.Resources {
+		if strings.SplitN(r.Type, "/", 2)[0] == base {
+			return r.ID
+		}
+	}
+	return ""
+}
+
+// feedClient performs read-only HTTP calls against a NuGet V3 feed.
+type feedClient struct {
+	httpClient *http.Client
+	source     string
+	apiKey     string
+	kind       feedKind
+	githubAPI  string
+	// username and password authenticate feed requests on feeds that require
+	// basic auth (GitHub Packages, Azure Artifacts).
+	username string
+	password string
+	// maxDownload bounds package downloads in bytes; zero means MaxDownloadSize.
+	maxDownload int64
+}
+
+// newFeedClient creates a feed client for the configured source.
+func (p *NuGetPlugin) newFeedClient(cfg *Config) *feedClient {
+	username, password := cfg.feedCredentials()
+	return &feedClient{
+		httpClient:  p.getHTTPClient(),
+		source:      cfg.Source,
+		apiKey:      cfg.APIKey,
+		kind:        cfg.feedKind(),
+		githubAPI:   cfg.GitHubAPIURL,
+		username:    username,
+		password:    password,
+		maxDownload: cfg.downloadLimit(),
+	}
+}
+
+// withAPIKey returns a copy of the client that authenticates with a different API key.
+func (c *feedClient) withAPIKey(apiKey string) *feedClient {
+	clone := *c
+	clone.apiKey = apiKey
+	return &clone
+}
+
+// serviceIndex fetches and decodes the feed's service index.
+func (c *feedClient) serviceIndex(ctx context.Context) (*serviceIndex, error) {
+	var index serviceIndex
+	if err := c.getJSON(ctx, c.source, &index); err != nil {
+		return nil, fmt.Errorf("failed to fetch service index: %w", err)
+	}
+	if len(index.Resources) == 0 {
+		return nil, fmt.Errorf("service index at %s lists no resources", c.source)
+	}
+	// The plugin calls the resources it uses, so a hostile index must not be able
+	// to point them at private networks.
+	for _, r := range index.Resources {
+		switch strings.SplitN(r.Type, "/", 2)[0] {
+		case "PackageBaseAddress", "PackagePublish":
+			if c.sameHost(r.ID) {
+				continue
+			}
+			if err := validateSourceURL(ctx, r.ID); err != nil {
+				return nil, fmt.Errorf("service index advertises an unsafe %s resource %s: %w", r.Type, r.ID, err)
+			}
+		}
+	}
+	return &index, nil
+}
+
+// sameHost reports whether rawURL is on the host of the configured source.
+func (c *feedClient) sameHost(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	source, err := url.Parse(c.source)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Scheme, source.Scheme) && strings.EqualFold(u.Host, source.Host)
+}
+
+// packageVersions lists the versions of a package in the flat container.
+func (c *feedClient) packageVersions(ctx context.Context, baseAddress, id string) ([]string, error) {
+	endpoint := strings.TrimSuffix(baseAddress, "/") + "/" + url.PathEscape(strings.ToLower(id)) + "/index.json"
+
+	var body struct {
+		Versions []string `json:"versions"`
+	}
+	if err := c.getJSON(ctx, endpoint, &body); err != nil {
+		return nil, err
+	}
+	return body.Versions, nil
+}
+
+// versionExists reports whether the given package version is already on the feed.
+func (c *feedClient) versionExists(ctx context.Context, baseAddress string, id packageIdentity) (bool, error) {
+	versions, err := c.packageVersions(ctx, baseAddress, id.ID)
+	if errors.Is(err, errPackageNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to list versions of %s: %w", id.ID, err)
+	}
+
+	want := comparableVersion(id.Version)
+	for _, v := range versions {
+		if comparableVersion(v) == want {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// apiKeyStatus is the outcome of a non-mutating API key check.
+type apiKeyStatus string
+
+const (
+	apiKeyValid      apiKeyStatus = "valid"
+	apiKeyInvalid    apiKeyStatus = "invalid"
+	apiKeyOutOfScope apiKeyStatus = "out_of_scope"
+	apiKeyUnverified apiKeyStatus = "unverified"
+)
+
+// checkAPIKey verifies the API key can push the given package without publishing anything.
+// Only feeds that expose a verification endpoint are checked; others report unverified.
+func (c *feedClient) checkAPIKey(ctx context.Context, publishURL string, id packageIdentity) (apiKeyStatus, error) {
+	if c.kind != feedKindNuGetOrg || publishURL == "" {
+		return apiKeyUnverified, nil
+	}
+
+	// nuget.org exposes api/v2/verifykey/{id}[/{version}] next to api/v2/package.
+	// The version being pushed is not published yet and would always return
+	// 404, so the key is checked against the package ID only.
+	base := strings.TrimSuffix(strings.TrimSuffix(publishURL, "/"), "/package")
+	endpoint := base + "/verifykey/" + url.PathEscape(id.ID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return apiKeyUnverified, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("X-NuGet-ApiKey", c.apiKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return apiKeyUnverified, fmt.Errorf("failed to verify API key: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxFeedResponseSize))
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return apiKeyValid, nil
+	case http.StatusUnauthorized:
+		return apiKeyInvalid, nil
+	case http.StatusForbidden:
+		// The key is valid but its glob scopes do not cover this package.
+		return apiKeyOutOfScope, nil
+	default:
+		// 404 means the package ID does not exist yet, so scope cannot be checked.
+		return apiKeyUnverified, nil
+	}
+}
+
+// getJSON performs a GET request and decodes a JSON response body.
+func (c *feedClient) getJSON(ctx context.Context, endpoint string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	c.authorize(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request to %s failed: %w", endpoint, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return errPackageNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, endpoint)
+	}
+
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxFeedResponseSize)).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response from %s: %w", endpoint, err)
+	}
+	return nil
+}
+
+// authorize adds basic auth to requests on feeds that do not allow anonymous access.
+// Credentials are only sent to the configured source's host, never to other
+// hosts the service index advertises.
+func (c *feedClient) authorize(req *http.Request) {
+	if c.password != "" && c.sameHost(req.URL.String()) {
+		req.SetBasicAuth(c.username, c.password)
+	}
+}
+
+// comparableVersion reduces a version string to the normalized, lowercase form
+// the flat container uses.
+func comparableVersion(v string) string {
+	return strings.ToLower(normalizedVersion(v))
+}
+
+// packageURL returns the public gallery page for a package, if the feed has one.
+func packageURL(kind feedKind, id packageIdentity) string {
+	if kind == feedKindNuGetOrg {
+		return "https://www.nuget.org/packages/" + url.PathEscape(id.ID) + "/" + url.PathEscape(normalizedVersion(id.Version))
+	}
+	return ""
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testFeed is an in-memory NuGet V3 feed served over HTTP for tests.
+type testFeed struct {
+	server *httptest.Server
+
+	mu sync.Mutex
+	// versions maps lowercase package ids to their published versions.
+	versions map[string][]string
+	// packages maps "id/version" (lowercase) to published package bytes.
+	packages map[string][]byte
+	// noPublish omits the PackagePublish resource from the service index.
+	noPublish bool
+	// requests records the paths requested from the feed.
+	requests []string
+	// authorizations records the Authorization header of each request.
+	authorizations []string
+}
+
+// newTestFeed starts a fake feed and registers its shutdown with t.
+func newTestFeed(t *testing.T) *testFeed {
+	t.Helper()
+
+	feed := &testFeed{versions: map[string][]string{}, packages: map[string][]byte{}}
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/v3/index.json", func(w http.ResponseWriter, _ *http.Request) {
+		resources := []serviceResource{
+			{ID: feed.server.URL + "/v3-flatcontainer/", Type: resourcePackageBaseAddress},
+		}
+		if !feed.noPublish {
+			resources = append(resources, serviceResource{ID: feed.server.URL + "/api/v2/package", Type: resourcePackagePublish})
+		}
+		writeJSON(w, serviceIndex{Version: "3.0.0", Resources: resources})
+	})
+
+	mux.HandleFunc("/v3-flatcontainer/", func(w http.ResponseWriter, r *http.Request) {
+		rest := strings.TrimPrefix(r.URL.Path, "/v3-flatcontainer/")
+		parts := strings.Split(rest, "/")
+		feed.mu.Lock()
+		versions, ok := feed.versions[parts[0]]
+		data, hasPackage := []byte(nil), false
+		if len(parts) == 3 {
+			data, hasPackage = feed.packages[parts[0]+"/"+parts[1]]
+		}
+		feed.mu.Unlock()
+		if hasPackage && parts[2] == parts[0]+"."+parts[1]+".nupkg" {
+			_, _ = w.Write(data)
+			return
+		}
+		if !ok || len(parts) != 2 || parts[1] != "index.json" {
+			http.NotFound(w, r)
+			return
+		}
+		writeJSON(w, map[string]any{"versions": versions})
+	})
+
+	feed.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		feed.mu.Lock()
+		feed.requests = append(feed.requests, r.URL.Path)
+		feed.authorizations = append(feed.authorizations, r.Header.Get("Authorization"))
+		feed.mu.Unlock()
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(feed.server.Close)
+
+	return feed
+}
+
+// SourceURL returns the service index URL of the fake feed.
+func (f *testFeed) SourceURL() string {
+	return f.server.URL + "/v3/index.json"
+}
+
+// AddVersion marks a package version as already published.
+func (f *testFeed) AddVersion(id, version string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := strings.ToLower(id)
+	f.versions[key] = append(f.versions[key], strings.ToLower(version))
+}
+
+// AddPackage publishes a package version with the given contents.
+func (f *testFeed) AddPackage(id, version string, data []byte) {
+	f.AddVersion(id, version)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.packages[strings.ToLower(id)+"/"+strings.ToLower(version)] = data
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestServiceIndexResourceURL(t *testing.T) {
+	index := &serviceIndex{
+		Resources: []serviceResource{
+			{ID: "https://example.com/publish", Type: "PackagePublish/2.0.0"},
+			{ID: "https://example.com/flat/", Type: "PackageBaseAddress/3.0.0"},
+			{ID: "https://example.com/search", Type: "SearchQueryService/3.5.0"},
+		},
+	}
+
+	tests := []struct {
+		resourceType string
+		want         string
+	}{
+		{resourceType: resourcePackagePublish, want: "https://example.com/publish"},
+		{resourceType: resourcePackageBaseAddress, want: "https://example.com/flat/"},
+		{resourceType: "SearchQueryService", want: "https://example.com/search"},
+		{resourceType: "RegistrationsBaseUrl/3.6.0", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.resourceType, func(t *testing.T) {
+			if got := index.resourceURL(tt.resourceType); got != tt.want {
+				t.Errorf("resourceURL(%q) = %q, want %q", tt.resourceType, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFeedClientVersionExists(t *testing.T) {
+	feed := newTestFeed(t)
+	feed.AddVersion("Contoso.Utils", "1.0.0")
+	feed.AddVersion("Contoso.Utils", "2.0.0-beta.1")
+
+	client := &feedClient{httpClient: feed.server.Client(), source: feed.SourceURL()}
+	baseAddress := feed.server.URL + "/v3-flatcontainer/"
+
+	tests := []struct {
+		name string
+		id   packageIdentity
+		want bool
+	}{
+		{name: "existing version", id: packageIdentity{ID: "Contoso.Utils", Version: "1.0.0"}, want: true},
+		{name: "case insensitive", id: packageIdentity{ID: "contoso.utils", Version: "2.0.0-BETA.1"}, want: true},
+		{name: "ignores build metadata", id: packageIdentity{ID: "Contoso.Utils", Version: "1.0.0+sha.abc"}, want: true},
+		{name: "new version", id: packageIdentity{ID: "Contoso.Utils", Version: "1.0.1"}, want: false},
+		{name: "unknown package", id: packageIdentity{ID: "Other", Version: "1.0.0"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := client.versionExists(context.Background(), baseAddress, tt.id)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("versionExists() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFeedClientCheckAPIKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Like nuget.org, versions that are not published yet are not found.
+		if r.URL.Path != "/api/v2/verifykey/Contoso.Utils" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch r.Header.Get("X-NuGet-ApiKey") {
+		case "good":
+			w.WriteHeader(http.StatusOK)
+		case "missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "unscoped":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	id := packageIdentity{ID: "Contoso.Utils", Version: "1.0.0"}
+	tests := []struct {
+		name   string
+		kind   feedKind
+		apiKey string
+		want   apiKeyStatus
+	}{
+		{name: "valid key", kind: feedKindNuGetOrg, apiKey: "good", want: apiKeyValid},
+		{name: "rejected key", kind: feedKindNuGetOrg, apiKey: "bad", want: apiKeyInvalid},
+		{name: "key scoped to other packages", kind: feedKindNuGetOrg, apiKey: "unscoped", want: apiKeyOutOfScope},
+		{name: "package ID not yet published", kind: feedKindNuGetOrg, apiKey: "missing", want: apiKeyUnverified},
+		{name: "generic feed is not checked", kind: feedKindGeneric, apiKey: "bad", want: apiKeyUnverified},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &feedClient{httpClient: server.Client(), apiKey: tt.apiKey, kind: tt.kind}
+			got, err := client.checkAPIKey(context.Background(), server.URL+"/api/v2/package", id)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("checkAPIKey() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectFeedKind(t *testing.T) {
+	tests := []struct {
+		source string
+		want   feedKind
+	}{
+		{source: DefaultSource, want: feedKindNuGetOrg},
+		{source: "https://API.NUGET.ORG/v3/index.json", want: feedKindNuGetOrg},
+		{source: "https://nuget.example.com/v3/index.json", want: feedKindGeneric},
+		{source: "https://nuget.pkg.github.com/contoso/index.json", want: feedKindGitHub},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			if got := detectFeedKind(tt.source); got != tt.want {
+				t.Errorf("detectFeedKind(%q) = %v, want %v", tt.source, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFeedClientAdvertisedResources(t *testing.T) {
+	var resources []serviceResource
+	var authorized []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, _, ok := r.BasicAuth(); ok {
+			authorized = append(authorized, r.Host)
+		}
+		writeJSON(w, serviceIndex{Version: "3.0.0", Resources: resources})
+	}))
+	defer server.Close()
+
+	client := &feedClient{httpClient: server.Client(), source: server.URL + "/v3/index.json", username: "ci", password: "token"}
+
+	resources = []serviceResource{{ID: "http://169.254.169.254/latest/", Type: resourcePackageBaseAddress}}
+	if _, err := client.serviceIndex(context.Background()); err == nil || !strings.Contains(err.Error(), "unsafe PackageBaseAddress/3.0.0 resource") {
+		t.Errorf("expected the metadata endpoint to be rejected, got %v", err)
+	}
+
+	resources = []serviceResource{{ID: server.URL + "/v3-flatcontainer/", Type: resourcePackageBaseAddress}}
+	if _, err := client.serviceIndex(context.Background()); err != nil {
+		t.Errorf("unexpected error for a resource on the source host: %v", err)
+	}
+
+	// Requests to another host, here the same server under another name, carry no credentials.
+	other := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+	var body struct{}
+	_ = client.getJSON(context.Background(), other+"/v3-flatcontainer/contoso.core/index.json", &body)
+	if len(authorized) != 2 {
+		t.Errorf("expected credentials only on the two source requests, got %v", authorized)
+	}
+}
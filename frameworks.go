@@ -0,0 +1,230 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Policies for a target framework that disappears in a non-major release.
+const (
+	// FrameworkRemovedFail fails the package.
+	FrameworkRemovedFail = "fail"
+	// FrameworkRemovedWarn publishes the package with a warning.
+	FrameworkRemovedWarn = "warn"
+	// FrameworkRemovedIgnore skips the comparison with the published release.
+	FrameworkRemovedIgnore = "ignore"
+)
+
+// TargetFrameworks lists the target frameworks a package supports, by where
+// they are declared.
+type TargetFrameworks struct {
+	Lib          []string `json:"lib,omitempty"`
+	Ref          []string `json:"ref,omitempty"`
+	Dependencies []string `json:"dependencies,omitempty"`
+}
+
+// All returns every target framework of the package, sorted.
+func (f TargetFrameworks) All() []string {
+	set := map[string]bool{}
+	for _, list := range [][]string{f.Lib, f.Ref, f.Dependencies} {
+		for _, tfm := range list {
+			set[tfm] = true
+		}
+	}
+	return sortedSet(set)
+}
+
+// longFrameworkNames maps framework identifiers used in nuspec dependency
+// groups to their short folder names.
+var longFrameworkNames = []struct{ long, short string }{
+	{".netstandard", "netstandard"},
+	{".netframework", "net"},
+	{".netcoreapp", "netcoreapp"},
+	{".netportable", "portable"},
+}
+
+// normalizeFramework converts a target framework to its lower-case short
+// folder name, so that ".NETStandard2.0", ".NETStandard,Version=v2.0" and
+// "netstandard2.0" compare equal.
+func normalizeFramework(tfm string) string {
+	s := strings.ToLower(strings.TrimSpace(tfm))
+	s = strings.Replace(s, ",version=v", "", 1)
+	s = strings.Replace(s, ",version=", "", 1)
+	for _, name := range longFrameworkNames {
+		if !strings.HasPrefix(s, name.long) {
+			continue
+		}
+		version := s[len(name.long):]
+		switch name.short {
+		case "net":
+			// .NET Framework folders drop the dots: net462.
+			version = strings.ReplaceAll(version, ".", "")
+		case "netcoreapp":
+			// .NET 5 and later use the net prefix: net8.0.
+			major, _, _ := strings.Cut(version, ".")
+			if n, err := strconv.Atoi(major); err == nil && n >= 5 {
+				return "net" + version
+			}
+		}
+		return name.short + version
+	}
+	return s
+}
+
+// packageFrameworks reads the target frameworks of a package from its lib/
+// and ref/ folders and its nuspec dependency groups.
+func packageFrameworks(path string) (TargetFrameworks, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return TargetFrameworks{}, fmt.Errorf("failed to open package: %w", err)
+	}
+	defer func() { _ = r.Close() }()
+
+	folders := map[string]map[string]bool{"lib": {}, "ref": {}}
+	for _, f := range r.File {
+		// Only files inside a framework folder count: lib/<tfm>/<file>.
+		parts := strings.Split(f.Name, "/")
+		if len(parts) < 3 || parts[1] == "" || strings.HasSuffix(f.Name, "/") {
+			continue
+		}
+		if set, ok := folders[strings.ToLower(parts[0])]; ok {
+			set[normalizeFramework(parts[1])] = true
+		}
+	}
+
+	groups := map[string]bool{}
+	doc, err := readNuspec(path)
+	if err != nil {
+		return TargetFrameworks{}, err
+	}
+	for _, g := range doc.Metadata.Dependencies.Groups {
+		if g.TargetFramework != "" {
+			groups[normalizeFramework(g.TargetFramework)] = true
+		}
+	}
+
+	return TargetFrameworks{
+		Lib:          sortedSet(folders["lib"]),
+		Ref:          sortedSet(folders["ref"]),
+		Dependencies: sortedSet(groups),
+	}, nil
+}
+
+// removedFrameworks returns the frameworks of the baseline that the current
+// package no longer supports.
+func removedFrameworks(baseline, current TargetFrameworks) []string {
+	supported := map[string]bool{}
+	for _, tfm := range current.All() {
+		supported[tfm] = true
+	}
+	var removed []string
+	for _, tfm := range baseline.All() {
+		if !supported[tfm] {
+			removed = append(removed, tfm)
+		}
+	}
+	return removed
+}
+
+// frameworkMatrix maps each package with known target frameworks to the
+// frameworks it supports.
+func frameworkMatrix(results []PackageResult) map[string][]string {
+	matrix := map[string][]string{}
+	for _, r := range results {
+		if r.Frameworks != nil {
+			matrix[packageLabel(r)] = r.Frameworks.All()
+		}
+	}
+	return matrix
+}
+
+// checkFrameworks records the target frameworks of every pending package and,
+// unless on_framework_removed is ignore, compares them with the latest stable
+// release on the feed. Frameworks dropped in a non-major release fail the
+// package (would-fail in a dry run) or add a warning, as does a release that
+// cannot be compared because the feed is unavailable; failed packages are
+// returned.
+func (p *NuGetPlugin) checkFrameworks(ctx context.Context, cfg *Config, results []PackageResult, dryRun bool) []string {
+	pending := make([]int, 0, len(results))
+	for i := range results {
+		result := &results[i]
+		if result.Status != StatusNotAttempted || isSymbolsPackage(result.Path) {
+			continue
+		}
+		frameworks, err := packageFrameworks(result.Path)
+		if err != nil {
+			result.Message = joinReasons(result.Message, "target framework check skipped: "+err.Error())
+			continue
+		}
+		result.Frameworks = &frameworks
+		pending = append(pending, i)
+	}
+
+	policy := cfg.OnFrameworkRemoved
+	if policy == "" || policy == FrameworkRemovedIgnore || len(pending) == 0 {
+		return nil
+	}
+
+	client, baseAddress, err := p.packageBaseAddress(ctx, cfg)
+	var dropped []string
+	for _, i := range pending {
+		result := &results[i]
+		checkErr := err
+		var removed []string
+		var baseline string
+		if checkErr == nil {
+			removed, baseline, checkErr = frameworkChanges(ctx, client, baseAddress, result)
+		}
+		if checkErr != nil {
+			if policy == FrameworkRemovedWarn {
+				result.Warnings = append(result.Warnings, "target framework check could not compare with the previous release: "+checkErr.Error())
+				continue
+			}
+			baselineUnavailable(result, ErrorKindFrameworkRemoved, "target framework", checkErr, dryRun)
+			dropped = append(dropped, packageLabel(*result))
+			continue
+		}
+		result.FrameworkBaseline = baseline
+		result.RemovedFrameworks = removed
+		if len(removed) == 0 {
+			continue
+		}
+
+		msg := fmt.Sprintf("target frameworks dropped since %s: %s", baseline, strings.Join(removed, ", "))
+		if policy == FrameworkRemovedWarn {
+			result.Warnings = append(result.Warnings, msg)
+			continue
+		}
+		result.Status = StatusFailed
+		if dryRun {
+			result.Status = StatusWouldFail
+		}
+		result.setError(ErrorKindFrameworkRemoved, msg)
+		dropped = append(dropped, packageLabel(*result))
+	}
+	return dropped
+}
+
+// frameworkChanges downloads the baseline release of a package and returns
+// the target frameworks it supported that the package no longer does.
+func frameworkChanges(ctx context.Context, client *feedClient, baseAddress string, result *PackageResult) ([]string, string, error) {
+	dir, err := os.MkdirTemp("", "nuget-frameworks-*")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	path, baseline, err := downloadBaseline(ctx, client, baseAddress, result, dir)
+	if err != nil || path == "" {
+		return nil, "", err
+	}
+	previous, err := packageFrameworks(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read %s %s: %w", result.ID, baseline, err)
+	}
+	return removedFrameworks(previous, *result.Frameworks), baseline, nil
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// frameworkNuspec returns a nuspec with one dependency group per framework.
+func frameworkNuspec(id, version string, groups ...string) string {
+	var b strings.Builder
+	for _, g := range groups {
+		fmt.Fprintf(&b, "\n      <group targetFramework=%q />", g)
+	}
+	return fmt.Sprintf(`<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://schemas.microsoft.com/packaging/2013/05/nuspec.xsd">
+  <metadata>
+    <id>%s</id>
+    <version>%s</version>
+    <dependencies>%s
+    </dependencies>
+  </metadata>
+</package>`, id, version, b.String())
+}
+
+func TestNormalizeFramework(t *testing.T) {
+	tests := map[string]string{
+		"netstandard2.0":            "netstandard2.0",
+		"NetStandard2.0":            "netstandard2.0",
+		".NETStandard2.0":           "netstandard2.0",
+		".NETStandard,Version=v2.1": "netstandard2.1",
+		".NETFramework4.6.2":        "net462",
+		"net462":                    "net462",
+		".NETCoreApp3.1":            "netcoreapp3.1",
+		".NETCoreApp8.0":            "net8.0",
+		"net8.0-windows":            "net8.0-windows",
+	}
+	for in, want := range tests {
+		if got := normalizeFramework(in); got != want {
+			t.Errorf("normalizeFramework(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestPackageFrameworks(t *testing.T) {
+	path := writeTestPackageWithNuspec(t, t.TempDir(), "Contoso.Core", "1.0.0",
+		frameworkNuspec("Contoso.Core", "1.0.0", ".NETStandard2.0", "net8.0", ""),
+		map[string]string{
+			"lib/net8.0/Contoso.Core.dll":         "dll",
+			"lib/netstandard2.0/Contoso.Core.dll": "dll",
+			"lib/net462/_._":                      "",
+			"lib/Contoso.Legacy.dll":              "dll",
+			"ref/net8.0/Contoso.Core.dll":         "dll",
+			"content/net8.0/readme.txt":           "text",
+		})
+
+	got, err := packageFrameworks(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := TargetFrameworks{
+		Lib:          []string{"net462", "net8.0", "netstandard2.0"},
+		Ref:          []string{"net8.0"},
+		Dependencies: []string{"net8.0", "netstandard2.0"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("packageFrameworks() = %+v, want %+v", got, want)
+	}
+}
+
+func TestRemovedFrameworks(t *testing.T) {
+	baseline := TargetFrameworks{Lib: []string{"net8.0", "netstandard2.0"}, Dependencies: []string{"net462"}}
+	current := TargetFrameworks{Lib: []string{"net8.0", "net9.0"}, Dependencies: []string{"net462"}}
+	if got, want := removedFrameworks(baseline, current), []string{"netstandard2.0"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("removedFrameworks() = %v, want %v", got, want)
+	}
+}
+
+func TestExecuteFrameworkCheck(t *testing.T) {
+	published, err := os.ReadFile(writeTestPackageWithNuspec(t, t.TempDir(), "Contoso.Core", "1.1.0",
+		frameworkNuspec("Contoso.Core", "1.1.0", ".NETStandard2.0", "net8.0"),
+		map[string]string{
+			"lib/net8.0/Contoso.Core.dll":         "dll",
+			"lib/netstandard2.0/Contoso.Core.dll": "dll",
+		}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		version     string
+		policy      string
+		keep        bool
+		missing     bool
+		wantErr     string
+		wantWarning bool
+	}{
+		{name: "minor release drops framework", version: "1.2.0", policy: "fail", wantErr: "target framework check failed for: Contoso.Core 1.2.0"},
+		{name: "warn policy", version: "1.2.0", policy: "warn", wantWarning: true},
+		{name: "major release may drop framework", version: "2.0.0", policy: "fail"},
+		{name: "frameworks kept", version: "1.2.0", policy: "fail", keep: true},
+		{name: "check ignored", version: "1.2.0", policy: "ignore"},
+		{name: "baseline unavailable", version: "1.2.0", policy: "fail", missing: true, wantErr: "target framework check failed for: Contoso.Core 1.2.0"},
+		{name: "baseline unavailable with warn policy", version: "1.2.0", policy: "warn", missing: true, wantWarning: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feed := newTestFeed(t)
+			feed.AddVersion("Contoso.Core", "1.0.0")
+			if tt.missing {
+				feed.AddVersion("Contoso.Core", "1.1.0")
+			} else {
+				feed.AddPackage("Contoso.Core", "1.1.0", published)
+			}
+			groups := []string{".NETCoreApp8.0"}
+			files := map[string]string{"lib/net8.0/Contoso.Core.dll": "dll"}
+			if tt.keep {
+				groups = append(groups, ".NETStandard2.0")
+				files["lib/netstandard2.0/Contoso.Core.dll"] = "dll"
+			}
+			pkg := writeTestPackageWithNuspec(t, t.TempDir(), "Contoso.Core", tt.version,
+				frameworkNuspec("Contoso.Core", tt.version, groups...), files)
+
+			mockExec := &MockCommandExecutor{}
+			p := &NuGetPlugin{cmdExecutor: mockExec, httpClient: feed.server.Client()}
+			resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+				Hook: plugin.HookPostPublish,
+				Config: map[string]any{
+					"api_key":              "test-key",
+					"source":               feed.SourceURL(),
+					"package_path":         pkg,
+					"on_framework_removed": tt.policy,
+				},
+				Context: plugin.ReleaseContext{Version: "v" + tt.version},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			label := "Contoso.Core " + tt.version
+			matrix, _ := resp.Outputs["framework_matrix"].(map[string][]string)
+			wantMatrix := []string{"net8.0"}
+			if tt.keep {
+				wantMatrix = append(wantMatrix, "netstandard2.0")
+			}
+			if !reflect.DeepEqual(matrix[label], wantMatrix) {
+				t.Errorf("framework_matrix = %v, want %s: %v", matrix, label, wantMatrix)
+			}
+
+			result := resp.Outputs["packages"].([]PackageResult)[0]
+			if tt.wantErr != "" {
+				if resp.Success || !strings.Contains(resp.Error, tt.wantErr) {
+					t.Fatalf("expected error containing %q, got success=%v error=%s", tt.wantErr, resp.Success, resp.Error)
+				}
+				if len(mockExec.Calls) != 0 {
+					t.Errorf("expected nothing to be pushed, got %d pushes", len(mockExec.Calls))
+				}
+				if tt.missing {
+					if result.ErrorClass != string(ErrorKindFrameworkRemoved) || !strings.Contains(result.Error, "could not compare with the previous release") {
+						t.Errorf("unexpected result: %+v", result)
+					}
+					return
+				}
+				if result.ErrorClass != string(ErrorKindFrameworkRemoved) || result.FrameworkBaseline != "1.1.0" ||
+					!reflect.DeepEqual(result.RemovedFrameworks, []string{"netstandard2.0"}) {
+					t.Errorf("unexpected result: %+v", result)
+				}
+				return
+			}
+
+			if !resp.Success || len(mockExec.Calls) != 1 {
+				t.Fatalf("expected the push to proceed, got success=%v error=%s", resp.Success, resp.Error)
+			}
+			warning := "target frameworks dropped since 1.1.0: netstandard2.0"
+			if tt.missing {
+				warning = "target framework check could not compare with the previous release"
+			}
+			warned := strings.Contains(resp.Message, warning)
+			if warned != tt.wantWarning {
+				t.Errorf("warning in message = %v, want %v: %s", warned, tt.wantWarning, resp.Message)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// githubPackagesSource returns the GitHub Packages NuGet registry of an owner.
+func githubPackagesSource(owner string) string {
+	return "https://nuget.pkg.github.com/" + url.PathEscape(owner) + "/index.json"
+}
+
+// githubRepository parses a GitHub repository URL into its owner and name.
+func githubRepository(rawURL string) (owner, repo string, ok bool) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return "", "", false
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], strings.TrimSuffix(parts[1], ".git"), true
+}
+
+// githubPackageURL returns the repository's package page for a GitHub Packages package.
+func githubPackageURL(owner, repo, id string) string {
+	return "https://github.com/" + url.PathEscape(owner) + "/" + url.PathEscape(repo) + "/pkgs/nuget/" + url.PathEscape(id)
+}
+
+// checkGitHubRepositories verifies that each pending package declares a
+// <repository url> under the configured owner, which GitHub Packages uses to
+// link the package to a repository and otherwise rejects. Passing packages get
+// their package page URL; failing ones are marked failed (or would-fail in a
+// dry run) and returned.
+func checkGitHubRepositories(cfg *Config, results []PackageResult, dryRun bool) []string {
+	var failed []string
+	for i := range results {
+		result := &results[i]
+		if result.Status != StatusNotAttempted {
+			continue
+		}
+
+		err := func() error {
+			doc, err := readNuspec(result.pushPath())
+			if err != nil {
+				return err
+			}
+			repoURL := doc.Metadata.Repository.URL
+			if repoURL == "" {
+				return fmt.Errorf("nuspec has no <repository url>; GitHub Packages needs it to link the package to a repository (set RepositoryUrl or enable inject_metadata)")
+			}
+			owner, repo, ok := githubRepository(repoURL)
+			if !ok {
+				return fmt.Errorf("repository url %q is not a GitHub repository URL", repoURL)
+			}
+			if !strings.EqualFold(owner, cfg.GitHubOwner) {
+				return fmt.Errorf("repository url %q belongs to %s, not %s", repoURL, owner, cfg.GitHubOwner)
+			}
+			result.URL = githubPackageURL(owner, repo, result.ID)
+			return nil
+		}()
+		if err != nil {
+			result.Status = StatusFailed
+			if dryRun {
+				result.Status = StatusWouldFail
+			}
+			result.setError(ErrorKindValidation, err.Error())
+			failed = append(failed, packageLabel(*result))
+		}
+	}
+	return failed
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// writeGitHubTestPackage creates a package whose nuspec declares a repository URL.
+func writeGitHubTestPackage(t *testing.T, dir, id, repositoryURL string) string {
+	t.Helper()
+
+	repository := ""
+	if repositoryURL != "" {
+		repository = `<repository type="git" url="` + repositoryURL + `" />`
+	}
+	nuspec := `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://schemas.microsoft.com/packaging/2013/05/nuspec.xsd">
+  <metadata>
+    <id>` + id + `</id>
+    <version>1.0.0</version>
+    <authors>Test</authors>
+    <description>Test package</description>
+    ` + repository + `
+  </metadata>
+</package>`
+	return writeTestPackageWithNuspec(t, dir, id, "1.0.0", nuspec, nil)
+}
+
+func TestApplyTarget(t *testing.T) {
+	t.Setenv("GITHUB_ACTOR", "")
+	p := &NuGetPlugin{}
+
+	cfg := p.parseConfig(map[string]any{"target": "github_packages", "github_token": "tok"})
+	cfg.applyTarget(plugin.ReleaseContext{RepositoryOwner: "contoso"})
+
+	if cfg.Source != "https://nuget.pkg.github.com/contoso/index.json" {
+		t.Errorf("expected source built from the repository owner, got %s", cfg.Source)
+	}
+	if cfg.APIKey != "tok" || cfg.GitHubUsername != "contoso" {
+		t.Errorf("expected token auth as the owner, got key=%q username=%q", cfg.APIKey, cfg.GitHubUsername)
+	}
+	if cfg.feedKind() != feedKindGitHub {
+		t.Errorf("expected GitHub feed kind, got %s", cfg.feedKind())
+	}
+
+	missing := p.parseConfig(map[string]any{"target": "github_packages", "github_token": "tok"})
+	missing.applyTarget(plugin.ReleaseContext{})
+	if err := p.validateConfig(context.Background(), missing); err == nil || !strings.Contains(err.Error(), "github_owner is required") {
+		t.Errorf("expected missing owner to be rejected, got %v", err)
+	}
+}
+
+func TestGitHubRepository(t *testing.T) {
+	tests := []struct {
+		url       string
+		wantOwner string
+		wantRepo  string
+		wantOK    bool
+	}{
+		{url: "https://github.com/contoso/core", wantOwner: "contoso", wantRepo: "core", wantOK: true},
+		{url: "https://github.com/contoso/core.git", wantOwner: "contoso", wantRepo: "core", wantOK: true},
+		{url: "https://github.com/contoso", wantOK: false},
+		{url: "git@github.com:contoso/core.git", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			owner, repo, ok := githubRepository(tt.url)
+			if ok != tt.wantOK || owner != tt.wantOwner || repo != tt.wantRepo {
+				t.Errorf("githubRepository(%q) = %q, %q, %v", tt.url, owner, repo, ok)
+			}
+		})
+	}
+}
+
+func TestExecuteGitHubPackages(t *testing.T) {
+	feed := newTestFeed(t)
+
+	run := func(dir string, dryRun bool) (*plugin.ExecuteResponse, *MockCommandExecutor) {
+		t.Helper()
+		mockExec := &MockCommandExecutor{}
+		p := &NuGetPlugin{cmdExecutor: mockExec, httpClient: feed.server.Client()}
+		resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+			Hook: plugin.HookPostPublish,
+			Config: map[string]any{
+				"target":          "github_packages",
+				"github_token":    "ghp_token",
+				"github_username": "release-bot",
+				"source":          feed.SourceURL(),
+				"package_path":    filepath.Join(dir, "*.nupkg"),
+			},
+			Context: plugin.ReleaseContext{Version: "v1.0.0", RepositoryOwner: "Contoso"},
+			DryRun:  dryRun,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return resp, mockExec
+	}
+
+	badDir := t.TempDir()
+	writeGitHubTestPackage(t, badDir, "Contoso.Core", "https://github.com/contoso/core")
+	writeGitHubTestPackage(t, badDir, "Contoso.Other", "https://github.com/fabrikam/other")
+	writeGitHubTestPackage(t, badDir, "Contoso.Bare", "")
+
+	resp, mockExec := run(badDir, false)
+	if resp.Success || len(mockExec.Calls) != 0 {
+		t.Fatalf("expected failure before any push, got success=%v pushes=%d", resp.Success, len(mockExec.Calls))
+	}
+	if !strings.Contains(resp.Error, "Contoso.Other") || !strings.Contains(resp.Error, "Contoso.Bare") || strings.Contains(resp.Error, "Contoso.Core 1.0.0") {
+		t.Errorf("expected error to name only the mismatched packages, got: %s", resp.Error)
+	}
+
+	goodDir := t.TempDir()
+	writeGitHubTestPackage(t, goodDir, "Contoso.Core", "https://github.com/contoso/core")
+
+	resp, _ = run(goodDir, true)
+	if !resp.Success {
+		t.Fatalf("expected dry run to succeed, got: %s", resp.Error)
+	}
+	wantAuth := "Basic " + base64.StdEncoding.EncodeToString([]byte("release-bot:ghp_token"))
+	if len(feed.authorizations) == 0 || feed.authorizations[0] != wantAuth {
+		t.Errorf("expected feed reads to use basic auth, got %v", feed.authorizations)
+	}
+
+	resp, mockExec = run(goodDir, false)
+	if !resp.Success {
+		t.Fatalf("expected success, got: %s", resp.Error)
+	}
+	if !contains(mockExec.Calls[0].Args, "ghp_token") {
+		t.Errorf("expected push to authenticate with the token, got %v", mockExec.Calls[0].Args)
+	}
+	results := resp.Outputs["packages"].([]PackageResult)
+	if results[0].URL != "https://github.com/contoso/core/pkgs/nuget/Contoso.Core" {
+		t.Errorf("expected package page URL, got %q", results[0].URL)
+	}
+}
@@ -0,0 +1,351 @@
+package main
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// The SPDX license list the license expressions are checked against.
+var (
+	//go:embed spdx/licenses.txt
+	spdxLicenseList string
+	//go:embed spdx/exceptions.txt
+	spdxExceptionList string
+)
+
+// spdxIdentifier is an entry of the embedded SPDX license list.
+type spdxIdentifier struct {
+	ID         string
+	Deprecated bool
+}
+
+// Known SPDX identifiers keyed by their lower-case form, as SPDX identifiers
+// are matched case-insensitively.
+var (
+	spdxLicenses   = parseSPDXList(spdxLicenseList)
+	spdxExceptions = parseSPDXList(spdxExceptionList)
+)
+
+// parseSPDXList reads an embedded list of identifiers, one per line, with
+// deprecated identifiers marked by a trailing "deprecated".
+func parseSPDXList(list string) map[string]spdxIdentifier {
+	ids := map[string]spdxIdentifier{}
+	scanner := bufio.NewScanner(strings.NewReader(list))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		ids[strings.ToLower(fields[0])] = spdxIdentifier{ID: fields[0], Deprecated: len(fields) > 1 && fields[1] == "deprecated"}
+	}
+	return ids
+}
+
+// Identifier syntax of SPDX license expressions (SPDX specification annex D).
+var (
+	spdxLicenseID   = regexp.MustCompile(`^(?:LicenseRef-[A-Za-z0-9.\-]+|[A-Za-z0-9][A-Za-z0-9.\-]*\+?)$`)
+	spdxExceptionID = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9.\-]*$`)
+)
+
+// maxLicenseDepth bounds how deeply parentheses in a license expression nest.
+const maxLicenseDepth = 32
+
+// spdxOperators are the keywords of an SPDX license expression. NuGet only
+// accepts them in upper case.
+var spdxOperators = map[string]bool{"AND": true, "OR": true, "WITH": true}
+
+// licenseExpression is a parsed SPDX license expression: either a license,
+// optionally with an exception, or two expressions joined by AND or OR.
+type licenseExpression struct {
+	Op          string
+	Left, Right *licenseExpression
+	License     string
+	Exception   string
+}
+
+// String renders the expression with its identifiers in canonical case.
+func (e *licenseExpression) String() string {
+	switch {
+	case e.Op != "":
+		return e.operand(e.Left) + " " + e.Op + " " + e.operand(e.Right)
+	case e.Exception != "":
+		return e.License + " WITH " + e.Exception
+	default:
+		return e.License
+	}
+}
+
+// operand renders a child expression, parenthesizing an OR inside an AND.
+func (e *licenseExpression) operand(child *licenseExpression) string {
+	if e.Op == "AND" && child.Op == "OR" {
+		return "(" + child.String() + ")"
+	}
+	return child.String()
+}
+
+// tokenizeLicenseExpression splits an SPDX license expression into
+// parentheses and words.
+func tokenizeLicenseExpression(expr string) []string {
+	expr = strings.NewReplacer("(", " ( ", ")", " ) ").Replace(expr)
+	return strings.Fields(expr)
+}
+
+// parseLicenseExpression parses an SPDX license expression: license
+// identifiers from the SPDX license list, optionally followed by WITH and an
+// exception, combined with AND and OR and grouped with parentheses. AND binds
+// more tightly than OR. Deprecated identifiers are rejected.
+func parseLicenseExpression(expr string) (*licenseExpression, error) {
+	tokens := tokenizeLicenseExpression(expr)
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("license expression is empty")
+	}
+	p := licenseParser{tokens: tokens}
+	parsed, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in license expression", p.tokens[p.pos])
+	}
+	return parsed, nil
+}
+
+// licenseRefs returns the custom LicenseRef- identifiers an expression uses.
+func (e *licenseExpression) licenseRefs() []string {
+	if e.Op != "" {
+		return append(e.Left.licenseRefs(), e.Right.licenseRefs()...)
+	}
+	if strings.HasPrefix(e.License, "LicenseRef-") {
+		return []string{e.License}
+	}
+	return nil
+}
+
+// validateLicenseExpression checks that expr is a valid SPDX license expression.
+func validateLicenseExpression(expr string) error {
+	_, err := parseLicenseExpression(expr)
+	return err
+}
+
+// licenseParser is a recursive-descent parser over license expression tokens.
+type licenseParser struct {
+	tokens []string
+	pos    int
+	depth  int
+}
+
+// peek returns the next token, or "" at the end of the expression.
+func (p *licenseParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+// or parses AND expressions joined by OR.
+func (p *licenseParser) or() (*licenseExpression, error) {
+	return p.binary("OR", p.and)
+}
+
+// and parses terms joined by AND.
+func (p *licenseParser) and() (*licenseExpression, error) {
+	return p.binary("AND", p.term)
+}
+
+// binary parses operands joined by op, associating to the left.
+func (p *licenseParser) binary(op string, operand func() (*licenseExpression, error)) (*licenseExpression, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == op {
+		p.pos++
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		left = &licenseExpression{Op: op, Left: left, Right: right}
+	}
+	return left, nil
+}
+
+// term parses a parenthesized expression or a license with an optional exception.
+func (p *licenseParser) term() (*licenseExpression, error) {
+	token := p.peek()
+	switch {
+	case token == "":
+		return nil, fmt.Errorf("license expression ends unexpectedly")
+	case token == "(":
+		if p.depth++; p.depth > maxLicenseDepth {
+			return nil, fmt.Errorf("license expression is nested too deeply")
+		}
+		p.pos++
+		inner, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("license expression is missing a closing parenthesis")
+		}
+		p.pos++
+		p.depth--
+		return inner, nil
+	case spdxOperators[token] || token == ")":
+		return nil, fmt.Errorf("unexpected %q in license expression", token)
+	case !spdxLicenseID.MatchString(token):
+		return nil, fmt.Errorf("%q is not a valid license identifier", token)
+	}
+	p.pos++
+
+	license, err := lookupLicense(token)
+	if err != nil {
+		return nil, err
+	}
+	term := &licenseExpression{License: license}
+	if p.peek() == "WITH" {
+		p.pos++
+		exception := p.peek()
+		if exception == "" || spdxOperators[exception] || !spdxExceptionID.MatchString(exception) {
+			return nil, fmt.Errorf("WITH must be followed by a license exception identifier")
+		}
+		p.pos++
+		known, ok := spdxExceptions[strings.ToLower(exception)]
+		switch {
+		case !ok:
+			return nil, fmt.Errorf("%q is not an SPDX license exception", exception)
+		case known.Deprecated:
+			return nil, fmt.Errorf("license exception %s is deprecated", known.ID)
+		}
+		term.Exception = known.ID
+	}
+	return term, nil
+}
+
+// lookupLicense resolves a license identifier, with an optional "or later"
+// plus, against the SPDX license list and returns it in canonical case.
+// LicenseRef- identifiers are returned unchanged.
+func lookupLicense(token string) (string, error) {
+	if strings.HasPrefix(token, "LicenseRef-") {
+		return token, nil
+	}
+	known, ok := spdxLicenses[strings.ToLower(token)]
+	plus := ""
+	if !ok && strings.HasSuffix(token, "+") {
+		known, ok = spdxLicenses[strings.ToLower(strings.TrimSuffix(token, "+"))]
+		plus = "+"
+	}
+	switch {
+	case !ok:
+		return "", fmt.Errorf("%q is not an SPDX license identifier", token)
+	case known.Deprecated:
+		return "", fmt.Errorf("license identifier %s is deprecated", known.ID+plus)
+	}
+	return known.ID + plus, nil
+}
+
+// parseAllowedLicenses reads the allowed_licenses list into a set of
+// lower-case identifiers, each a license or "license WITH exception".
+// Entries that are not a single known license are returned as problems.
+func parseAllowedLicenses(entries []string) (map[string]bool, []string) {
+	allowed := map[string]bool{}
+	var problems []string
+	for _, entry := range entries {
+		parsed, err := parseLicenseExpression(entry)
+		switch {
+		case err != nil:
+			problems = append(problems, fmt.Sprintf("%s: %v", entry, err))
+		case parsed.Op != "":
+			problems = append(problems, fmt.Sprintf("%s: list each license separately instead of an expression", entry))
+		default:
+			allowed[strings.ToLower(parsed.String())] = true
+		}
+	}
+	return allowed, problems
+}
+
+// licenseAllowed reports whether a consumer can use the package under allowed
+// licenses only: one side of each OR and both sides of each AND must be
+// allowed. A license with an exception is allowed when either the license or
+// the license with that exception is listed, as exceptions only add permissions.
+func licenseAllowed(e *licenseExpression, allowed map[string]bool) bool {
+	switch e.Op {
+	case "OR":
+		return licenseAllowed(e.Left, allowed) || licenseAllowed(e.Right, allowed)
+	case "AND":
+		return licenseAllowed(e.Left, allowed) && licenseAllowed(e.Right, allowed)
+	}
+	return allowed[strings.ToLower(e.License)] || (e.Exception != "" && allowed[strings.ToLower(e.String())])
+}
+
+// licenseProblem checks the license a nuspec declares against the license
+// policy and returns the canonical expression and a description of the
+// problem, if any. The policy applies whenever allowed_licenses is set, even
+// if none of its entries could be parsed, so a typo never lifts it.
+func licenseProblem(meta nuspecMetadata, cfg *Config, allowed map[string]bool) (string, string) {
+	license := strings.TrimSpace(meta.License.Value)
+	restricted := len(cfg.AllowedLicenses) > 0
+	switch {
+	case meta.License.Type == "expression":
+		parsed, err := parseLicenseExpression(license)
+		if err != nil {
+			return "", "invalid license expression: " + err.Error()
+		}
+		if restricted && !licenseAllowed(parsed, allowed) {
+			return parsed.String(), fmt.Sprintf("license %s is not allowed by allowed_licenses", parsed)
+		}
+		return parsed.String(), ""
+	case meta.License.Type == "file":
+		if restricted {
+			return "", fmt.Sprintf("license file %s cannot be checked against allowed_licenses; use a license expression", license)
+		}
+	case strings.TrimSpace(meta.LicenseURL) != "":
+		if cfg.RejectLicenseURL {
+			return "", "package only declares the deprecated <licenseUrl>; use <license type=\"expression\"> instead"
+		}
+		if restricted {
+			return "", "package only declares a <licenseUrl>, which cannot be checked against allowed_licenses"
+		}
+	case restricted:
+		return "", "package does not declare a license"
+	}
+	return "", ""
+}
+
+// checkLicenses enforces allowed_licenses and reject_license_url on every
+// pending package and fails packages that violate them (would-fail in a dry
+// run), returning them.
+func checkLicenses(cfg *Config, results []PackageResult, dryRun bool) []string {
+	if len(cfg.AllowedLicenses) == 0 && !cfg.RejectLicenseURL {
+		return nil
+	}
+	allowed, _ := parseAllowedLicenses(cfg.AllowedLicenses)
+
+	var rejected []string
+	for i := range results {
+		result := &results[i]
+		if result.Status != StatusNotAttempted || isSymbolsPackage(result.Path) {
+			continue
+		}
+		doc, err := readNuspec(result.Path)
+		if err != nil {
+			result.Message = joinReasons(result.Message, "license check skipped: "+err.Error())
+			continue
+		}
+
+		license, problem := licenseProblem(doc.Metadata, cfg, allowed)
+		result.License = license
+		if problem == "" {
+			continue
+		}
+		result.Status = StatusFailed
+		if dryRun {
+			result.Status = StatusWouldFail
+		}
+		result.setError(ErrorKindLicense, problem)
+		rejected = append(rejected, packageLabel(*result))
+	}
+	return rejected
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestValidateLicenseExpression(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr string
+	}{
+		{expr: "MIT"},
+		{expr: "Apache-2.0 OR MIT"},
+		{expr: "GPL-2.0-or-later WITH Classpath-exception-2.0"},
+		{expr: "(MIT OR Apache-2.0) AND BSD-3-Clause"},
+		{expr: "Apache-1.1+"},
+		{expr: "mit OR apache-2.0"},
+		{expr: "LicenseRef-Contoso"},
+		{expr: "LGPL-2.1+", wantErr: "license identifier LGPL-2.1+ is deprecated"},
+		{expr: "GPL-2.0", wantErr: "license identifier GPL-2.0 is deprecated"},
+		{expr: "Contoso-1.0", wantErr: `"Contoso-1.0" is not an SPDX license identifier`},
+		{expr: "MIT WITH Contoso-exception", wantErr: `"Contoso-exception" is not an SPDX license exception`},
+		{expr: "", wantErr: "empty"},
+		{expr: "MIT or Apache-2.0", wantErr: `unexpected "or"`},
+		{expr: "MIT OR", wantErr: "ends unexpectedly"},
+		{expr: "(MIT OR Apache-2.0", wantErr: "missing a closing parenthesis"},
+		{expr: "MIT)", wantErr: `unexpected ")"`},
+		{expr: "MIT WITH", wantErr: "WITH must be followed"},
+		{expr: "MIT/Apache", wantErr: "not a valid license identifier"},
+		{expr: strings.Repeat("(", 40) + "MIT" + strings.Repeat(")", 40), wantErr: "nested too deeply"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			err := validateLicenseExpression(tt.expr)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestParseLicenseExpression(t *testing.T) {
+	tests := map[string]string{
+		"mit":                                       "MIT",
+		"MIT OR Apache-2.0 AND BSD-3-Clause":        "MIT OR Apache-2.0 AND BSD-3-Clause",
+		"(MIT OR Apache-2.0) AND BSD-3-Clause":      "(MIT OR Apache-2.0) AND BSD-3-Clause",
+		"gpl-2.0-only WITH classpath-exception-2.0": "GPL-2.0-only WITH Classpath-exception-2.0",
+	}
+	for expr, want := range tests {
+		parsed, err := parseLicenseExpression(expr)
+		if err != nil {
+			t.Errorf("parseLicenseExpression(%q) failed: %v", expr, err)
+			continue
+		}
+		if got := parsed.String(); got != want {
+			t.Errorf("parseLicenseExpression(%q) = %q, want %q", expr, got, want)
+		}
+	}
+
+	// AND binds more tightly than OR.
+	parsed, _ := parseLicenseExpression("MIT OR Apache-2.0 AND BSD-3-Clause")
+	if parsed.Op != "OR" || parsed.Right.Op != "AND" {
+		t.Errorf("expected OR at the root and AND on the right, got %+v", parsed)
+	}
+}
+
+func TestLicenseAllowed(t *testing.T) {
+	allowed, problems := parseAllowedLicenses([]string{"MIT", "apache-2.0", "GPL-2.0-only WITH Classpath-exception-2.0"})
+	if len(problems) > 0 {
+		t.Fatalf("unexpected problems: %v", problems)
+	}
+
+	tests := map[string]bool{
+		"MIT":                                       true,
+		"Apache-2.0 WITH LLVM-exception":            true,
+		"MIT OR GPL-3.0-only":                       true,
+		"MIT AND GPL-3.0-only":                      false,
+		"(MIT OR GPL-3.0-only) AND Apache-2.0":      true,
+		"GPL-2.0-only":                              false,
+		"GPL-2.0-only WITH Classpath-exception-2.0": true,
+		"BSD-3-Clause":                              false,
+	}
+	for expr, want := range tests {
+		parsed, err := parseLicenseExpression(expr)
+		if err != nil {
+			t.Fatalf("parseLicenseExpression(%q) failed: %v", expr, err)
+		}
+		if got := licenseAllowed(parsed, allowed); got != want {
+			t.Errorf("licenseAllowed(%q) = %v, want %v", expr, got, want)
+		}
+	}
+}
+
+func TestParseAllowedLicenses(t *testing.T) {
+	_, problems := parseAllowedLicenses([]string{"MIT", "MIT OR Apache-2.0", "Contoso-1.0"})
+	want := []string{
+		"MIT OR Apache-2.0: list each license separately instead of an expression",
+		`Contoso-1.0: "Contoso-1.0" is not an SPDX license identifier`,
+	}
+	if strings.Join(problems, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected problems:\n%s", strings.Join(problems, "\n"))
+	}
+}
+
+func TestExecuteLicensePolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		license string
+		config  map[string]any
+		wantErr string
+	}{
+		{name: "allowed license", license: `<license type="expression">MIT OR GPL-3.0-only</license>`, config: map[string]any{"allowed_licenses": []any{"MIT"}}},
+		{name: "license not allowed", license: `<license type="expression">GPL-3.0-only</license>`, config: map[string]any{"allowed_licenses": []any{"MIT"}}, wantErr: "license GPL-3.0-only is not allowed by allowed_licenses"},
+		{name: "license file", license: `<license type="file">LICENSE.txt</license>`, config: map[string]any{"allowed_licenses": []any{"MIT"}}, wantErr: "license file LICENSE.txt cannot be checked"},
+		{name: "no license", config: map[string]any{"allowed_licenses": []any{"MIT"}}, wantErr: "package does not declare a license"},
+		{name: "license URL only", license: `<licenseUrl>https://contoso.com/license</licenseUrl>`, config: map[string]any{"reject_license_url": true}, wantErr: "deprecated <licenseUrl>"},
+		{name: "license URL allowed by default", license: `<licenseUrl>https://contoso.com/license</licenseUrl>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg := writeTestPackageWithNuspec(t, t.TempDir(), "Contoso.Core", "1.0.0",
+				docsNuspec("Contoso.Core", "1.0.0", "", tt.license), map[string]string{"LICENSE.txt": "MIT"})
+
+			config := map[string]any{
+				"api_key":      "test-key",
+				"source":       "https://127.0.0.1/v3/index.json",
+				"package_path": pkg,
+			}
+			for k, v := range tt.config {
+				config[k] = v
+			}
+			mockExec := &MockCommandExecutor{}
+			p := &NuGetPlugin{cmdExecutor: mockExec}
+			resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+				Hook:    plugin.HookPostPublish,
+				Config:  config,
+				Context: plugin.ReleaseContext{Version: "v1.0.0"},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tt.wantErr == "" {
+				if !resp.Success || len(mockExec.Calls) != 1 {
+					t.Errorf("expected the push to proceed, got success=%v error=%s", resp.Success, resp.Error)
+				}
+				return
+			}
+			if resp.Success || !strings.Contains(resp.Error, "license policy rejected package(s): Contoso.Core 1.0.0") {
+				t.Fatalf("expected the license policy to reject the package, got success=%v error=%s", resp.Success, resp.Error)
+			}
+			if len(mockExec.Calls) != 0 {
+				t.Errorf("expected nothing to be pushed, got %d pushes", len(mockExec.Calls))
+			}
+			result := resp.Outputs["packages"].([]PackageResult)[0]
+			if result.ErrorClass != string(ErrorKindLicense) || !strings.Contains(result.Error, tt.wantErr) {
+				t.Errorf("expected %s error containing %q, got %s: %s", ErrorKindLicense, tt.wantErr, result.ErrorClass, result.Error)
+			}
+		})
+	}
+}
+
+func TestLicensePolicyWithInvalidEntries(t *testing.T) {
+	cfg := &Config{
+		APIKey:          "key",
+		Source:          "https://127.0.0.1/v3/index.json",
+		PackagePath:     DefaultPackagePath,
+		Timeout:         DefaultTimeout,
+		AllowedLicenses: []string{"Apache 2.0"},
+	}
+	if err := (&NuGetPlugin{}).validateConfig(context.Background(), cfg); err == nil || !strings.Contains(err.Error(), "invalid allowed_licenses") {
+		t.Errorf("expected invalid allowed_licenses to fail validation, got %v", err)
+	}
+
+	// Even without validation, an allow-list with no usable entry allows nothing.
+	allowed, _ := parseAllowedLicenses(cfg.AllowedLicenses)
+	meta := nuspecMetadata{License: nuspecLicense{Type: "expression", Value: "GPL-3.0-only"}}
+	if _, problem := licenseProblem(meta, cfg, allowed); !strings.Contains(problem, "is not allowed by allowed_licenses") {
+		t.Errorf("expected the license to be rejected, got %q", problem)
+	}
+}
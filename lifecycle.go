@@ -0,0 +1,560 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// Release lifecycle phases. Each phase runs on a fixed hook and can be
+// toggled individually with the phases config map.
+const (
+	// PhasePreflight checks configuration, credentials, feed reachability and
+	// package presence on PreInit, before any release work is done.
+	PhasePreflight = "preflight"
+	// PhaseValidate checks every package against the feed on PrePublish.
+	PhaseValidate = "validate"
+	// PhasePush pushes the packages on PostPublish.
+	PhasePush = "push"
+	// PhaseVerify confirms the pushed versions are listed on the feed, after the push.
+	PhaseVerify = "verify"
+	// PhaseCleanup prunes plugin state on OnSuccess and OnError.
+	PhaseCleanup = "cleanup"
+	// PhaseNotify reports the release outcome on OnSuccess and OnError.
+	PhaseNotify = "notify"
+)
+
+// DefaultVerifyTimeout is the default time in seconds to wait for pushed versions to be listed.
+const DefaultVerifyTimeout = 600
+
+// verifyPollInterval is the delay between feed checks while verifying.
+const verifyPollInterval = 15 * time.Second
+
+// defaultPhases returns the phases enabled when the phases map does not mention them.
+// Preflight and validate are opt-in because they need the built packages before
+// PostPublish and network access to the feed, which existing configs may not
+// provide. Verification is opt-in because feeds such as nuget.org can take
+// minutes to list a package.
+func defaultPhases() map[string]bool {
+	return map[string]bool{
+		PhasePreflight: false,
+		PhaseValidate:  false,
+		PhasePush:      true,
+		PhaseVerify:    false,
+		PhaseCleanup:   true,
+		PhaseNotify:    true,
+	}
+}
+
+// parsePhases merges the phases config map over the defaults.
+// Unknown phases and non-boolean values are returned as errors for validation.
+func parsePhases(raw map[string]any) (map[string]bool, []string) {
+	phases := defaultPhases()
+	var problems []string
+	for _, name := range sortedPhaseNames(raw) {
+		if _, known := phases[name]; !known {
+			problems = append(problems, fmt.Sprintf("unknown phase %q", name))
+			continue
+		}
+		enabled, ok := raw[name].(bool)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("phase %q must be true or false", name))
+			continue
+		}
+		phases[name] = enabled
+	}
+	return phases, problems
+}
+
+// sortedPhaseNames returns the keys of a phases map in a stable order.
+func sortedPhaseNames(raw map[string]any) []string {
+	names := make([]string, 0, len(raw))
+	for name := range raw {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// phaseEnabled reports whether a lifecycle phase is enabled.
+func (c *Config) phaseEnabled(phase string) bool {
+	if c.Phases == nil {
+		return defaultPhases()[phase]
+	}
+	return c.Phases[phase]
+}
+
+// phaseDisabledResponse is returned when the phase bound to a hook is turned off.
+func phaseDisabledResponse(hook plugin.Hook, phases ...string) *plugin.ExecuteResponse {
+	return &plugin.ExecuteResponse{
+		Success: true,
+		Message: fmt.Sprintf("Hook %s skipped: phase %s disabled", hook, strings.Join(phases, " and ")),
+	}
+}
+
+// preflight checks that a release can publish before any release work is done:
+// the configuration is valid, packages are present, the feed is reachable and
+// accepts pushes, and the API key is accepted where the feed can tell.
+func (p *NuGetPlugin) preflight(ctx context.Context, cfg *Config, releaseCtx plugin.ReleaseContext) (*plugin.ExecuteResponse, error) {
+	started := time.Now()
+	version := strings.TrimPrefix(releaseCtx.Version, "v")
+
+	// Every response carries the same outputs as a push, plus the feed check
+	// once the feed has been contacted.
+	var results []PackageResult
+	var check *feedCheck
+	outputs := func() map[string]any {
+		o := resultOutputs(summarize(cfg, version, true, results, time.Since(started)), results)
+		o["phase"] = PhasePreflight
+		if check != nil {
+			o["feed"] = *check
+		}
+		return o
+	}
+
+	if err := p.validateConfig(ctx, cfg); err != nil {
+		return &plugin.ExecuteResponse{Success: false, Error: fmt.Sprintf("preflight: configuration validation failed: %v", err), Outputs: outputs()}, nil
+	}
+
+	packages, promoted, err := p.releasePackages(ctx, cfg, version)
+	if err != nil {
+		return &plugin.ExecuteResponse{Success: false, Error: "preflight: " + err.Error(), Outputs: outputs()}, nil
+	}
+	defer promoted.cleanup()
+
+	results = newPackageResults(packages, cfg.Source)
+	identifyPackages(results, cfg.feedKind())
+	if oversized := checkPackageSizes(cfg, results, true); len(oversized) > 0 {
+		return &plugin.ExecuteResponse{
+			Success: false,
+			Error:   fmt.Sprintf("preflight: package(s) too large to push: %s (hint: %s)", oversizedDetails(results), ErrorKindTooLarge.Hint()),
+			Outputs: outputs(),
+		}, nil
+	}
+	if flagged := scanContents(cfg, results, true); len(flagged) > 0 {
+		return &plugin.ExecuteResponse{
+			Success: false,
+			Error:   fmt.Sprintf("preflight: content scan flagged package(s): %s (hint: %s)", strings.Join(flagged, ", "), ErrorKindForbiddenContent.Hint()),
+			Outputs: outputs(),
+		}, nil
+	}
+	if flagged := checkPackageDocs(cfg, results, true); len(flagged) > 0 {
+		return &plugin.ExecuteResponse{
+			Success: false,
+			Error:   fmt.Sprintf("preflight: readme or license check failed for package(s): %s (hint: %s)", strings.Join(flagged, ", "), ErrorKindPackageDocs.Hint()),
+			Outputs: outputs(),
+		}, nil
+	}
+	if rejected := checkLicenses(cfg, results, true); len(rejected) > 0 {
+		return &plugin.ExecuteResponse{
+			Success: false,
+			Error:   fmt.Sprintf("preflight: license policy rejected package(s): %s (hint: %s)", strings.Join(rejected, ", "), ErrorKindLicense.Hint()),
+			Outputs: outputs(),
+		}, nil
+	}
+	if mismatched := checkAssemblyVersions(cfg, results, true); len(mismatched) > 0 {
+		return &plugin.ExecuteResponse{
+			Success: false,
+			Error:   fmt.Sprintf("preflight: assembly versions do not match package version in: %s (hint: %s)", strings.Join(mismatched, ", "), ErrorKindAssemblyVersion.Hint()),
+			Outputs: outputs(),
+		}, nil
+	}
+	if breaking := p.checkPublicAPI(ctx, cfg, results, true); len(breaking) > 0 {
+		return &plugin.ExecuteResponse{
+			Success: false,
+			Error:   fmt.Sprintf("preflight: public API check failed for: %s (hint: %s)", strings.Join(breaking, ", "), ErrorKindBreakingChange.Hint()),
+			Outputs: outputs(),
+		}, nil
+	}
+	if dropped := p.checkFrameworks(ctx, cfg, results, true); len(dropped) > 0 {
+		return &plugin.ExecuteResponse{
+			Success: false,
+			Error:   fmt.Sprintf("preflight: target framework check failed for: %s (hint: %s)", strings.Join(dropped, ", "), ErrorKindFrameworkRemoved.Hint()),
+			Outputs: outputs(),
+		}, nil
+	}
+
+	client := p.newFeedClient(cfg)
+	check = &feedCheck{APIKey: apiKeyUnverified}
+
+	index, err := client.serviceIndex(ctx)
+	if err != nil {
+		check.Error = err.Error()
+		return &plugin.ExecuteResponse{Success: false, Error: fmt.Sprintf("preflight: feed unreachable: %v", err), Outputs: outputs()}, nil
+	}
+	check.Reachable = true
+	check.PublishURL = index.resourceURL(resourcePackagePublish)
+	if check.PublishURL == "" {
+		check.Error = "service index does not advertise a PackagePublish resource"
+		return &plugin.ExecuteResponse{Success: false, Error: "preflight: " + check.Error, Outputs: outputs()}, nil
+	}
+
+	warning, err := p.checkCredentials(ctx, cfg, client, check, packages)
+	if err != nil {
+		check.Error = err.Error()
+		return &plugin.ExecuteResponse{
+			Success: false,
+			Error:   fmt.Sprintf("preflight: %v (hint: %s)", err, ErrorKindAuth.Hint()),
+			Outputs: outputs(),
+		}, nil
+	}
+
+	message := fmt.Sprintf("Preflight passed: %d package(s) ready for %s (API key %s)", len(packages), cfg.Source, check.APIKey)
+	if warning != "" {
+		message += "; warning: " + warning
+	}
+	if releaseCtx.Version != "" {
+		for _, w := range versionWarnings(releaseCtx.Version) {
+			message += "; warning: " + w
+		}
+	}
+	for _, r := range results {
+		if len(r.RemovedFrameworks) > 0 {
+			message += fmt.Sprintf("; warning: %s: target frameworks dropped since %s: %s", packageLabel(r), r.FrameworkBaseline, strings.Join(r.RemovedFrameworks, ", "))
+		}
+	}
+	return &plugin.ExecuteResponse{
+		Success: true,
+		Message: message,
+		Outputs: outputs(),
+	}, nil
+}
+
+// validatePackages plans the push against the feed before publishing and fails
+// if any package would be rejected. Nothing is pushed.
+func (p *NuGetPlugin) validatePackages(ctx context.Context, cfg *Config, releaseCtx plugin.ReleaseContext) (*plugin.ExecuteResponse, error) {
+	resp, err := p.pushPackage(ctx, cfg, releaseCtx, true)
+	if err != nil || !resp.Success {
+		return resp, err
+	}
+	resp.Outputs["phase"] = PhaseValidate
+
+	results, _ := resp.Outputs["packages"].([]PackageResult)
+	var failing []string
+	for _, r := range results {
+		if r.Status == StatusWouldFail {
+			failing = append(failing, fmt.Sprintf("%s: %s", packageLabel(r), r.Error))
+		}
+	}
+	if len(failing) > 0 {
+		resp.Success = false
+		resp.Message = ""
+		resp.Error = fmt.Sprintf("validation failed for %d package(s): %s", len(failing), strings.Join(failing, "; "))
+		return resp, nil
+	}
+
+	summary, _ := resp.Outputs["summary"].(RunSummary)
+	resp.Message = fmt.Sprintf("Validation passed: %d package(s) ready to push", summary.Pushed)
+	if summary.Skipped > 0 {
+		resp.Message += fmt.Sprintf(" (%d skipped)", summary.Skipped)
+	}
+	return resp, nil
+}
+
+// publish runs the push and verify phases bound to PostPublish.
+func (p *NuGetPlugin) publish(ctx context.Context, cfg *Config, releaseCtx plugin.ReleaseContext, dryRun bool) (*plugin.ExecuteResponse, error) {
+	push, verify := cfg.phaseEnabled(PhasePush), cfg.phaseEnabled(PhaseVerify)
+	if !push && !verify {
+		return phaseDisabledResponse(plugin.HookPostPublish, PhasePush, PhaseVerify), nil
+	}
+
+	if !push {
+		return p.verifyOnly(ctx, cfg, releaseCtx, dryRun)
+	}
+
+	resp, err := p.pushPackage(ctx, cfg, releaseCtx, dryRun)
+	if err != nil {
+		return resp, err
+	}
+
+	if resp.Success && verify && !dryRun {
+		results, _ := resp.Outputs["packages"].([]PackageResult)
+		if missing := p.verifyPublished(ctx, cfg, results); len(missing) > 0 {
+			resp.Success = false
+			resp.Error = fmt.Sprintf("%s, but %d package(s) are not listed on the feed after %ds: %s",
+				resp.Message, len(missing), cfg.VerifyTimeout, strings.Join(missing, ", "))
+			resp.Message = ""
+		} else {
+			resp.Message += "; verified on feed"
+		}
+	}
+
+	p.attachMarkdownSummary(cfg, resp, dryRun)
+	return resp, nil
+}
+
+// attachMarkdownSummary adds the Markdown release summary to the outputs and,
+// outside dry runs, writes it to summary_path and the job summary. Failing to
+// write the summary does not fail the release.
+func (p *NuGetPlugin) attachMarkdownSummary(cfg *Config, resp *plugin.ExecuteResponse, dryRun bool) {
+	summary, ok := resp.Outputs["summary"].(RunSummary)
+	if !ok {
+		return
+	}
+	results, _ := resp.Outputs["packages"].([]PackageResult)
+	markdown := renderMarkdownSummary(summary, results)
+	resp.Outputs["markdown_summary"] = markdown
+
+	if dryRun {
+		return
+	}
+	if err := writeMarkdownSummary(cfg, markdown); err != nil && resp.Success {
+		resp.Message += "; warning: " + err.Error()
+	}
+}
+
+// verifyOnly verifies the packages of a release that was pushed outside this plugin.
+func (p *NuGetPlugin) verifyOnly(ctx context.Context, cfg *Config, releaseCtx plugin.ReleaseContext, dryRun bool) (*plugin.ExecuteResponse, error) {
+	started := time.Now()
+	version := strings.TrimPrefix(releaseCtx.Version, "v")
+
+	packages, promoted, err := p.releasePackages(ctx, cfg, version)
+	if err != nil {
+		summary := summarize(cfg, version, dryRun, nil, time.Since(started))
+		return failureResponse(summary, nil, err.Error()), nil
+	}
+	defer promoted.cleanup()
+	results := newPackageResults(packages, cfg.Source)
+	identifyPackages(results, cfg.feedKind())
+
+	if dryRun {
+		summary := summarize(cfg, version, dryRun, results, time.Since(started))
+		return &plugin.ExecuteResponse{
+			Success: true,
+			Message: fmt.Sprintf("Would verify %d package(s) on the feed", len(results)),
+			Outputs: resultOutputs(summary, results),
+		}, nil
+	}
+
+	missing := p.verifyPublished(ctx, cfg, results)
+	summary := summarize(cfg, version, dryRun, results, time.Since(started))
+	if len(missing) > 0 {
+		return failureResponse(summary, results, fmt.Sprintf("%d package(s) are not listed on the feed after %ds: %s",
+			len(missing), cfg.VerifyTimeout, strings.Join(missing, ", "))), nil
+	}
+	return &plugin.ExecuteResponse{
+		Success: true,
+		Message: fmt.Sprintf("Verified %d package(s) on the feed", len(results)),
+		Outputs: resultOutputs(summary, results),
+	}, nil
+}
+
+// verifyPublished polls the feed until every pushed or skipped package version is
+// listed, or VerifyTimeout elapses. It marks verified results and returns the
+// packages that could not be confirmed.
+func (p *NuGetPlugin) verifyPublished(ctx context.Context, cfg *Config, results []PackageResult) []string {
+	client := p.newFeedClient(cfg)
+	deadline := time.Now().Add(time.Duration(cfg.VerifyTimeout) * time.Second)
+
+	for {
+		missing := p.checkListed(ctx, client, results)
+		if len(missing) == 0 || !time.Now().Add(verifyPollInterval).Before(deadline) {
+			return missing
+		}
+		if err := sleepContext(ctx, verifyPollInterval); err != nil {
+			return missing
+		}
+	}
+}
+
+// checkListed checks each unverified package against the flat container once.
+func (p *NuGetPlugin) checkListed(ctx context.Context, client *feedClient, results []PackageResult) []string {
+	var missing []string
+
+	index, err := client.serviceIndex(ctx)
+	var baseAddress string
+	if err == nil {
+		baseAddress = index.resourceURL(resourcePackageBaseAddress)
+	}
+
+	for i := range results {
+		result := &results[i]
+		if result.Verified || result.Status == StatusFailed || result.Status == StatusWouldFail {
+			continue
+		}
+		if result.ID == "" || baseAddress == "" {
+			missing = append(missing, packageLabel(*result))
+			continue
+		}
+		exists, err := client.versionExists(ctx, baseAddress, packageIdentity{ID: result.ID, Version: result.Version})
+		if err != nil || !exists {
+			missing = append(missing, packageIdentity{ID: result.ID, Version: result.Version}.String())
+			continue
+		}
+		result.Verified = true
+	}
+	return missing
+}
+
+// packageLabel names a result by its identity, or its file name if unidentified.
+func packageLabel(r PackageResult) string {
+	if r.ID != "" {
+		return packageIdentity{ID: r.ID, Version: r.Version}.String()
+	}
+	return stateKey(&r)
+}
+
+// releaseNotification is the outcome report produced by the notify phase.
+type releaseNotification struct {
+	Outcome  string            `json:"outcome"`
+	Version  string            `json:"version"`
+	Source   string            `json:"source"`
+	Text     string            `json:"text"`
+	Packages []notifiedPackage `json:"packages"`
+}
+
+// notifiedPackage is a package listed in a release notification.
+type notifiedPackage struct {
+	ID      string        `json:"id"`
+	Version string        `json:"version"`
+	URL     string        `json:"url,omitempty"`
+	Status  PackageStatus `json:"status,omitempty"`
+}
+
+// finish runs the cleanup and notify phases bound to OnSuccess and OnError.
+// They also run for channels that are not pushed, so the notification says so.
+func (p *NuGetPlugin) finish(cfg *Config, channel *releaseChannel, hook plugin.Hook, releaseCtx plugin.ReleaseContext, dryRun bool) (*plugin.ExecuteResponse, error) {
+	cleanup, notify := cfg.phaseEnabled(PhaseCleanup), cfg.phaseEnabled(PhaseNotify)
+	if !cleanup && !notify {
+		return phaseDisabledResponse(hook, PhaseCleanup, PhaseNotify), nil
+	}
+
+	succeeded := hook == plugin.HookOnSuccess
+	version := strings.TrimPrefix(releaseCtx.Version, "v")
+	outputs := map[string]any{}
+	var messages []string
+
+	var state *pushState
+	if cfg.Resume {
+		loaded, err := loadPushState(cfg.StateFile)
+		if err != nil {
+			messages = append(messages, fmt.Sprintf("state file unavailable: %v", err))
+		} else {
+			state = loaded
+		}
+	}
+
+	if cleanup {
+		messages = append(messages, cleanupState(state, version, succeeded, dryRun))
+	}
+
+	if notify {
+		notification := p.buildNotification(cfg, channel, state, version, succeeded)
+		outputs["notification"] = notification
+		messages = append(messages, notification.Text)
+	}
+
+	return &plugin.ExecuteResponse{
+		Success: true,
+		Message: strings.Join(messages, "; "),
+		Outputs: outputs,
+	}, nil
+}
+
+// cleanupState prunes the push state once a release succeeded, keeping only the
+// current release so a later re-run still recognizes its packages. After a
+// failure the state is kept as is so the release can be resumed.
+func cleanupState(state *pushState, version string, succeeded, dryRun bool) string {
+	if state == nil {
+		return "nothing to clean up"
+	}
+	if !succeeded {
+		return "push state kept for resuming the release"
+	}
+
+	current := comparableVersion(version)
+	var stale []string
+	for v := range state.Releases {
+		if v != current {
+			stale = append(stale, v)
+		}
+	}
+	if len(stale) == 0 {
+		return "nothing to clean up"
+	}
+	if dryRun {
+		return fmt.Sprintf("would prune push state of %d earlier release(s)", len(stale))
+	}
+
+	for _, v := range stale {
+		delete(state.Releases, v)
+	}
+	if err := state.save(); err != nil {
+		return fmt.Sprintf("could not prune push state: %v", err)
+	}
+	return fmt.Sprintf("pruned push state of %d earlier release(s)", len(stale))
+}
+
+// buildNotification describes the outcome of the release for the packages the push handled.
+// Package statuses come from the push state when resume is enabled.
+func (p *NuGetPlugin) buildNotification(cfg *Config, channel *releaseChannel, state *pushState, version string, succeeded bool) releaseNotification {
+	notification := releaseNotification{
+		Outcome:  "success",
+		Version:  version,
+		Source:   cfg.Source,
+		Packages: []notifiedPackage{},
+	}
+	if !succeeded {
+		notification.Outcome = "error"
+	}
+
+	results := p.releaseResults(cfg, version)
+
+	confirmed := 0
+	for i := range results {
+		result := &results[i]
+		if result.ID == "" {
+			continue
+		}
+		pkg := notifiedPackage{ID: result.ID, Version: result.Version, URL: result.URL}
+		if state != nil {
+			if recorded, ok := state.lookup(version, result.Feed, stateKey(result)); ok {
+				pkg.Status = recorded.Status
+				confirmed++
+			}
+		}
+		notification.Packages = append(notification.Packages, pkg)
+	}
+
+	switch {
+	case channel != nil && !channel.Push:
+		notification.Text = fmt.Sprintf("Release %s not pushed (channel %s); %d NuGet package(s) were not published", version, channel.Name, len(notification.Packages))
+	case succeeded:
+		notification.Text = fmt.Sprintf("Released %d NuGet package(s) for version %s to %s", len(notification.Packages), version, cfg.Source)
+	case state != nil:
+		notification.Text = fmt.Sprintf("Release %s failed after %d of %d NuGet package(s) were confirmed; re-run to resume", version, confirmed, len(notification.Packages))
+	default:
+		notification.Text = fmt.Sprintf("Release %s failed; %d NuGet package(s) may be partially published to %s", version, len(notification.Packages), cfg.Source)
+	}
+	return notification
+}
+
+// releaseResults returns the package results the push starts from, without
+// downloading anything: in promote mode the promote_packages IDs at the release
+// version, otherwise the local files matching package_path.
+func (p *NuGetPlugin) releaseResults(cfg *Config, version string) []PackageResult {
+	if cfg.PromoteFrom != "" {
+		results := make([]PackageResult, 0, len(cfg.PromotePackages))
+		for _, id := range cfg.PromotePackages {
+			identity := packageIdentity{ID: id, Version: version}
+			results = append(results, PackageResult{
+				ID:           id,
+				Version:      version,
+				URL:          packageURL(cfg.feedKind(), identity),
+				Status:       StatusNotAttempted,
+				Feed:         cfg.Source,
+				PromotedFrom: cfg.PromoteFrom,
+			})
+		}
+		return results
+	}
+
+	packages, _ := p.findPackages(cfg.PackagePath)
+	results := newPackageResults(packages, cfg.Source)
+	identifyPackages(results, cfg.feedKind())
+	return results
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestParsePhases(t *testing.T) {
+	tests := []struct {
+		name         string
+		raw          map[string]any
+		wantDisabled []string
+		wantProblems int
+	}{
+		{name: "defaults", raw: nil, wantDisabled: []string{PhasePreflight, PhaseValidate, PhaseVerify}},
+		{name: "toggle phases", raw: map[string]any{"preflight": true, "push": false, "verify": true}, wantDisabled: []string{PhaseValidate, PhasePush}},
+		{name: "unknown phase", raw: map[string]any{"deploy": true}, wantDisabled: []string{PhasePreflight, PhaseValidate, PhaseVerify}, wantProblems: 1},
+		{name: "non-boolean value", raw: map[string]any{"push": "no"}, wantDisabled: []string{PhasePreflight, PhaseValidate, PhaseVerify}, wantProblems: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			phases, problems := parsePhases(tt.raw)
+			if len(problems) != tt.wantProblems {
+				t.Errorf("expected %d problems, got %v", tt.wantProblems, problems)
+			}
+			var disabled []string
+			for _, name := range []string{PhasePreflight, PhaseValidate, PhasePush, PhaseVerify, PhaseCleanup, PhaseNotify} {
+				if !phases[name] {
+					disabled = append(disabled, name)
+				}
+			}
+			if strings.Join(disabled, ",") != strings.Join(tt.wantDisabled, ",") {
+				t.Errorf("expected disabled phases %v, got %v", tt.wantDisabled, disabled)
+			}
+		})
+	}
+}
+
+func TestLifecycleHooks(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTestPackage(t, tmpDir, "Contoso.Core", "1.0.0", nil)
+	feed := newTestFeed(t)
+
+	pushFeed := newTestFeed(t)
+	pushFeed.noPublish = true
+
+	existsFeed := newTestFeed(t)
+	existsFeed.AddVersion("Contoso.Core", "1.0.0")
+
+	tests := []struct {
+		name        string
+		hook        plugin.Hook
+		feed        *testFeed
+		config      map[string]any
+		wantSuccess bool
+		wantMsg     string
+		wantPushes  int
+		wantOutputs bool
+	}{
+		{name: "preflight passes", hook: plugin.HookPreInit, feed: feed, wantSuccess: true, wantMsg: "Preflight passed: 1 package(s)", wantOutputs: true},
+		{name: "preflight without packages", hook: plugin.HookPreInit, feed: feed, config: map[string]any{"package_path": filepath.Join(tmpDir, "missing", "*.nupkg")}, wantMsg: "no packages found", wantOutputs: true},
+		{name: "preflight feed without publish resource", hook: plugin.HookPreInit, feed: pushFeed, wantMsg: "PackagePublish", wantOutputs: true},
+		{name: "preflight disabled", hook: plugin.HookPreInit, feed: feed, config: map[string]any{"phases": map[string]any{"preflight": false}}, wantSuccess: true, wantMsg: "phase preflight disabled"},
+		{name: "preflight disabled by default", hook: plugin.HookPreInit, feed: feed, config: map[string]any{"phases": map[string]any{}, "package_path": filepath.Join(tmpDir, "missing", "*.nupkg")}, wantSuccess: true, wantMsg: "phase preflight disabled"},
+		{name: "validate disabled by default", hook: plugin.HookPrePublish, feed: existsFeed, config: map[string]any{"phases": map[string]any{}}, wantSuccess: true, wantMsg: "phase validate disabled"},
+		{name: "validate passes", hook: plugin.HookPrePublish, feed: feed, wantSuccess: true, wantMsg: "Validation passed: 1 package(s) ready to push"},
+		{name: "validate rejects existing version", hook: plugin.HookPrePublish, feed: existsFeed, wantMsg: "validation failed for 1 package(s)"},
+		{name: "validate skips existing version when allowed", hook: plugin.HookPrePublish, feed: existsFeed, config: map[string]any{"on_duplicate": "skip"}, wantSuccess: true, wantMsg: "(1 skipped)"},
+		{name: "push disabled", hook: plugin.HookPostPublish, feed: feed, config: map[string]any{"phases": map[string]any{"push": false}}, wantSuccess: true, wantMsg: "phase push and verify disabled"},
+		{name: "push and verify", hook: plugin.HookPostPublish, feed: existsFeed, config: map[string]any{"phases": map[string]any{"verify": true}, "verify_timeout": 0}, wantSuccess: true, wantMsg: "verified on feed", wantPushes: 1},
+		{name: "verify finds missing version", hook: plugin.HookPostPublish, feed: feed, config: map[string]any{"phases": map[string]any{"verify": true}, "verify_timeout": 0}, wantMsg: "not listed on the feed", wantPushes: 1},
+		{name: "verify only", hook: plugin.HookPostPublish, feed: existsFeed, config: map[string]any{"phases": map[string]any{"push": false, "verify": true}, "verify_timeout": 0}, wantSuccess: true, wantMsg: "Verified 1 package(s)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockExec := &MockCommandExecutor{}
+			p := &NuGetPlugin{cmdExecutor: mockExec, httpClient: tt.feed.server.Client()}
+
+			config := map[string]any{
+				"api_key":      "test-key",
+				"source":       tt.feed.SourceURL(),
+				"package_path": filepath.Join(tmpDir, "*.nupkg"),
+				"phases":       map[string]any{"preflight": true, "validate": true},
+			}
+			for k, v := range tt.config {
+				config[k] = v
+			}
+
+			resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+				Hook:    tt.hook,
+				Config:  config,
+				Context: plugin.ReleaseContext{Version: "v1.0.0"},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if resp.Success != tt.wantSuccess {
+				t.Errorf("expected success=%v, got success=%v (error: %s)", tt.wantSuccess, resp.Success, resp.Error)
+			}
+			if got := resp.Message + resp.Error; !strings.Contains(got, tt.wantMsg) {
+				t.Errorf("expected response to contain %q, got %q", tt.wantMsg, got)
+			}
+			if tt.wantOutputs {
+				if _, ok := resp.Outputs["packages"].([]PackageResult); !ok {
+					t.Errorf("expected package results, got %T", resp.Outputs["packages"])
+				}
+				if _, ok := resp.Outputs["summary"].(RunSummary); !ok {
+					t.Errorf("expected run summary, got %T", resp.Outputs["summary"])
+				}
+				if resp.Outputs["phase"] != PhasePreflight {
+					t.Errorf("expected phase %q, got %v", PhasePreflight, resp.Outputs["phase"])
+				}
+			}
+			if len(mockExec.Calls) != tt.wantPushes {
+				t.Errorf("expected %d pushes, got %d", tt.wantPushes, len(mockExec.Calls))
+			}
+		})
+	}
+}
+
+func TestFinishPhases(t *testing.T) {
+	tmpDir := t.TempDir()
+	pkg := writeTestPackage(t, tmpDir, "Contoso.Core", "1.0.0", nil)
+	stateFile := filepath.Join(tmpDir, "state.json")
+
+	state, err := loadPushState(stateFile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	hash, err := fileSHA512(pkg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pushed := pushedPackage{Path: pkg, SHA512: hash, Status: StatusPushed, PushedAt: time.Now()}
+	for _, version := range []string{"0.9.0", "1.0.0"} {
+		if err := state.record(version, DefaultSource, "contoso.core/1.0.0", pushed); err != nil {
+			t.Fatalf("failed to record state: %v", err)
+		}
+	}
+
+	p := &NuGetPlugin{}
+	run := func(hook plugin.Hook) *plugin.ExecuteResponse {
+		t.Helper()
+		resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+			Hook: hook,
+			Config: map[string]any{
+				"api_key":      "test-key",
+				"package_path": pkg,
+				"resume":       true,
+				"state_file":   stateFile,
+			},
+			Context: plugin.ReleaseContext{Version: "v1.0.0"},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !resp.Success {
+			t.Fatalf("expected %s to succeed, got: %s", hook, resp.Error)
+		}
+		return resp
+	}
+
+	resp := run(plugin.HookOnError)
+	notification := resp.Outputs["notification"].(releaseNotification)
+	if notification.Outcome != "error" || !strings.Contains(notification.Text, "1 of 1") {
+		t.Errorf("unexpected error notification: %+v", notification)
+	}
+	if !strings.Contains(resp.Message, "push state kept") {
+		t.Errorf("expected state to be kept after an error, got %q", resp.Message)
+	}
+
+	resp = run(plugin.HookOnSuccess)
+	notification = resp.Outputs["notification"].(releaseNotification)
+	if notification.Outcome != "success" || len(notification.Packages) != 1 || notification.Packages[0].Status != StatusPushed {
+		t.Errorf("unexpected success notification: %+v", notification)
+	}
+	if notification.Packages[0].URL != "https://www.nuget.org/packages/Contoso.Core/1.0.0" {
+		t.Errorf("expected gallery URL, got %q", notification.Packages[0].URL)
+	}
+
+	reloaded, err := loadPushState(stateFile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := reloaded.Releases["0.9.0"]; ok {
+		t.Error("expected earlier release to be pruned from the state file")
+	}
+	if _, ok := reloaded.lookup("1.0.0", DefaultSource, "contoso.core/1.0.0"); !ok {
+		t.Error("expected current release to be kept in the state file")
+	}
+}
+
+func TestFinishPromoteMode(t *testing.T) {
+	p := &NuGetPlugin{}
+	resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+		Hook: plugin.HookOnSuccess,
+		Config: map[string]any{
+			"api_key":          "test-key",
+			"package_path":     filepath.Join(t.TempDir(), "*.nupkg"),
+			"promote_from":     "http://localhost:1/v3/index.json",
+			"promote_packages": []any{"Contoso.Core", "Contoso.Data"},
+		},
+		Context: plugin.ReleaseContext{Version: "v1.2.0"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	notification := resp.Outputs["notification"].(releaseNotification)
+	if len(notification.Packages) != 2 || !strings.Contains(notification.Text, "Released 2 NuGet package(s)") {
+		t.Fatalf("expected the promoted packages to be notified, got %+v", notification)
+	}
+	want := notifiedPackage{ID: "Contoso.Data", Version: "1.2.0", URL: "https://www.nuget.org/packages/Contoso.Data/1.2.0"}
+	if notification.Packages[1] != want {
+		t.Errorf("expected %+v, got %+v", want, notification.Packages[1])
+	}
+}
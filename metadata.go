@@ -0,0 +1,310 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// MaxReleaseNotesLength is the longest <releaseNotes> value nuget.org accepts.
+const MaxReleaseNotesLength = 35000
+
+// releaseNotesTruncationSuffix marks release notes that were shortened to fit the limit.
+const releaseNotesTruncationSuffix = "\n\n(truncated)"
+
+// Patterns locating the nuspec elements the plugin rewrites.
+var (
+	releaseNotesElement = regexp.MustCompile(`(?s)<releaseNotes(?:\s[^>]*)?/>|<releaseNotes(?:\s[^>]*)?>.*?</releaseNotes>`)
+	repositoryElement   = regexp.MustCompile(`(?s)<repository(?:\s[^>]*)?/>|<repository(?:\s[^>]*)?>.*?</repository>`)
+	metadataClose       = regexp.MustCompile(`([ \t]*)</metadata>`)
+)
+
+// metadataInjection holds the release metadata written into each nuspec.
+type metadataInjection struct {
+	ReleaseNotes  string
+	RepositoryURL string
+	Commit        string
+}
+
+// newMetadataInjection builds the injected metadata from the release context.
+func newMetadataInjection(releaseCtx plugin.ReleaseContext) metadataInjection {
+	notes := releaseCtx.ReleaseNotes
+	if strings.TrimSpace(notes) == "" {
+		notes = releaseCtx.Changelog
+	}
+	return metadataInjection{
+		ReleaseNotes:  truncateReleaseNotes(strings.TrimSpace(notes)),
+		RepositoryURL: releaseCtx.RepositoryURL,
+		Commit:        releaseCtx.CommitSHA,
+	}
+}
+
+// truncateReleaseNotes shortens release notes to MaxReleaseNotesLength characters.
+func truncateReleaseNotes(notes string) string {
+	if utf8.RuneCountInString(notes) <= MaxReleaseNotesLength {
+		return notes
+	}
+	keep := MaxReleaseNotesLength - utf8.RuneCountInString(releaseNotesTruncationSuffix)
+	runes := []rune(notes)
+	return string(runes[:keep]) + releaseNotesTruncationSuffix
+}
+
+// isSignedPackage reports whether the package carries an author or repository signature.
+func isSignedPackage(path string) (bool, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to open package: %w", err)
+	}
+	defer func() { _ = r.Close() }()
+
+	for _, f := range r.File {
+		if f.Name == signatureEntry {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// stageMetadata rewrites every package with the release metadata into a temporary
+// directory and points the results at the rewritten copies. Signed packages, and
+// packages that cannot be rewritten, are pushed unchanged with a note on the result.
+// The returned function removes the temporary directory.
+func stageMetadata(results []PackageResult, releaseCtx plugin.ReleaseContext) (func(), error) {
+	dir, err := os.MkdirTemp("", "nuget-metadata-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	cleanup := func() { _ = os.RemoveAll(dir) }
+
+	meta := newMetadataInjection(releaseCtx)
+	for i := range results {
+		result := &results[i]
+		if result.Status != StatusNotAttempted {
+			continue
+		}
+		pkgDir, err := packageStagingDir(dir, i)
+		if err != nil {
+			cleanup()
+			return nil, err
+		}
+		staged, err := injectMetadata(result.Path, pkgDir, meta)
+		if err != nil {
+			result.Message = joinReasons(result.Message, fmt.Sprintf("metadata not injected: %v", err))
+			continue
+		}
+		result.stagedPath = staged
+	}
+	return cleanup, nil
+}
+
+// packageStagingDir creates the subdirectory of a staging directory that the
+// package at index i is staged in, so that packages with the same file name
+// from different directories do not overwrite each other.
+func packageStagingDir(dir string, i int) (string, error) {
+	pkgDir := filepath.Join(dir, strconv.Itoa(i))
+	if err := os.MkdirAll(pkgDir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	return pkgDir, nil
+}
+
+// injectMetadata writes a copy of the package into dir with the release metadata
+// set in its nuspec, and returns the path of the copy. All other parts, including
+// the OPC [Content_Types].xml, _rels and core properties, are copied unchanged.
+// Signed packages are refused because rewriting them would break the signature.
+func injectMetadata(path, dir string, meta metadataInjection) (string, error) {
+	signed, err := isSignedPackage(path)
+	if err != nil {
+		return "", err
+	}
+	if signed {
+		return "", fmt.Errorf("package is signed; rewriting the nuspec would invalidate the signature")
+	}
+
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open package: %w", err)
+	}
+	defer func() { _ = r.Close() }()
+
+	nuspecFile := findNuspecEntry(&r.Reader)
+	if nuspecFile == nil {
+		return "", fmt.Errorf("package does not contain a .nuspec file")
+	}
+
+	original, err := readZipEntry(nuspecFile)
+	if err != nil {
+		return "", err
+	}
+	rewritten, err := rewriteNuspec(original, meta)
+	if err != nil {
+		return "", err
+	}
+
+	out := filepath.Join(dir, filepath.Base(path))
+	f, err := os.Create(out)
+	if err != nil {
+		return "", fmt.Errorf("failed to create rewritten package: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	zw := zip.NewWriter(f)
+	for _, entry := range r.File {
+		if entry == nuspecFile {
+			header := entry.FileHeader
+			header.Method = zip.Deflate
+			w, err := zw.CreateHeader(&header)
+			if err != nil {
+				return "", fmt.Errorf("failed to write nuspec: %w", err)
+			}
+			if _, err := w.Write(rewritten); err != nil {
+				return "", fmt.Errorf("failed to write nuspec: %w", err)
+			}
+			continue
+		}
+		if err := copyZipEntry(zw, entry); err != nil {
+			return "", err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return "", fmt.Errorf("failed to finalize rewritten package: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("failed to finalize rewritten package: %w", err)
+	}
+
+	return out, nil
+}
+
+// copyZipEntry copies an entry without recompressing it.
+func copyZipEntry(zw *zip.Writer, entry *zip.File) error {
+	raw, err := entry.OpenRaw()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", entry.Name, err)
+	}
+	header := entry.FileHeader
+	w, err := zw.CreateRaw(&header)
+	if err != nil {
+		return fmt.Errorf("failed to copy %s: %w", entry.Name, err)
+	}
+	if _, err := io.Copy(w, raw); err != nil {
+		return fmt.Errorf("failed to copy %s: %w", entry.Name, err)
+	}
+	return nil
+}
+
+// readZipEntry reads the uncompressed contents of an archive entry.
+func readZipEntry(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", f.Name, err)
+	}
+	defer func() { _ = rc.Close() }()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", f.Name, err)
+	}
+	return data, nil
+}
+
+// rewriteNuspec sets <releaseNotes> and <repository> in a nuspec document,
+// leaving the rest of the document untouched.
+func rewriteNuspec(nuspec []byte, meta metadataInjection) ([]byte, error) {
+	doc := string(nuspec)
+	if !metadataClose.MatchString(doc) {
+		return nil, fmt.Errorf("nuspec has no </metadata> element")
+	}
+
+	if meta.ReleaseNotes != "" {
+		element := "<releaseNotes>" + escapeXML(meta.ReleaseNotes) + "</releaseNotes>"
+		doc = setNuspecElement(doc, releaseNotesElement, element)
+	}
+
+	if meta.RepositoryURL != "" || meta.Commit != "" {
+		doc = setNuspecElement(doc, repositoryElement, repositoryTag(doc, meta))
+	}
+
+	// Make sure the result is still well-formed XML before it is pushed.
+	if err := xml.Unmarshal([]byte(doc), new(nuspecDocument)); err != nil {
+		return nil, fmt.Errorf("rewritten nuspec is invalid: %w", err)
+	}
+	return []byte(doc), nil
+}
+
+// setNuspecElement replaces an existing element or inserts it before </metadata>.
+func setNuspecElement(doc string, pattern *regexp.Regexp, element string) string {
+	if loc := pattern.FindStringIndex(doc); loc != nil {
+		return doc[:loc[0]] + element + doc[loc[1]:]
+	}
+
+	loc := metadataClose.FindStringSubmatchIndex(doc)
+	indent := doc[loc[2]:loc[3]]
+	return doc[:loc[0]] + indent + "  " + element + "\n" + doc[loc[0]:]
+}
+
+// repositoryAttrPattern matches attributes of an existing <repository> element.
+var repositoryAttrPattern = regexp.MustCompile(`(\w+)\s*=\s*"([^"]*)"`)
+
+// repositoryTag builds a <repository> element, keeping attributes of an existing
+// element that the release context does not override.
+func repositoryTag(doc string, meta metadataInjection) string {
+	attrs := map[string]string{}
+	order := []string{"type", "url", "branch", "commit"}
+	if existing := repositoryElement.FindString(doc); existing != "" {
+		start := strings.TrimSuffix(strings.SplitN(existing, ">", 2)[0], "/")
+		for _, m := range repositoryAttrPattern.FindAllStringSubmatch(start, -1) {
+			if _, known := attrs[m[1]]; !known && !stringInSlice(order, m[1]) {
+				order = append(order, m[1])
+			}
+			attrs[m[1]] = m[2]
+		}
+	}
+
+	if attrs["type"] == "" {
+		attrs["type"] = "git"
+	}
+	if meta.RepositoryURL != "" {
+		attrs["url"] = escapeXML(meta.RepositoryURL)
+	}
+	if meta.Commit != "" {
+		attrs["commit"] = escapeXML(meta.Commit)
+	}
+
+	var b strings.Builder
+	b.WriteString("<repository")
+	for _, name := range order {
+		if v, ok := attrs[name]; ok && v != "" {
+			_, _ = fmt.Fprintf(&b, ` %s="%s"`, name, v)
+		}
+	}
+	b.WriteString(" />")
+	return b.String()
+}
+
+// xmlEscaper escapes XML special characters while keeping line breaks readable.
+var xmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&apos;")
+
+// escapeXML escapes text for use in XML character data and attribute values.
+func escapeXML(s string) string {
+	return xmlEscaper.Replace(s)
+}
+
+// stringInSlice reports whether list contains s.
+func stringInSlice(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
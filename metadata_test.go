@@ -0,0 +1,225 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestRewriteNuspec(t *testing.T) {
+	const base = `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://schemas.microsoft.com/packaging/2013/05/nuspec.xsd">
+  <metadata>
+    <id>Contoso.Core</id>
+    <version>1.0.0</version>
+%s  </metadata>
+</package>`
+
+	tests := []struct {
+		name     string
+		extra    string
+		meta     metadataInjection
+		want     []string
+		dontWant []string
+	}{
+		{
+			name: "inserts missing elements",
+			meta: metadataInjection{ReleaseNotes: "Fixed things", RepositoryURL: "https://github.com/contoso/core", Commit: "abc123"},
+			want: []string{
+				"    <releaseNotes>Fixed things</releaseNotes>\n",
+				`<repository type="git" url="https://github.com/contoso/core" commit="abc123" />`,
+			},
+		},
+		{
+			name:     "replaces existing release notes",
+			extra:    "    <releaseNotes>old notes</releaseNotes>\n",
+			meta:     metadataInjection{ReleaseNotes: "new notes"},
+			want:     []string{"<releaseNotes>new notes</releaseNotes>"},
+			dontWant: []string{"old notes", "<repository"},
+		},
+		{
+			name:  "keeps existing repository attributes",
+			extra: "    <repository type=\"git\" url=\"https://old\" branch=\"main\" />\n",
+			meta:  metadataInjection{RepositoryURL: "https://github.com/contoso/core", Commit: "abc123"},
+			want:  []string{`<repository type="git" url="https://github.com/contoso/core" branch="main" commit="abc123" />`},
+		},
+		{
+			name: "escapes markup in release notes",
+			meta: metadataInjection{ReleaseNotes: "- Fix <T> & \"quotes\"\n- Second line"},
+			want: []string{"<releaseNotes>- Fix &lt;T&gt; &amp; &quot;quotes&quot;\n- Second line</releaseNotes>"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rewriteNuspec([]byte(strings.Replace(base, "%s", tt.extra, 1)), tt.meta)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(got), want) {
+					t.Errorf("expected nuspec to contain %q, got:\n%s", want, got)
+				}
+			}
+			for _, dontWant := range tt.dontWant {
+				if strings.Contains(string(got), dontWant) {
+					t.Errorf("expected nuspec not to contain %q, got:\n%s", dontWant, got)
+				}
+			}
+		})
+	}
+}
+
+func TestTruncateReleaseNotes(t *testing.T) {
+	notes := strings.Repeat("é", MaxReleaseNotesLength+100)
+	got := truncateReleaseNotes(notes)
+	if n := utf8.RuneCountInString(got); n != MaxReleaseNotesLength {
+		t.Errorf("expected %d characters, got %d", MaxReleaseNotesLength, n)
+	}
+	if !strings.HasSuffix(got, releaseNotesTruncationSuffix) {
+		t.Error("expected truncated notes to be marked")
+	}
+	if short := truncateReleaseNotes("short"); short != "short" {
+		t.Errorf("expected short notes unchanged, got %q", short)
+	}
+}
+
+func TestInjectMetadata(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"[Content_Types].xml":         "<Types/>",
+		"_rels/.rels":                 "<Relationships/>",
+		"lib/net8.0/Contoso.Core.dll": "binary",
+	}
+	pkg := writeTestPackage(t, tmpDir, "Contoso.Core", "1.0.0", files)
+	meta := metadataInjection{ReleaseNotes: "notes", Commit: "abc123"}
+
+	out, err := injectMetadata(pkg, t.TempDir(), meta)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r, err := zip.OpenReader(out)
+	if err != nil {
+		t.Fatalf("failed to open rewritten package: %v", err)
+	}
+	defer func() { _ = r.Close() }()
+
+	entries := map[string]string{}
+	for _, f := range r.File {
+		data, err := readZipEntry(f)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", f.Name, err)
+		}
+		entries[f.Name] = string(data)
+	}
+	for name, content := range files {
+		if entries[name] != content {
+			t.Errorf("expected %s to be preserved, got %q", name, entries[name])
+		}
+	}
+	if !strings.Contains(entries["Contoso.Core.nuspec"], "<releaseNotes>notes</releaseNotes>") {
+		t.Errorf("expected release notes in nuspec, got:\n%s", entries["Contoso.Core.nuspec"])
+	}
+	if id, err := readPackageIdentity(out); err != nil || id.ID != "Contoso.Core" {
+		t.Errorf("expected rewritten package to keep its identity, got %+v (%v)", id, err)
+	}
+
+	signed := filepath.Join(t.TempDir(), "Contoso.Core.1.0.0.nupkg")
+	if err := os.WriteFile(signed, withSignature(t, pkg), 0644); err != nil {
+		t.Fatalf("failed to write signed package: %v", err)
+	}
+	if _, err := injectMetadata(signed, t.TempDir(), meta); err == nil || !strings.Contains(err.Error(), "signed") {
+		t.Errorf("expected signed package to be refused, got %v", err)
+	}
+}
+
+func TestStageMetadataSameFileName(t *testing.T) {
+	results := []PackageResult{
+		{Status: StatusNotAttempted, Path: writeTestPackage(t, t.TempDir(), "Contoso.Core", "1.0.0", map[string]string{"a.txt": "a"})},
+		{Status: StatusNotAttempted, Path: writeTestPackage(t, t.TempDir(), "Contoso.Core", "1.0.0", map[string]string{"b.txt": "b"})},
+	}
+
+	cleanup, err := stageMetadata(results, plugin.ReleaseContext{CommitSHA: "abc123"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer cleanup()
+
+	if results[0].stagedPath == "" || results[0].stagedPath == results[1].stagedPath {
+		t.Fatalf("expected separate staged copies, got %q and %q", results[0].stagedPath, results[1].stagedPath)
+	}
+	for i, want := range []string{"a.txt", "b.txt"} {
+		r, err := zip.OpenReader(results[i].stagedPath)
+		if err != nil {
+			t.Fatalf("failed to open staged package: %v", err)
+		}
+		found := false
+		for _, f := range r.File {
+			found = found || f.Name == want
+		}
+		_ = r.Close()
+		if !found {
+			t.Errorf("expected staged copy of package %d to contain %s", i, want)
+		}
+	}
+}
+
+func TestExecuteInjectsMetadata(t *testing.T) {
+	tmpDir := t.TempDir()
+	pkg := writeTestPackage(t, tmpDir, "Contoso.Core", "1.0.0", nil)
+	feed := newTestFeed(t)
+
+	var pushedNuspec string
+	mockExec := &MockCommandExecutor{
+		RunFunc: func(_ context.Context, _ string, args ...string) ([]byte, error) {
+			r, err := zip.OpenReader(args[2])
+			if err != nil {
+				return nil, err
+			}
+			defer func() { _ = r.Close() }()
+			data, err := readZipEntry(findNuspecEntry(&r.Reader))
+			pushedNuspec = string(data)
+			return []byte("Your package was pushed."), err
+		},
+	}
+	p := &NuGetPlugin{cmdExecutor: mockExec, httpClient: feed.server.Client()}
+
+	resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+		Hook: plugin.HookPostPublish,
+		Config: map[string]any{
+			"api_key":         "test-key",
+			"source":          feed.SourceURL(),
+			"package_path":    pkg,
+			"inject_metadata": true,
+		},
+		Context: plugin.ReleaseContext{
+			Version:       "v1.0.0",
+			ReleaseNotes:  "Release notes",
+			RepositoryURL: "https://github.com/contoso/core",
+			CommitSHA:     "abc123",
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("expected success, got: %s", resp.Error)
+	}
+
+	if mockExec.Calls[0].Args[2] == pkg {
+		t.Error("expected the rewritten copy to be pushed, not the original")
+	}
+	if !strings.Contains(pushedNuspec, `commit="abc123"`) || !strings.Contains(pushedNuspec, "<releaseNotes>Release notes</releaseNotes>") {
+		t.Errorf("expected pushed nuspec to carry release metadata, got:\n%s", pushedNuspec)
+	}
+	if results := resp.Outputs["packages"].([]PackageResult); results[0].Path != pkg {
+		t.Errorf("expected result to report the original path, got %s", results[0].Path)
+	}
+}
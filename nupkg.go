@@ -0,0 +1,200 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// maxPackageIDLength is the longest package ID NuGet accepts.
+const maxPackageIDLength = 100
+
+// packageIDPattern is NuGet's package ID grammar: word characters separated
+// by single dots, hyphens or underscores.
+var packageIDPattern = regexp.MustCompile(`^\w+(?:[.\-]\w+)*$`)
+
+// packageIdentity identifies a package by its nuspec id and version.
+type packageIdentity struct {
+	ID      string
+	Version string
+}
+
+// String returns the identity in "id version" form.
+func (i packageIdentity) String() string {
+	return i.ID + " " + i.Version
+}
+
+// nuspecDocument is the subset of a .nuspec manifest the plugin reads.
+type nuspecDocument struct {
+	XMLName  xml.Name       `xml:"package"`
+	Metadata nuspecMetadata `xml:"metadata"`
+}
+
+// nuspecMetadata holds the <metadata> element of a .nuspec manifest.
+type nuspecMetadata struct {
+	ID           string             `xml:"id"`
+	Version      string             `xml:"version"`
+	Repository   nuspecRepository   `xml:"repository"`
+	Dependencies nuspecDependencies `xml:"dependencies"`
+	Readme       string             `xml:"readme"`
+	License      nuspecLicense      `xml:"license"`
+	LicenseURL   string             `xml:"licenseUrl"`
+}
+
+// nuspecLicense holds the <license> element of a .nuspec manifest.
+type nuspecLicense struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+// nuspecRepository holds the <repository> element of a .nuspec manifest.
+type nuspecRepository struct {
+	Type   string `xml:"type,attr"`
+	URL    string `xml:"url,attr"`
+	Commit string `xml:"commit,attr"`
+}
+
+// nuspecDependencies holds the <dependencies> element of a .nuspec manifest.
+type nuspecDependencies struct {
+	Groups []nuspecDependencyGroup `xml:"group"`
+	// Dependencies are listed without a group by older nuspecs.
+	Dependencies []nuspecDependency `xml:"dependency"`
+}
+
+// nuspecDependencyGroup is a <group> of dependencies for one target framework.
+type nuspecDependencyGroup struct {
+	TargetFramework string             `xml:"targetFramework,attr"`
+	Dependencies    []nuspecDependency `xml:"dependency"`
+}
+
+// nuspecDependency is a <dependency> on another package and its version range.
+type nuspecDependency struct {
+	ID      string `xml:"id,attr"`
+	Version string `xml:"version,attr"`
+}
+
+// validatePackageID checks that id is a valid NuGet package ID.
+func validatePackageID(id string) error {
+	if len(id) > maxPackageIDLength {
+		return fmt.Errorf("package ID %q is longer than %d characters", id, maxPackageIDLength)
+	}
+	if !packageIDPattern.MatchString(id) {
+		return fmt.Errorf("%q is not a valid package ID", id)
+	}
+	return nil
+}
+
+// readPackageIdentity reads the package id and version from the nuspec inside a .nupkg.
+// If the archive cannot be read, the identity is derived from the file name.
+func readPackageIdentity(path string) (packageIdentity, error) {
+	doc, err := readNuspec(path)
+	if err == nil && doc.Metadata.ID != "" && doc.Metadata.Version != "" {
+		return packageIdentity{ID: doc.Metadata.ID, Version: doc.Metadata.Version}, nil
+	}
+
+	if id, ok := identityFromFileName(path); ok {
+		return id, nil
+	}
+
+	if err == nil {
+		err = fmt.Errorf("nuspec is missing id or version")
+	}
+	return packageIdentity{}, fmt.Errorf("failed to read package identity from %s: %w", path, err)
+}
+
+// readNuspec opens a .nupkg archive and parses its root .nuspec manifest.
+func readNuspec(path string) (*nuspecDocument, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open package: %w", err)
+	}
+	defer func() { _ = r.Close() }()
+
+	f := findNuspecEntry(&r.Reader)
+	if f == nil {
+		return nil, fmt.Errorf("package does not contain a .nuspec file")
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open nuspec: %w", err)
+	}
+	defer func() { _ = rc.Close() }()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read nuspec: %w", err)
+	}
+
+	var doc nuspecDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse nuspec: %w", err)
+	}
+
+	return &doc, nil
+}
+
+// findNuspecEntry returns the .nuspec entry at the root of the archive, if any.
+func findNuspecEntry(r *zip.Reader) *zip.File {
+	for _, f := range r.File {
+		if !strings.Contains(f.Name, "/") && strings.HasSuffix(strings.ToLower(f.Name), ".nuspec") {
+			return f
+		}
+	}
+	return nil
+}
+
+// identityFromFileName splits a "<id>.<version>.nupkg" file name into its parts.
+// The version starts at the first dot-separated segment that begins with a digit
+// and is followed only by version-like segments.
+func identityFromFileName(path string) (packageIdentity, bool) {
+	name := filepath.Base(path)
+	lower := strings.ToLower(name)
+	if !strings.HasSuffix(lower, ".nupkg") {
+		return packageIdentity{}, false
+	}
+	name = name[:len(name)-len(".nupkg")]
+	name = strings.TrimSuffix(name, ".symbols")
+
+	parts := strings.Split(name, ".")
+	if parts[0] == "" {
+		return packageIdentity{}, false
+	}
+	for i := 1; i < len(parts); i++ {
+		if parts[i] == "" || parts[i][0] < '0' || parts[i][0] > '9' {
+			continue
+		}
+		// Require at least major.minor so ids like "Foo.2D" are not split.
+		if i+1 >= len(parts) || !isNumeric(parts[i]) || !startsWithDigit(parts[i+1]) {
+			continue
+		}
+		return packageIdentity{
+			ID:      strings.Join(parts[:i], "."),
+			Version: strings.Join(parts[i:], "."),
+		}, true
+	}
+
+	return packageIdentity{}, false
+}
+
+// isNumeric reports whether s is a non-empty string of ASCII digits.
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// startsWithDigit reports whether s begins with an ASCII digit.
+func startsWithDigit(s string) bool {
+	return s != "" && s[0] >= '0' && s[0] <= '9'
+}
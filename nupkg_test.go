@@ -0,0 +1,158 @@
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestPackage creates a minimal .nupkg with a nuspec and the given extra files.
+func writeTestPackage(t *testing.T, dir, id, version string, files map[string]string) string {
+	t.Helper()
+
+	nuspec := fmt.Sprintf(`<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://schemas.microsoft.com/packaging/2013/05/nuspec.xsd">
+  <metadata>
+    <id>%s</id>
+    <version>%s</version>
+    <authors>Test</authors>
+    <description>Test package</description>
+  </metadata>
+</package>`, id, version)
+
+	return writeTestPackageWithNuspec(t, dir, id, version, nuspec, files)
+}
+
+// writeTestPackageWithNuspec creates a .nupkg with a custom nuspec document.
+func writeTestPackageWithNuspec(t *testing.T, dir, id, version, nuspec string, files map[string]string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, fmt.Sprintf("%s.%s.nupkg", id, version))
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("failed to create package: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	zw := zip.NewWriter(f)
+	entries := map[string]string{id + ".nuspec": nuspec}
+	for name, content := range files {
+		entries[name] = content
+	}
+	for _, name := range sortedKeys(entries) {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("failed to add %s: %v", name, err)
+		}
+		if _, err := w.Write([]byte(entries[name])); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("failed to finalize package: %v", err)
+	}
+
+	return path
+}
+
+func TestReadPackageIdentity(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	fromNuspec := writeTestPackage(t, tmpDir, "Contoso.Utils", "1.2.3-beta.1", nil)
+
+	notZip := filepath.Join(tmpDir, "Other.Package.2.0.0.nupkg")
+	if err := os.WriteFile(notZip, []byte("test"), 0644); err != nil {
+		t.Fatalf("failed to create test package: %v", err)
+	}
+
+	unparseable := filepath.Join(tmpDir, "garbage.nupkg")
+	if err := os.WriteFile(unparseable, []byte("test"), 0644); err != nil {
+		t.Fatalf("failed to create test package: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		want    packageIdentity
+		wantErr bool
+	}{
+		{
+			name: "reads nuspec",
+			path: fromNuspec,
+			want: packageIdentity{ID: "Contoso.Utils", Version: "1.2.3-beta.1"},
+		},
+		{
+			name: "falls back to file name",
+			path: notZip,
+			want: packageIdentity{ID: "Other.Package", Version: "2.0.0"},
+		},
+		{
+			name:    "no identity available",
+			path:    unparseable,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readPackageIdentity(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readPackageIdentity() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestIdentityFromFileName(t *testing.T) {
+	tests := []struct {
+		file   string
+		want   packageIdentity
+		wantOK bool
+	}{
+		{file: "test.1.0.0.nupkg", want: packageIdentity{ID: "test", Version: "1.0.0"}, wantOK: true},
+		{file: "Foo.Bar.2.1.0-rc.1.nupkg", want: packageIdentity{ID: "Foo.Bar", Version: "2.1.0-rc.1"}, wantOK: true},
+		{file: "Foo.2D.1.0.0.nupkg", want: packageIdentity{ID: "Foo.2D", Version: "1.0.0"}, wantOK: true},
+		{file: "Foo.1.0.0.symbols.nupkg", want: packageIdentity{ID: "Foo", Version: "1.0.0"}, wantOK: true},
+		{file: "Foo.nupkg", wantOK: false},
+		{file: ".1.0.0.nupkg", wantOK: false},
+		{file: "Foo.1.0.0.zip", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			got, ok := identityFromFileName(tt.file)
+			if ok != tt.wantOK {
+				t.Fatalf("expected ok=%v, got ok=%v", tt.wantOK, ok)
+			}
+			if ok && got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestValidatePackageID(t *testing.T) {
+	tests := map[string]bool{
+		"Contoso.Core":             true,
+		"Contoso_Core-2":           true,
+		"":                         false,
+		"../Contoso.Core":          false,
+		"Contoso/Core":             false,
+		"Contoso..Core":            false,
+		".Contoso":                 false,
+		strings.Repeat("a", 101):   false,
+		strings.Repeat("a", 100):   true,
+		"Contoso.Core.1.0.0.nupkg": true,
+	}
+	for id, want := range tests {
+		if err := validatePackageID(id); (err == nil) != want {
+			t.Errorf("validatePackageID(%q) = %v, want valid=%v", id, err, want)
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"bytes"
+	"debug/pe"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"unicode/utf16"
+)
+
+// PE data directory entries used to find .NET metadata and version resources.
+const (
+	peResourceDirectory = 2
+	peCLRDirectory      = 14
+)
+
+// rtVersion is the resource type of a VS_VERSIONINFO resource.
+const rtVersion = 16
+
+// errTruncatedImage is returned when a structure points past the end of the file.
+var errTruncatedImage = errors.New("truncated PE image")
+
+// le decodes PE structures and .NET metadata, which are little-endian.
+var le = binary.LittleEndian
+
+// assemblyInfo holds the versions recorded in a .NET assembly.
+type assemblyInfo struct {
+	// AssemblyVersion comes from the assembly manifest in the metadata tables.
+	AssemblyVersion string
+	// FileVersion and InformationalVersion come from the Win32 version resource,
+	// where the compiler stores AssemblyFileVersion and AssemblyInformationalVersion.
+	FileVersion          string
+	InformationalVersion string
+}
+
+// openAssembly parses a PE image and its .NET metadata tables. Files that are
+// not PE images, and native images, return nil metadata.
+func openAssembly(data []byte) (peImage, *metadataTables, error) {
+	f, err := pe.NewFile(bytes.NewReader(data))
+	if err != nil {
+		return peImage{}, nil, nil
+	}
+	img := peImage{f: f}
+	clr, ok := img.directory(peCLRDirectory)
+	if !ok {
+		return img, nil, nil
+	}
+	md, err := img.metadata(clr)
+	if err != nil {
+		return img, nil, fmt.Errorf("failed to read .NET metadata: %w", err)
+	}
+	return img, md, nil
+}
+
+// readAssemblyInfo reads the versions of a .NET assembly. Files that are not
+// PE images, and native images without .NET metadata, report managed false.
+func readAssemblyInfo(data []byte) (info assemblyInfo, managed bool, err error) {
+	img, md, err := openAssembly(data)
+	if err != nil {
+		return info, true, err
+	}
+	if md == nil {
+		return info, false, nil
+	}
+	if info.AssemblyVersion, err = md.assemblyVersion(); err != nil {
+		return info, true, fmt.Errorf("failed to read .NET metadata: %w", err)
+	}
+
+	if res, ok := img.directory(peResourceDirectory); ok {
+		strs, err := img.versionStrings(res)
+		if err != nil {
+			return info, true, fmt.Errorf("failed to read version resource: %w", err)
+		}
+		info.FileVersion = strs["FileVersion"]
+		info.InformationalVersion = strs["ProductVersion"]
+	}
+	return info, true, nil
+}
+
+// peImage reads structures from a parsed PE file by relative virtual address.
+type peImage struct {
+	f *pe.File
+}
+
+// directory returns a data directory entry if it is present.
+func (img peImage) directory(i int) (pe.DataDirectory, bool) {
+	var dirs []pe.DataDirectory
+	switch h := img.f.OptionalHeader.(type) {
+	case *pe.OptionalHeader32:
+		dirs = h.DataDirectory[:min(int(h.NumberOfRvaAndSizes), len(h.DataDirectory))]
+	case *pe.OptionalHeader64:
+		dirs = h.DataDirectory[:min(int(h.NumberOfRvaAndSizes), len(h.DataDirectory))]
+	}
+	if i >= len(dirs) || dirs[i].VirtualAddress == 0 || dirs[i].Size == 0 {
+		return pe.DataDirectory{}, false
+	}
+	return dirs[i], true
+}
+
+// read returns size bytes at an RVA from the section that contains it.
+func (img peImage) read(rva, size uint32) ([]byte, error) {
+	for _, s := range img.f.Sections {
+		if rva < s.VirtualAddress || rva >= s.VirtualAddress+max(s.VirtualSize, s.Size) {
+			continue
+		}
+		data, err := s.Data()
+		if err != nil {
+			return nil, err
+		}
+		off := uint64(rva - s.VirtualAddress)
+		if off+uint64(size) > uint64(len(data)) {
+			return nil, errTruncatedImage
+		}
+		return data[off : off+uint64(size)], nil
+	}
+	return nil, fmt.Errorf("RVA %#x is outside every section", rva)
+}
+
+// metadata reads the .NET metadata tables located by the CLI header.
+func (img peImage) metadata(clr pe.DataDirectory) (*metadataTables, error) {
+	// The CLI header holds the metadata directory at offset 8.
+	header, err := img.read(clr.VirtualAddress, 16)
+	if err != nil {
+		return nil, err
+	}
+	md, err := img.read(le.Uint32(header[8:]), le.Uint32(header[12:]))
+	if err != nil {
+		return nil, err
+	}
+	streams, err := metadataStreams(md)
+	if err != nil {
+		return nil, err
+	}
+	return newMetadataTables(streams)
+}
+
+// versionStrings reads the string table of the RT_VERSION resource, such as
+// FileVersion and ProductVersion. Images without one return an empty map.
+func (img peImage) versionStrings(res pe.DataDirectory) (map[string]string, error) {
+	rsrc, err := img.read(res.VirtualAddress, res.Size)
+	if err != nil {
+		return nil, err
+	}
+
+	// The resource tree is type -> name -> language -> data entry.
+	dir, found, err := resourceChild(rsrc, 0, func(id uint32) bool { return id == rtVersion })
+	if err != nil || !found {
+		return map[string]string{}, err
+	}
+	for level := 0; level < 2; level++ {
+		if dir, found, err = resourceChild(rsrc, dir&0x7FFFFFFF, nil); err != nil || !found {
+			return map[string]string{}, err
+		}
+	}
+	if dir&0x80000000 != 0 || uint64(dir)+8 > uint64(len(rsrc)) {
+		return nil, errTruncatedImage
+	}
+	data, err := img.read(le.Uint32(rsrc[dir:]), le.Uint32(rsrc[dir+4:]))
+	if err != nil {
+		return nil, err
+	}
+	return parseVersionInfo(data)
+}
+
+// resourceChild returns the target of the first entry of a resource directory
+// whose ID satisfies match, or of the first entry if match is nil. Targets
+// with the high bit set are subdirectories.
+func resourceChild(rsrc []byte, dir uint32, match func(id uint32) bool) (uint32, bool, error) {
+	if uint64(dir)+16 > uint64(len(rsrc)) {
+		return 0, false, errTruncatedImage
+	}
+	count := uint64(le.Uint16(rsrc[dir+12:])) + uint64(le.Uint16(rsrc[dir+14:]))
+	for i := uint64(0); i < count; i++ {
+		entry := uint64(dir) + 16 + 8*i
+		if entry+8 > uint64(len(rsrc)) {
+			return 0, false, errTruncatedImage
+		}
+		if match == nil || match(le.Uint32(rsrc[entry:])) {
+			return le.Uint32(rsrc[entry+4:]), true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+// versionBlock is a node of a VS_VERSIONINFO structure.
+type versionBlock struct {
+	key      string
+	value    []byte
+	children []byte
+}
+
+// parseVersionBlock parses one block and returns it with its length in bytes.
+func parseVersionBlock(b []byte) (versionBlock, int, error) {
+	if len(b) < 6 {
+		return versionBlock{}, 0, errTruncatedImage
+	}
+	length := int(le.Uint16(b))
+	valueLength := int(le.Uint16(b[2:]))
+	if length < 6 || length > len(b) {
+		return versionBlock{}, 0, errTruncatedImage
+	}
+	b = b[:length]
+	// Text values (type 1) are measured in UTF-16 code units.
+	if le.Uint16(b[4:]) == 1 {
+		valueLength *= 2
+	}
+
+	key, n := utf16String(b[6:])
+	off := align4(6 + n)
+	end := min(off+valueLength, length)
+	block := versionBlock{key: key}
+	if off < end {
+		block.value = b[off:end]
+	}
+	if next := align4(end); next < length {
+		block.children = b[next:]
+	}
+	return block, length, nil
+}
+
+// versionChildren parses consecutive child blocks.
+func versionChildren(b []byte) ([]versionBlock, error) {
+	var blocks []versionBlock
+	for len(b) >= 6 {
+		block, n, err := parseVersionBlock(b)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, block)
+		b = b[min(align4(n), len(b)):]
+	}
+	return blocks, nil
+}
+
+// parseVersionInfo collects the strings of every StringFileInfo table. When
+// there is no FileVersion string, it falls back to the fixed file version.
+func parseVersionInfo(data []byte) (map[string]string, error) {
+	root, _, err := parseVersionBlock(data)
+	if err != nil {
+		return nil, err
+	}
+	blocks, err := versionChildren(root.children)
+	if err != nil {
+		return nil, err
+	}
+
+	strs := make(map[string]string)
+	for _, block := range blocks {
+		if block.key != "StringFileInfo" {
+			continue
+		}
+		tables, err := versionChildren(block.children)
+		if err != nil {
+			return nil, err
+		}
+		for _, table := range tables {
+			entries, err := versionChildren(table.children)
+			if err != nil {
+				return nil, err
+			}
+			for _, entry := range entries {
+				if _, seen := strs[entry.key]; !seen {
+					strs[entry.key], _ = utf16String(entry.value)
+				}
+			}
+		}
+	}
+
+	// VS_FIXEDFILEINFO: signature 0xFEEF04BD, then dwFileVersionMS/LS at offset 8.
+	if fixed := root.value; strs["FileVersion"] == "" && len(fixed) >= 16 && le.Uint32(fixed) == 0xFEEF04BD {
+		ms, ls := le.Uint32(fixed[8:]), le.Uint32(fixed[12:])
+		strs["FileVersion"] = fmt.Sprintf("%d.%d.%d.%d", ms>>16, ms&0xFFFF, ls>>16, ls&0xFFFF)
+	}
+	return strs, nil
+}
+
+// utf16String decodes a null-terminated UTF-16LE string, returning it and the
+// number of bytes consumed including the terminator.
+func utf16String(b []byte) (string, int) {
+	var units []uint16
+	for i := 0; i+1 < len(b); i += 2 {
+		u := le.Uint16(b[i:])
+		if u == 0 {
+			return string(utf16.Decode(units)), i + 2
+		}
+		units = append(units, u)
+	}
+	return string(utf16.Decode(units)), len(b)
+}
+
+// align4 rounds n up to a multiple of 4.
+func align4(n int) int {
+	return (n + 3) &^ 3
+}
@@ -3,12 +3,16 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net"
+	"net/http"
 	"net/url"
-	"os/exec"
+	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/relicta-tech/relicta-plugin-sdk/helpers"
 	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
@@ -19,19 +23,40 @@ type CommandExecutor interface {
 	Run(ctx context.Context, name string, args ...string) ([]byte, error)
 }
 
+// EnvCommandExecutor is implemented by executors that can run a command with
+// additional environment variables.
+type EnvCommandExecutor interface {
+	RunWithEnv(ctx context.Context, env []string, name string, args ...string) ([]byte, error)
+}
+
+// StreamingCommandExecutor is implemented by executors that report output while
+// the command runs. onLine receives each line of stdout and stderr; the returned
+// output is a bounded tail of the combined output.
+type StreamingCommandExecutor interface {
+	RunStreaming(ctx context.Context, env []string, onLine func(stream, line string), name string, args ...string) ([]byte, error)
+}
+
 // RealCommandExecutor executes actual system commands.
 type RealCommandExecutor struct{}
 
 // Run executes the command with the given arguments.
 func (e *RealCommandExecutor) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
-	cmd := exec.CommandContext(ctx, name, args...)
-	return cmd.CombinedOutput()
+	return newCommand(ctx, nil, name, args...).CombinedOutput()
+}
+
+// RunWithEnv executes the command with env added to the current environment.
+func (e *RealCommandExecutor) RunWithEnv(ctx context.Context, env []string, name string, args ...string) ([]byte, error) {
+	return newCommand(ctx, env, name, args...).CombinedOutput()
 }
 
 // NuGetPlugin implements the Publish packages to NuGet (.NET) plugin.
 type NuGetPlugin struct {
 	// cmdExecutor is used for executing shell commands. If nil, uses RealCommandExecutor.
 	cmdExecutor CommandExecutor
+	// httpClient is used for feed requests. If nil, a client with DefaultHTTPTimeout is used.
+	httpClient *http.Client
+	// logger receives streamed push output. If nil, logs are written as text to stderr.
+	logger *slog.Logger
 }
 
 // getExecutor returns the command executor, defaulting to RealCommandExecutor.
@@ -42,13 +67,114 @@ func (p *NuGetPlugin) getExecutor() CommandExecutor {
 	return &RealCommandExecutor{}
 }
 
+// getHTTPClient returns the HTTP client used for feed requests.
+func (p *NuGetPlugin) getHTTPClient() *http.Client {
+	if p.httpClient != nil {
+		return p.httpClient
+	}
+	return &http.Client{Timeout: DefaultHTTPTimeout}
+}
+
 // Config represents the NuGet plugin configuration.
 type Config struct {
+	Target        string
 	APIKey        string
 	Source        string
 	PackagePath   string
 	SkipDuplicate bool
+	OnDuplicate   string
 	Timeout       int
+	Retries       int
+	RetryDelay    int
+	Resume        bool
+	StateFile     string
+	ResetState    bool
+	// APIKeys maps package ID globs to API keys; when set, it replaces APIKey.
+	APIKeys []APIKeyMapping
+	// InjectMetadata rewrites each nuspec with release notes and repository metadata.
+	InjectMetadata bool
+	// Phases enables or disables each release lifecycle phase.
+	Phases map[string]bool
+	// VerifyTimeout is how long, in seconds, the verify phase waits for pushed versions to be listed.
+	VerifyTimeout int
+	// GitHubAPIURL is the GitHub API used to check GitHub Packages tokens during preflight.
+	GitHubAPIURL string
+	// GitHubOwner is the user or organization whose GitHub Packages registry is the target.
+	GitHubOwner string
+	// GitHubUsername authenticates GitHub Packages feed reads together with the token.
+	GitHubUsername string
+	// Azure Artifacts feed location, view to promote to, and the PAT used to authenticate.
+	AzureURL          string
+	AzureOrganization string
+	AzureProject      string
+	AzureFeed         string
+	AzureView         string
+	AzurePAT          string
+	// Promote mode: the staging feed to copy the release version of each package ID from,
+	// and the credentials used to read it.
+	PromoteFrom     string
+	PromotePackages []string
+	PromoteUsername string
+	PromotePassword string
+	// ScanContent checks packages for ForbiddenFiles globs and secrets before pushing.
+	ScanContent    bool
+	ForbiddenFiles []string
+	// SecretPatterns are detectors added to the built-in secret detectors.
+	SecretPatterns []SecretDetector
+	// CheckPackageDocs checks the readme and license files the nuspec
+	// references; OnReadmeIssue decides whether readme rendering issues fail.
+	CheckPackageDocs bool
+	OnReadmeIssue    string
+	// AllowedLicenses are the SPDX licenses packages may use; RejectLicenseURL
+	// fails packages that only declare the deprecated <licenseUrl>.
+	AllowedLicenses  []string
+	RejectLicenseURL bool
+	// CheckAssemblyVersions compares the versions of assemblies under lib/ and
+	// ref/ with the package version using AssemblyVersionRules.
+	CheckAssemblyVersions bool
+	AssemblyVersionRules  map[string]string
+	// CheckPublicAPI compares the public API with the latest stable release on
+	// the feed and fails non-major releases that break it.
+	CheckPublicAPI bool
+	// OnFrameworkRemoved is the policy for target frameworks dropped since the
+	// latest stable release on the feed in a non-major release.
+	OnFrameworkRemoved string
+	// MaxPackageSize is the upload limit in bytes; -1 uses the feed's known limit and 0 disables the check.
+	MaxPackageSize int64
+	// PushTimeout bounds each push attempt and TotalTimeout the whole hook, in seconds; zero disables either deadline.
+	PushTimeout  int
+	TotalTimeout int
+	// SBOM selects per-package or per-release CycloneDX SBOMs, written to
+	// SBOMDir or next to each package; EmbedSBOM also adds each package's SBOM
+	// to the pushed package.
+	SBOM      string
+	SBOMDir   string
+	EmbedSBOM bool
+	// SummaryPath is where the Markdown release summary is written.
+	SummaryPath string
+	// Symbol package settings passed to dotnet nuget push.
+	NoSymbols    bool
+	SymbolSource string
+	SymbolAPIKey string
+	// Channels route releases by prerelease label and override source, credentials and symbols.
+	Channels []ChannelRule
+}
+
+// sbomEnabled reports whether SBOMs are generated.
+func (c *Config) sbomEnabled() bool {
+	return c.SBOM == SBOMPackage || c.SBOM == SBOMRelease
+}
+
+// duplicatePolicy returns the effective on_duplicate policy.
+// An unset policy falls back to skip_duplicate.
+func (c *Config) duplicatePolicy() string {
+	if c.OnDuplicate != "" {
+		return c.OnDuplicate
+	}
+	if c.SkipDuplicate {
+		return OnDuplicateSkip
+	}
+	return OnDuplicateFail
 }
 
 // DefaultSource is the default NuGet source URL.
@@ -60,6 +186,15 @@ const DefaultPackagePath = "*.nupkg"
 // DefaultTimeout is the default timeout in seconds.
 const DefaultTimeout = 300
 
+// DefaultRetries is the default number of retries for transient push failures.
+const DefaultRetries = 2
+
+// DefaultRetryDelay is the default delay in seconds before the first retry.
+const DefaultRetryDelay = 5
+
+// DefaultHTTPTimeout is the default timeout for feed HTTP requests.
+const DefaultHTTPTimeout = 30 * time.Second
+
 // GetInfo returns plugin metadata.
 func (p *NuGetPlugin) GetInfo() plugin.Info {
 	return plugin.Info{
@@ -68,16 +203,126 @@ func (p *NuGetPlugin) GetInfo() plugin.Info {
 		Description: "Publish packages to NuGet (.NET)",
 		Author:      "Relicta Team",
 		Hooks: []plugin.Hook{
+			plugin.HookPreInit,
+			plugin.HookPrePublish,
 			plugin.HookPostPublish,
+			plugin.HookOnSuccess,
+			plugin.HookOnError,
 		},
 		ConfigSchema: `{
 			"type": "object",
 			"properties": {
+				"target": {"type": "string", "enum": ["nuget", "github_packages", "azure_artifacts"], "description": "Push target: any NuGet feed given by source, the GitHub Packages registry of github_owner, or an Azure Artifacts feed", "default": "nuget"},
 				"api_key": {"type": "string", "description": "NuGet API key (or use NUGET_API_KEY env)"},
+				"github_owner": {"type": "string", "description": "GitHub Packages owner (defaults to the repository owner of the release)"},
+				"github_token": {"type": "string", "description": "Token for GitHub Packages with write:packages (or use GITHUB_TOKEN env)"},
+				"github_username": {"type": "string", "description": "Username for GitHub Packages feed reads (defaults to GITHUB_ACTOR or the owner)"},
+				"azure_organization": {"type": "string", "description": "Azure DevOps organization of the Azure Artifacts feed"},
+				"azure_project": {"type": "string", "description": "Azure DevOps project, for project-scoped feeds"},
+				"azure_feed": {"type": "string", "description": "Azure Artifacts feed name"},
+				"azure_view": {"type": "string", "description": "View to promote pushed packages to, e.g. Release (optional)"},
+				"azure_pat": {"type": "string", "description": "Azure DevOps PAT with Packaging read & write (or use AZURE_DEVOPS_PAT env); requires the Azure Artifacts Credential Provider"},
+				"promote_from": {"type": "string", "description": "Staging feed service index to promote from: the release version of each promote_packages ID is downloaded from it and pushed unchanged instead of local packages"},
+				"promote_packages": {"type": "array", "items": {"type": "string"}, "description": "Package IDs to promote from promote_from"},
+				"promote_username": {"type": "string", "description": "Username for reading the staging feed"},
+				"promote_password": {"type": "string", "description": "Password or token for reading the staging feed (or use PROMOTE_FEED_PASSWORD env)"},
+				"azure_url": {"type": "string", "description": "Azure DevOps packaging host (for Azure DevOps Server use https://SERVER/COLLECTION)", "default": "https://pkgs.dev.azure.com"},
+				"api_keys": {
+					"type": "array",
+					"description": "Per-package API keys; each package is pushed with the key of the narrowest matching pattern, and packages no pattern covers fail",
+					"items": {
+						"type": "object",
+						"properties": {
+							"pattern": {"type": "string", "description": "Package ID glob, e.g. Contoso.*"},
+							"key_env": {"type": "string", "description": "Environment variable holding the API key"}
+						},
+						"required": ["pattern", "key_env"]
+					}
+				},
 				"source": {"type": "string", "description": "NuGet source URL", "default": "https://api.nuget.org/v3/index.json"},
+				"channels": {
+					"type": "array",
+					"description": "Release channels routed by the prerelease label of the release version; the first matching rule wins and releases no rule matches use the top-level settings",
+					"items": {
+						"type": "object",
+						"properties": {
+							"name": {"type": "string", "description": "Channel name reported in outputs, e.g. preview"},
+							"match": {"type": "string", "description": "Prerelease label glob, e.g. beta.*, or stable for versions without a label"},
+							"push": {"type": "boolean", "description": "Whether releases on this channel are pushed", "default": true},
+							"source": {"type": "string", "description": "NuGet source URL for this channel"},
+							"api_key_env": {"type": "string", "description": "Environment variable holding the API key for this channel"},
+							"no_symbols": {"type": "boolean", "description": "Overrides no_symbols for this channel"},
+							"symbol_source": {"type": "string", "description": "Overrides symbol_source for this channel"},
+							"symbol_api_key_env": {"type": "string", "description": "Environment variable holding the symbol API key for this channel"}
+						},
+						"required": ["name", "match"]
+					}
+				},
+				"no_symbols": {"type": "boolean", "description": "Do not push symbol packages", "default": false},
+				"symbol_source": {"type": "string", "description": "Symbol server URL for .snupkg symbol packages"},
+				"symbol_api_key": {"type": "string", "description": "API key for the symbol server (or use NUGET_SYMBOL_API_KEY env)"},
 				"package_path": {"type": "string", "description": "Path to package files (supports wildcards)", "default": "*.nupkg"},
-				"skip_duplicate": {"type": "boolean", "description": "Skip pushing if package already exists", "default": false},
-				"timeout": {"type": "integer", "description": "Push timeout in seconds", "default": 300}
+				"skip_duplicate": {"type": "boolean", "description": "Skip pushing if package already exists (shorthand for on_duplicate: skip)", "default": false},
+				"on_duplicate": {"type": "string", "enum": ["fail", "skip", "skip_if_identical"], "description": "What to do when a package version already exists on the feed"},
+				"timeout": {"type": "integer", "description": "Push timeout in seconds", "default": 300},
+				"scan_content": {"type": "boolean", "description": "Scan each package for forbidden files and secrets and fail the push when something is found", "default": false},
+				"forbidden_files": {"type": "array", "items": {"type": "string"}, "description": "Path globs that must not appear in packages; globs without a slash match file names in any folder (.pdb files are allowed in symbols packages)", "default": ["*.pdb", "*.pfx", "*.snk", ".env", "appsettings.*.json"]},
+				"secret_patterns": {
+					"type": "array",
+					"description": "Regular expressions matched against each line of text files in addition to the built-in secret detectors",
+					"items": {
+						"type": "object",
+						"properties": {
+							"name": {"type": "string", "description": "Detector name used in reports"},
+							"pattern": {"type": "string", "description": "Go regular expression"}
+						},
+						"required": ["name", "pattern"]
+					}
+				},
+				"check_package_docs": {"type": "boolean", "description": "Check that the readme and license files the nuspec references are in the package, that the license expression is valid SPDX, and that the readme has no relative links or images or HTML that nuget.org strips", "default": false},
+				"on_readme_issue": {"type": "string", "enum": ["fail", "warn"], "description": "What to do when the readme has links, images or HTML that nuget.org will not render; missing files and invalid license expressions always fail", "default": "warn"},
+				"allowed_licenses": {"type": "array", "items": {"type": "string"}, "description": "SPDX license identifiers (optionally \"<license> WITH <exception>\") packages may use; each package's license expression must be satisfiable with these licenses only"},
+				"reject_license_url": {"type": "boolean", "description": "Fail packages that only declare the deprecated <licenseUrl> instead of a <license> element", "default": false},
+				"check_assembly_versions": {"type": "boolean", "description": "Compare the versions of assemblies under lib/ and ref/ with the package version and fail the push on a mismatch", "default": false},
+				"assembly_version_rules": {
+					"type": "object",
+					"description": "How each assembly version must match the package version: exact (version and prerelease label, ignoring build metadata), numeric (major.minor.patch), major_minor, major or ignore",
+					"properties": {
+						"informational": {"type": "string", "enum": ["exact", "numeric", "major_minor", "major", "ignore"], "description": "AssemblyInformationalVersion rule", "default": "exact"},
+						"file": {"type": "string", "enum": ["exact", "numeric", "major_minor", "major", "ignore"], "description": "AssemblyFileVersion rule", "default": "numeric"},
+						"assembly": {"type": "string", "enum": ["exact", "numeric", "major_minor", "major", "ignore"], "description": "AssemblyVersion rule", "default": "major"}
+					}
+				},
+				"check_public_api": {"type": "boolean", "description": "Download the latest stable release of each package from the feed and fail a non-major release whose assemblies remove or change public types or members", "default": false},
+				"on_framework_removed": {"type": "string", "enum": ["fail", "warn", "ignore"], "description": "What to do when a non-major release drops a target framework (lib/, ref/ or dependency group) supported by the latest stable release on the feed", "default": "ignore"},
+				"sbom": {"type": "string", "enum": ["off", "package", "release"], "description": "Write a CycloneDX JSON SBOM of each package's files (with hashes) and nuspec dependencies, one per package or one for the whole release", "default": "off"},
+				"sbom_dir": {"type": "string", "description": "Directory the SBOMs are written to (default: next to each package); required with promote_from"},
+				"embed_sbom": {"type": "boolean", "description": "Also add each package's SBOM to the package as sbom/bom.cdx.json before pushing (never applied to signed packages)", "default": false},
+				"max_package_size": {"type": ["integer", "string"], "description": "Largest package to push, in bytes or as a size such as 100MB; defaults to the feed's known limit (250MB for nuget.org, 500MB for Azure Artifacts) and 0 disables the check"},
+				"push_timeout": {"type": "integer", "description": "Seconds a single push attempt may run before dotnet is killed and the attempt is retried; 0 disables the deadline", "default": 0},
+				"total_timeout": {"type": "integer", "description": "Seconds a whole hook may run, including feed checks, retries and verification; 0 disables the deadline", "default": 0},
+				"retries": {"type": "integer", "description": "Retries for network, timeout and rate-limit failures", "default": 2},
+				"retry_delay": {"type": "integer", "description": "Seconds to wait before the first retry (doubles per attempt)", "default": 5},
+				"resume": {"type": "boolean", "description": "Record confirmed pushes in a state file and skip them when a release is re-run", "default": false},
+				"state_file": {"type": "string", "description": "Path of the push state file in the workspace", "default": ".relicta/nuget-push-state.json"},
+				"reset_state": {"type": "boolean", "description": "Forget recorded pushes for this release version and start over", "default": false},
+				"inject_metadata": {"type": "boolean", "description": "Set <releaseNotes> and <repository> in each nuspec from the release before pushing (never applied to signed packages)", "default": false},
+				"summary_path": {"type": "string", "description": "File to write the Markdown release summary to; it is also appended to $GITHUB_STEP_SUMMARY when set"},
+				"phases": {
+					"type": "object",
+					"description": "Enable or disable lifecycle phases: preflight (pre-init), validate (pre-publish), push and verify (post-publish), cleanup and notify (on-success/on-error)",
+					"properties": {
+						"preflight": {"type": "boolean", "default": false},
+						"validate": {"type": "boolean", "default": false},
+						"push": {"type": "boolean", "default": true},
+						"verify": {"type": "boolean", "default": false},
+						"cleanup": {"type": "boolean", "default": true},
+						"notify": {"type": "boolean", "default": true}
+					},
+					"additionalProperties": false
+				},
+				"verify_timeout": {"type": "integer", "description": "Seconds the verify phase waits for pushed versions to be listed on the feed", "default": 600},
+				"github_api_url": {"type": "string", "description": "GitHub API used to check GitHub Packages token scopes and expiry (for GitHub Enterprise Server use https://HOST/api/v3)", "default": "https://api.github.com"}
 			},
 			"required": []
 		}`,
@@ -87,10 +332,59 @@ func (p *NuGetPlugin) GetInfo() plugin.Info {
 // Execute runs the plugin for a given hook.
 func (p *NuGetPlugin) Execute(ctx context.Context, req plugin.ExecuteRequest) (*plugin.ExecuteResponse, error) {
 	cfg := p.parseConfig(req.Config)
+	cfg.applyTarget(req.Context)
+
+	ctx, cancel := cfg.withTotalTimeout(ctx)
+	defer cancel()
 
+	reporting := req.Hook == plugin.HookOnSuccess || req.Hook == plugin.HookOnError
+	channel, channelErr := cfg.applyChannel(req.Context.Version)
+	if channelErr != nil && !reporting {
+		return &plugin.ExecuteResponse{Success: false, Error: channelErr.Error()}, nil
+	}
+	if channel != nil && !channel.Push && !reporting {
+		return channelSkippedResponse(req.Hook, channel), nil
+	}
+
+	resp, err := p.runHook(ctx, cfg, channel, req)
+	// The cleanup and notify phases still report the release outcome when
+	// the channel cannot be applied; the push phase has already failed on it.
+	if resp != nil && channelErr != nil {
+		resp.Message = joinReasons(resp.Message, "channel not applied: "+channelErr.Error())
+	}
+	if resp != nil && !resp.Success && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		resp.Error = fmt.Sprintf("total_timeout of %ds exceeded: %s (hint: %s)", cfg.TotalTimeout, resp.Error, ErrorKindDeadline.Hint())
+		if resp.Outputs == nil {
+			resp.Outputs = map[string]any{}
+		}
+		resp.Outputs["timed_out"] = true
+	}
+	if resp != nil && channel != nil {
+		if resp.Outputs == nil {
+			resp.Outputs = map[string]any{}
+		}
+		resp.Outputs["channel"] = channel
+	}
+	return resp, err
+}
+
+// runHook dispatches a hook to the lifecycle phase bound to it.
+func (p *NuGetPlugin) runHook(ctx context.Context, cfg *Config, channel *releaseChannel, req plugin.ExecuteRequest) (*plugin.ExecuteResponse, error) {
 	switch req.Hook {
+	case plugin.HookPreInit:
+		if !cfg.phaseEnabled(PhasePreflight) {
+			return phaseDisabledResponse(req.Hook, PhasePreflight), nil
+		}
+		return p.preflight(ctx, cfg, req.Context)
+	case plugin.HookPrePublish:
+		if !cfg.phaseEnabled(PhaseValidate) {
+			return phaseDisabledResponse(req.Hook, PhaseValidate), nil
+		}
+		return p.validatePackages(ctx, cfg, req.Context)
 	case plugin.HookPostPublish:
-		return p.pushPackage(ctx, cfg, req.Context, req.DryRun)
+		return p.publish(ctx, cfg, req.Context, req.DryRun)
+	case plugin.HookOnSuccess, plugin.HookOnError:
+		return p.finish(cfg, channel, req.Hook, req.Context, req.DryRun)
 	default:
 		return &plugin.ExecuteResponse{
 			Success: true,
@@ -101,89 +395,372 @@ func (p *NuGetPlugin) Execute(ctx context.Context, req plugin.ExecuteRequest) (*
 
 // pushPackage pushes NuGet packages to the configured source.
 func (p *NuGetPlugin) pushPackage(ctx context.Context, cfg *Config, releaseCtx plugin.ReleaseContext, dryRun bool) (*plugin.ExecuteResponse, error) {
+	started := time.Now()
+	version := strings.TrimPrefix(releaseCtx.Version, "v")
+
 	// Validate configuration
-	if err := p.validateConfig(cfg); err != nil {
-		return &plugin.ExecuteResponse{
-			Success: false,
-			Error:   fmt.Sprintf("configuration validation failed: %v", err),
-		}, nil
+	if err := p.validateConfig(ctx, cfg); err != nil {
+		summary := summarize(cfg, version, dryRun, nil, time.Since(started))
+		return failureResponse(summary, nil, fmt.Sprintf("configuration validation failed: %v", err)), nil
 	}
 
-	// Find package files
-	packages, err := p.findPackages(cfg.PackagePath)
+	// Find package files, or download them from the staging feed in promote mode
+	packages, promoted, err := p.releasePackages(ctx, cfg, version)
 	if err != nil {
-		return &plugin.ExecuteResponse{
-			Success: false,
-			Error:   fmt.Sprintf("failed to find packages: %v", err),
-		}, nil
+		summary := summarize(cfg, version, dryRun, nil, time.Since(started))
+		return failureResponse(summary, nil, err.Error()), nil
 	}
+	defer promoted.cleanup()
 
-	if len(packages) == 0 {
-		return &plugin.ExecuteResponse{
-			Success: false,
-			Error:   fmt.Sprintf("no packages found matching pattern: %s", cfg.PackagePath),
-		}, nil
+	results := newPackageResults(packages, cfg.Source)
+	identifyPackages(results, cfg.feedKind())
+	if promoted != nil {
+		promoted.annotate(results)
 	}
 
-	version := strings.TrimPrefix(releaseCtx.Version, "v")
+	// Reject packages over the feed's upload limit before a long upload fails
+	if oversized := checkPackageSizes(cfg, results, dryRun); len(oversized) > 0 && !dryRun {
+		summary := summarize(cfg, version, dryRun, results, time.Since(started))
+		return failureResponse(summary, results, fmt.Sprintf("package(s) too large to push: %s (hint: %s)", oversizedDetails(results), ErrorKindTooLarge.Hint())), nil
+	}
+
+	// Refuse to publish forbidden files or secrets
+	if flagged := scanContents(cfg, results, dryRun); len(flagged) > 0 && !dryRun {
+		summary := summarize(cfg, version, dryRun, results, time.Since(started))
+		return failureResponse(summary, results, fmt.Sprintf("content scan flagged package(s): %s (hint: %s)", strings.Join(flagged, ", "), ErrorKindForbiddenContent.Hint())), nil
+	}
+
+	// Refuse to publish a readme or license nuget.org rejects
+	if flagged := checkPackageDocs(cfg, results, dryRun); len(flagged) > 0 && !dryRun {
+		summary := summarize(cfg, version, dryRun, results, time.Since(started))
+		return failureResponse(summary, results, fmt.Sprintf("readme or license check failed for package(s): %s (hint: %s)", strings.Join(flagged, ", "), ErrorKindPackageDocs.Hint())), nil
+	}
+
+	// Refuse to publish a license the policy does not allow
+	if rejected := checkLicenses(cfg, results, dryRun); len(rejected) > 0 && !dryRun {
+		summary := summarize(cfg, version, dryRun, results, time.Since(started))
+		return failureResponse(summary, results, fmt.Sprintf("license policy rejected package(s): %s (hint: %s)", strings.Join(rejected, ", "), ErrorKindLicense.Hint())), nil
+	}
+
+	// Refuse to publish assemblies built with a different version
+	if mismatched := checkAssemblyVersions(cfg, results, dryRun); len(mismatched) > 0 && !dryRun {
+		summary := summarize(cfg, version, dryRun, results, time.Since(started))
+		return failureResponse(summary, results, fmt.Sprintf("assembly versions do not match package version in: %s (hint: %s)", strings.Join(mismatched, ", "), ErrorKindAssemblyVersion.Hint())), nil
+	}
+
+	// Refuse to break public API in a minor or patch release
+	if breaking := p.checkPublicAPI(ctx, cfg, results, dryRun); len(breaking) > 0 && !dryRun {
+		summary := summarize(cfg, version, dryRun, results, time.Since(started))
+		return failureResponse(summary, results, fmt.Sprintf("public API check failed for: %s (hint: %s)", strings.Join(breaking, ", "), ErrorKindBreakingChange.Hint())), nil
+	}
+
+	// Refuse to drop a target framework in a minor or patch release
+	if dropped := p.checkFrameworks(ctx, cfg, results, dryRun); len(dropped) > 0 && !dryRun {
+		summary := summarize(cfg, version, dryRun, results, time.Since(started))
+		return failureResponse(summary, results, fmt.Sprintf("target framework check failed for: %s (hint: %s)", strings.Join(dropped, ", "), ErrorKindFrameworkRemoved.Hint())), nil
+	}
+
+	// Select the API key for each package before anything is pushed
+	if uncovered := assignAPIKeys(cfg, results, dryRun); len(uncovered) > 0 && !dryRun {
+		summary := summarize(cfg, version, dryRun, results, time.Since(started))
+		return failureResponse(summary, results, fmt.Sprintf("no usable API key for package(s): %s (hint: %s)", strings.Join(uncovered, ", "), ErrorKindAuth.Hint())), nil
+	}
+
+	// Load the state of previous runs so an interrupted release resumes where it stopped
+	var state *pushState
+	if cfg.Resume {
+		state, err = p.loadState(cfg, version, dryRun)
+		if err != nil {
+			summary := summarize(cfg, version, dryRun, results, time.Since(started))
+			return failureResponse(summary, results, err.Error()), nil
+		}
+		resumeFromState(state, version, results, dryRun)
+	}
+
+	if cfg.InjectMetadata {
+		cleanup, err := stageMetadata(results, releaseCtx)
+		if err != nil {
+			summary := summarize(cfg, version, dryRun, results, time.Since(started))
+			return failureResponse(summary, results, err.Error()), nil
+		}
+		defer cleanup()
+	}
+
+	// GitHub Packages rejects packages whose repository does not belong to the owner
+	if cfg.Target == TargetGitHubPackages {
+		if failed := checkGitHubRepositories(cfg, results, dryRun); len(failed) > 0 && !dryRun {
+			summary := summarize(cfg, version, dryRun, results, time.Since(started))
+			return failureResponse(summary, results, fmt.Sprintf("package(s) cannot be pushed to GitHub Packages: %s (hint: %s)", strings.Join(failed, ", "), ErrorKindValidation.Hint())), nil
+		}
+	}
+
+	// Write the SBOMs, and embed them, before anything is pushed; the SBOMs of
+	// packages that do not make it to the feed are removed again
+	if cfg.sbomEnabled() && !dryRun {
+		cleanup, err := p.generateSBOMs(cfg, releaseCtx, results)
+		if err != nil {
+			summary := summarize(cfg, version, dryRun, results, time.Since(started))
+			return failureResponse(summary, results, err.Error()), nil
+		}
+		defer cleanup()
+	}
 
 	if dryRun {
+		check := p.planPush(ctx, cfg, results)
+		summary := summarize(cfg, version, dryRun, results, time.Since(started))
+		outputs := resultOutputs(summary, results)
+		outputs["feed"] = check
+		message := dryRunMessage(summary)
+		if promoted != nil {
+			message += fmt.Sprintf(" (promoted from %s)", cfg.PromoteFrom)
+		}
+		message += versionWarningNote(results)
+		if cfg.Target == TargetAzureArtifacts && cfg.azureView() != "" {
+			message += fmt.Sprintf(" and promote to @%s", cfg.azureView())
+		}
 		return &plugin.ExecuteResponse{
 			Success: true,
-			Message: fmt.Sprintf("Would push %d package(s) to NuGet", len(packages)),
-			Outputs: map[string]any{
-				"packages":       packages,
-				"source":         cfg.Source,
-				"skip_duplicate": cfg.SkipDuplicate,
-				"version":        version,
-			},
+			Message: message,
+			Outputs: outputs,
 		}, nil
 	}
 
 	// Push each package
-	pushedPackages := make([]string, 0, len(packages))
-	for _, pkg := range packages {
-		if err := p.executePush(ctx, cfg, pkg); err != nil {
-			return &plugin.ExecuteResponse{
-				Success: false,
-				Error:   fmt.Sprintf("failed to push package %s: %v", pkg, err),
-				Outputs: map[string]any{
-					"pushed_packages": pushedPackages,
-					"failed_package":  pkg,
-				},
-			}, nil
+	for i := range results {
+		result := &results[i]
+		if result.Resumed {
+			continue
+		}
+
+		// A promoted package must still be the exact bytes the staging feed served
+		if result.SHA512 != "" {
+			if err := checkPromotedHash(result); err != nil {
+				result.Status = StatusFailed
+				result.setError(ErrorKindValidation, err.Error())
+				discardUnpushedSBOMs(results)
+				summary := summarize(cfg, version, dryRun, results, time.Since(started))
+				return failureResponse(summary, results, fmt.Sprintf("refusing to push package %s: %v", packageLabel(*result), err)), nil
+			}
+		}
+
+		pushErr := p.pushWithRetry(ctx, cfg, result)
+		recordResult(state, version, result)
+		if pushErr != nil {
+			discardUnpushedSBOMs(results)
+			summary := summarize(cfg, version, dryRun, results, time.Since(started))
+			return failureResponse(summary, results, fmt.Sprintf("failed to push package %s: %v (hint: %s)", result.Path, pushErr, pushErr.Hint())), nil
+		}
+	}
+
+	// Promote to the Azure Artifacts view once everything is on the feed
+	if cfg.Target == TargetAzureArtifacts && cfg.azureView() != "" {
+		if failed := p.promoteToView(ctx, cfg, results); len(failed) > 0 {
+			summary := summarize(cfg, version, dryRun, results, time.Since(started))
+			return failureResponse(summary, results, fmt.Sprintf("pushed all packages but failed to promote %s to @%s (hint: check that the view exists and the PAT has Packaging read & write scope)", strings.Join(failed, ", "), cfg.azureView())), nil
 		}
-		pushedPackages = append(pushedPackages, pkg)
 	}
 
+	// Promoted packages must match the staging copy once they are on the target
+	if promoted != nil {
+		if differing := p.confirmPromoted(ctx, cfg, results); len(differing) > 0 {
+			summary := summarize(cfg, version, dryRun, results, time.Since(started))
+			return failureResponse(summary, results, fmt.Sprintf("published copy of %s differs from the staging feed %s (hint: %s)", strings.Join(differing, ", "), cfg.PromoteFrom, ErrorKindValidation.Hint())), nil
+		}
+	}
+
+	summary := summarize(cfg, version, dryRun, results, time.Since(started))
+	message := fmt.Sprintf("Successfully pushed %d package(s) to NuGet", summary.Pushed)
+	if promoted != nil {
+		message = fmt.Sprintf("Successfully promoted %d package(s) from %s", summary.Pushed, cfg.PromoteFrom)
+	}
+	if summary.Skipped > 0 {
+		message += fmt.Sprintf(" (%d skipped)", summary.Skipped)
+	}
+	if cfg.Target == TargetAzureArtifacts && cfg.azureView() != "" {
+		message += fmt.Sprintf(", promoted to @%s", cfg.azureView())
+	}
+	message += versionWarningNote(results)
 	return &plugin.ExecuteResponse{
 		Success: true,
-		Message: fmt.Sprintf("Successfully pushed %d package(s) to NuGet", len(pushedPackages)),
-		Outputs: map[string]any{
-			"packages": pushedPackages,
-			"source":   cfg.Source,
-			"version":  version,
-		},
+		Message: message,
+		Outputs: resultOutputs(summary, results),
 	}, nil
 }
 
+// identifyPackages reads the id and version of each package from its nuspec.
+// Packages that cannot be identified keep an empty identity.
+func identifyPackages(results []PackageResult, kind feedKind) {
+	for i := range results {
+		if info, err := os.Stat(results[i].Path); err == nil {
+			results[i].SizeBytes = info.Size()
+		}
+		id, err := readPackageIdentity(results[i].Path)
+		if err != nil {
+			continue
+		}
+		results[i].ID = id.ID
+		results[i].Version = id.Version
+		results[i].URL = packageURL(kind, id)
+		results[i].Warnings = versionWarnings(id.Version)
+	}
+}
+
+// loadState loads the push state file, applying reset_state outside of dry runs.
+func (p *NuGetPlugin) loadState(cfg *Config, version string, dryRun bool) (*pushState, error) {
+	state, err := loadPushState(cfg.StateFile)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.ResetState {
+		state.reset(version)
+		if !dryRun {
+			if err := state.save(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return state, nil
+}
+
+// pushWithRetry pushes a single package, retrying transient failures.
+// It records the outcome on result and returns the final error, if any.
+func (p *NuGetPlugin) pushWithRetry(ctx context.Context, cfg *Config, result *PackageResult) *PushError {
+	pushStarted := time.Now()
+	defer func() { result.DurationMS = time.Since(pushStarted).Milliseconds() }()
+
+	for attempt := 1; ; attempt++ {
+		result.Attempts = attempt
+		pushErr := p.executePush(ctx, cfg, result.pushPath(), result.apiKey, attempt)
+		if pushErr == nil {
+			result.Status = StatusPushed
+			return nil
+		}
+
+		if pushErr.Kind == ErrorKindDuplicate {
+			return p.handleDuplicate(ctx, cfg, result, pushErr)
+		}
+
+		if !pushErr.Kind.Retryable() || attempt > cfg.Retries {
+			result.Status = StatusFailed
+			result.setError(pushErr.Kind, pushErr.Error())
+			return pushErr
+		}
+
+		delay := time.Duration(cfg.RetryDelay) * time.Second << (attempt - 1)
+		if err := sleepContext(ctx, delay); err != nil {
+			result.Status = StatusFailed
+			result.setError(pushErr.Kind, pushErr.Error())
+			return pushErr
+		}
+	}
+}
+
+// handleDuplicate applies the on_duplicate policy to a package the feed rejected as a duplicate.
+func (p *NuGetPlugin) handleDuplicate(ctx context.Context, cfg *Config, result *PackageResult, pushErr *PushError) *PushError {
+	switch cfg.duplicatePolicy() {
+	case OnDuplicateSkip:
+		result.Status = StatusSkipped
+		result.Message = "version already exists on feed"
+		return nil
+	case OnDuplicateSkipIfIdentical:
+		identical, err := p.matchesPublished(ctx, cfg, result)
+		if err != nil {
+			pushErr = &PushError{Kind: ErrorKindDuplicate, Output: fmt.Sprintf("version already exists and could not be compared: %v", err), Err: pushErr.Err}
+		} else if identical {
+			result.Status = StatusSkipped
+			result.Message = "identical package already published"
+			return nil
+		} else {
+			pushErr = &PushError{Kind: ErrorKindDuplicate, Output: "a different package is already published under this version", Err: pushErr.Err}
+		}
+	}
+
+	result.Status = StatusFailed
+	result.setError(pushErr.Kind, pushErr.Error())
+	return pushErr
+}
+
+// matchesPublished compares a local package with the copy already published on the feed.
+func (p *NuGetPlugin) matchesPublished(ctx context.Context, cfg *Config, result *PackageResult) (bool, error) {
+	if result.ID == "" || result.Version == "" {
+		return false, fmt.Errorf("package identity is unknown")
+	}
+
+	client := p.newFeedClient(cfg)
+	index, err := client.serviceIndex(ctx)
+	if err != nil {
+		return false, err
+	}
+	baseAddress := index.resourceURL(resourcePackageBaseAddress)
+	if baseAddress == "" {
+		return false, fmt.Errorf("service index does not advertise a PackageBaseAddress resource")
+	}
+
+	return client.compareWithPublished(ctx, baseAddress, packageIdentity{ID: result.ID, Version: result.Version}, result.pushPath())
+}
+
+// sleepContext waits for the given duration or until the context is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
 // executePush executes the dotnet nuget push command for a single package.
-func (p *NuGetPlugin) executePush(ctx context.Context, cfg *Config, packagePath string) error {
+// Output is streamed to the logger when the executor supports it.
+func (p *NuGetPlugin) executePush(ctx context.Context, cfg *Config, packagePath, apiKey string, attempt int) *PushError {
 	args := []string{"nuget", "push", packagePath}
 
-	args = append(args, "--api-key", cfg.APIKey)
+	args = append(args, "--api-key", apiKey)
 	args = append(args, "--source", cfg.Source)
 
-	if cfg.SkipDuplicate {
-		args = append(args, "--skip-duplicate")
+	// --skip-duplicate is never passed: the 409 must reach handleDuplicate so
+	// the package is reported as skipped instead of pushed.
+
+	if cfg.NoSymbols {
+		args = append(args, "--no-symbols")
+	}
+	if cfg.SymbolSource != "" {
+		args = append(args, "--symbol-source", cfg.SymbolSource)
+	}
+	if cfg.SymbolAPIKey != "" {
+		args = append(args, "--symbol-api-key", cfg.SymbolAPIKey)
 	}
 
 	args = append(args, "--timeout", fmt.Sprintf("%d", cfg.Timeout))
 
+	attemptCtx, cancel := cfg.withPushTimeout(ctx)
+	defer cancel()
+
 	executor := p.getExecutor()
-	output, err := executor.Run(ctx, "dotnet", args...)
+	var output []byte
+	var err error
+	env := cfg.pushEnv()
+	if streamer, ok := executor.(StreamingCommandExecutor); ok {
+		p.getLogger().Info("pushing package", "package", packagePath, "attempt", attempt, "source", cfg.Source)
+		output, err = streamer.RunStreaming(attemptCtx, env, p.pushOutputLogger(packagePath, attempt), "dotnet", args...)
+	} else if len(env) > 0 {
+		envExecutor, ok := executor.(EnvCommandExecutor)
+		if !ok {
+			return &PushError{Kind: ErrorKindAuth, Output: "the command executor cannot pass feed credentials to dotnet"}
+		}
+		output, err = envExecutor.RunWithEnv(attemptCtx, env, "dotnet", args...)
+	} else {
+		output, err = executor.Run(attemptCtx, "dotnet", args...)
+	}
 	if err != nil {
-		return fmt.Errorf("%s: %w", strings.TrimSpace(string(output)), err)
+		if pushErr := deadlineError(ctx, attemptCtx, cfg, err); pushErr != nil {
+			return pushErr
+		}
+		return classifyPushFailure(attemptCtx, output, err)
 	}
 
 	return nil
@@ -213,15 +790,48 @@ func (p *NuGetPlugin) findPackages(pattern string) ([]string, error) {
 }
 
 // validateConfig validates the plugin configuration.
-func (p *NuGetPlugin) validateConfig(cfg *Config) error {
-	if cfg.APIKey == "" {
-		return fmt.Errorf("API key is required (set api_key or NUGET_API_KEY environment variable)")
+func (p *NuGetPlugin) validateConfig(ctx context.Context, cfg *Config) error {
+	switch cfg.Target {
+	case "", TargetNuGet:
+	case TargetGitHubPackages:
+		if cfg.GitHubOwner == "" {
+			return fmt.Errorf("github_owner is required for the github_packages target when the release has no repository owner")
+		}
+	case TargetAzureArtifacts:
+		if cfg.AzureOrganization == "" || cfg.AzureFeed == "" {
+			return fmt.Errorf("azure_organization and azure_feed are required for the azure_artifacts target")
+		}
+		if cfg.AzurePAT == "" {
+			return fmt.Errorf("an Azure DevOps PAT is required (set azure_pat or AZURE_DEVOPS_PAT environment variable)")
+		}
+	default:
+		return fmt.Errorf("target must be one of: nuget, github_packages, azure_artifacts")
+	}
+
+	if cfg.APIKey == "" && len(cfg.APIKeys) == 0 {
+		return fmt.Errorf("API key is required (set api_key, api_keys or NUGET_API_KEY environment variable)")
+	}
+
+	for _, m := range cfg.APIKeys {
+		if m.Pattern == "" || m.KeyEnv == "" {
+			return fmt.Errorf("api_keys entries require both pattern and key_env")
+		}
 	}
 
-	if err := validateSourceURL(cfg.Source); err != nil {
+	if err := validateSourceURL(ctx, cfg.Source); err != nil {
 		return fmt.Errorf("invalid source URL: %w", err)
 	}
 
+	if cfg.SymbolSource != "" {
+		if err := validateSourceURL(ctx, cfg.SymbolSource); err != nil {
+			return fmt.Errorf("invalid symbol_source URL: %w", err)
+		}
+	}
+
+	if problems := channelURLProblems(ctx, cfg.Channels); len(problems) > 0 {
+		return fmt.Errorf("invalid channels: %s", strings.Join(problems, "; "))
+	}
+
 	if err := validatePackagePath(cfg.PackagePath); err != nil {
 		return fmt.Errorf("invalid package path: %w", err)
 	}
@@ -230,11 +840,97 @@ func (p *NuGetPlugin) validateConfig(cfg *Config) error {
 		return fmt.Errorf("timeout must be a positive integer")
 	}
 
+	switch cfg.duplicatePolicy() {
+	case OnDuplicateFail, OnDuplicateSkip, OnDuplicateSkipIfIdentical:
+	default:
+		return fmt.Errorf("on_duplicate must be one of: fail, skip, skip_if_identical")
+	}
+
+	if cfg.Retries < 0 {
+		return fmt.Errorf("retries cannot be negative")
+	}
+
+	if cfg.Resume {
+		if err := validatePackagePath(cfg.StateFile); err != nil {
+			return fmt.Errorf("invalid state file: %w", err)
+		}
+	}
+
+	if cfg.RetryDelay < 0 {
+		return fmt.Errorf("retry_delay cannot be negative")
+	}
+
+	if cfg.PushTimeout < 0 {
+		return fmt.Errorf("push_timeout cannot be negative")
+	}
+
+	if cfg.TotalTimeout < 0 {
+		return fmt.Errorf("total_timeout cannot be negative")
+	}
+
+	if cfg.VerifyTimeout < 0 {
+		return fmt.Errorf("verify_timeout cannot be negative")
+	}
+
+	if cfg.SummaryPath != "" {
+		if err := validatePackagePath(cfg.SummaryPath); err != nil {
+			return fmt.Errorf("invalid summary_path: %w", err)
+		}
+	}
+
+	if _, problems := parseAllowedLicenses(cfg.AllowedLicenses); len(problems) > 0 {
+		return fmt.Errorf("invalid allowed_licenses: %s", strings.Join(problems, "; "))
+	}
+
+	if cfg.SBOMDir != "" {
+		if err := validatePackagePath(cfg.SBOMDir); err != nil {
+			return fmt.Errorf("invalid sbom_dir: %w", err)
+		}
+	}
+	if cfg.EmbedSBOM && !cfg.sbomEnabled() {
+		return fmt.Errorf("embed_sbom requires sbom to be package or release")
+	}
+
+	if cfg.PromoteFrom != "" {
+		if err := validateSourceURL(ctx, cfg.PromoteFrom); err != nil {
+			return fmt.Errorf("invalid promote_from URL: %w", err)
+		}
+		if len(cfg.PromotePackages) == 0 {
+			return fmt.Errorf("promote_packages must list the package IDs to promote")
+		}
+		for _, id := range cfg.PromotePackages {
+			if err := validatePackageID(id); err != nil {
+				return fmt.Errorf("invalid promote_packages: %w", err)
+			}
+		}
+		if cfg.InjectMetadata {
+			return fmt.Errorf("inject_metadata cannot be used with promote_from: promoted packages are pushed unchanged")
+		}
+		if cfg.EmbedSBOM {
+			return fmt.Errorf("embed_sbom cannot be used with promote_from: promoted packages are pushed unchanged")
+		}
+		if cfg.sbomEnabled() && cfg.SBOMDir == "" {
+			return fmt.Errorf("sbom_dir is required with promote_from: promoted packages are downloaded to a temporary directory")
+		}
+	}
+
+	if cfg.Target == TargetAzureArtifacts && cfg.AzureURL != DefaultAzureDevOpsURL {
+		if err := validateSourceURL(ctx, cfg.AzureURL); err != nil {
+			return fmt.Errorf("invalid azure_url: %w", err)
+		}
+	}
+
+	if cfg.feedKind() == feedKindGitHub && cfg.GitHubAPIURL != DefaultGitHubAPIURL {
+		if err := validateSourceURL(ctx, cfg.GitHubAPIURL); err != nil {
+			return fmt.Errorf("invalid github_api_url: %w", err)
+		}
+	}
+
 	return nil
 }
 
 // validateSourceURL validates that a URL is safe to use (SSRF protection).
-func validateSourceURL(rawURL string) error {
+func validateSourceURL(ctx context.Context, rawURL string) error {
 	if rawURL == "" {
 		return fmt.Errorf("source URL cannot be empty")
 	}
@@ -259,8 +955,10 @@ func validateSourceURL(rawURL string) error {
 		return nil
 	}
 
-	// Resolve hostname to check for private IPs
-	ips, err := net.LookupIP(host)
+	// Resolve hostname to check for private IPs, without letting a DNS stall hang the release
+	ctx, cancel := context.WithTimeout(ctx, sourceLookupTimeout)
+	defer cancel()
+	ips, err := net.DefaultResolver.LookupIP(ctx, "ip", host)
 	if err != nil {
 		return fmt.Errorf("failed to resolve hostname: %w", err)
 	}
@@ -341,25 +1039,97 @@ func validatePackagePath(path string) error {
 // parseConfig parses the raw configuration into a Config struct.
 func (p *NuGetPlugin) parseConfig(raw map[string]any) *Config {
 	parser := helpers.NewConfigParser(raw)
+	phases, _ := parsePhases(parser.GetMap("phases"))
+	apiKeys, _ := parseAPIKeyMappings(raw["api_keys"])
+	channels, _ := parseChannels(raw["channels"])
+	secretPatterns, _ := parseSecretPatterns(raw["secret_patterns"])
+	assemblyRules, _ := parseAssemblyVersionRules(parser.GetMap("assembly_version_rules"))
+	maxPackageSize, err := parsePackageSize(raw["max_package_size"])
+	if err != nil {
+		maxPackageSize = -1
+	}
+
+	// GitHub Packages authenticates with a GitHub token and builds its source from the owner
+	target := parser.GetString("target", "", TargetNuGet)
+	apiKey := parser.GetString("api_key", "NUGET_API_KEY", "")
+	source := parser.GetString("source", "", DefaultSource)
+	switch target {
+	case TargetGitHubPackages:
+		apiKey = parser.GetString("github_token", "GITHUB_TOKEN", parser.GetString("api_key", "", ""))
+		source = parser.GetString("source", "", "")
+	case TargetAzureArtifacts:
+		apiKey = parser.GetString("api_key", "", azureAPIKeyPlaceholder)
+		source = parser.GetString("source", "", "")
+	}
 
 	return &Config{
-		APIKey:        parser.GetString("api_key", "NUGET_API_KEY", ""),
-		Source:        parser.GetString("source", "", DefaultSource),
+		Target:        target,
+		APIKey:        apiKey,
+		APIKeys:       apiKeys,
+		Source:        source,
 		PackagePath:   parser.GetString("package_path", "", DefaultPackagePath),
 		SkipDuplicate: parser.GetBool("skip_duplicate", false),
+		OnDuplicate:   parser.GetString("on_duplicate", "", ""),
 		Timeout:       parser.GetInt("timeout", DefaultTimeout),
+		Retries:       parser.GetInt("retries", DefaultRetries),
+		RetryDelay:    parser.GetInt("retry_delay", DefaultRetryDelay),
+		Resume:        parser.GetBool("resume", false),
+		StateFile:     parser.GetString("state_file", "", DefaultStateFile),
+		ResetState:    parser.GetBool("reset_state", false),
+
+		InjectMetadata: parser.GetBool("inject_metadata", false),
+		Phases:         phases,
+		VerifyTimeout:  parser.GetInt("verify_timeout", DefaultVerifyTimeout),
+		GitHubAPIURL:   parser.GetString("github_api_url", "", DefaultGitHubAPIURL),
+		GitHubOwner:    parser.GetString("github_owner", "", ""),
+		GitHubUsername: parser.GetString("github_username", "", ""),
+
+		AzureURL:          parser.GetString("azure_url", "", DefaultAzureDevOpsURL),
+		AzureOrganization: parser.GetString("azure_organization", "", ""),
+		AzureProject:      parser.GetString("azure_project", "", ""),
+		AzureFeed:         parser.GetString("azure_feed", "", ""),
+		AzureView:         parser.GetString("azure_view", "", ""),
+		AzurePAT:          parser.GetString("azure_pat", "AZURE_DEVOPS_PAT", ""),
+
+		PromoteFrom:           parser.GetString("promote_from", "", ""),
+		PromotePackages:       parser.GetStringSlice("promote_packages", nil),
+		PromoteUsername:       parser.GetString("promote_username", "", ""),
+		PromotePassword:       parser.GetString("promote_password", "PROMOTE_FEED_PASSWORD", ""),
+		SBOM:                  parser.GetString("sbom", "", SBOMOff),
+		SBOMDir:               parser.GetString("sbom_dir", "", ""),
+		EmbedSBOM:             parser.GetBool("embed_sbom", false),
+		SummaryPath:           parser.GetString("summary_path", "", ""),
+		ScanContent:           parser.GetBool("scan_content", false),
+		ForbiddenFiles:        parser.GetStringSlice("forbidden_files", DefaultForbiddenFiles),
+		SecretPatterns:        secretPatterns,
+		CheckPackageDocs:      parser.GetBool("check_package_docs", false),
+		OnReadmeIssue:         parser.GetString("on_readme_issue", "", ReadmeIssueWarn),
+		AllowedLicenses:       parser.GetStringSlice("allowed_licenses", nil),
+		RejectLicenseURL:      parser.GetBool("reject_license_url", false),
+		CheckAssemblyVersions: parser.GetBool("check_assembly_versions", false),
+		AssemblyVersionRules:  assemblyRules,
+		CheckPublicAPI:        parser.GetBool("check_public_api", false),
+		OnFrameworkRemoved:    parser.GetString("on_framework_removed", "", FrameworkRemovedIgnore),
+		MaxPackageSize:        maxPackageSize,
+		PushTimeout:           parser.GetInt("push_timeout", 0),
+		TotalTimeout:          parser.GetInt("total_timeout", 0),
+
+		NoSymbols:    parser.GetBool("no_symbols", false),
+		SymbolSource: parser.GetString("symbol_source", "", ""),
+		SymbolAPIKey: parser.GetString("symbol_api_key", "NUGET_SYMBOL_API_KEY", ""),
+		Channels:     channels,
 	}
 }
 
 // Validate validates the plugin configuration.
-func (p *NuGetPlugin) Validate(_ context.Context, config map[string]any) (*plugin.ValidateResponse, error) {
+func (p *NuGetPlugin) Validate(ctx context.Context, config map[string]any) (*plugin.ValidateResponse, error) {
 	vb := helpers.NewValidationBuilder()
 	parser := helpers.NewConfigParser(config)
 
 	// Validate source URL if provided
 	source := parser.GetString("source", "", DefaultSource)
 	if source != "" {
-		if err := validateSourceURL(source); err != nil {
+		if err := validateSourceURL(ctx, source); err != nil {
 			vb.AddError("source", err.Error())
 		}
 	}
@@ -378,6 +1148,119 @@ func (p *NuGetPlugin) Validate(_ context.Context, config map[string]any) (*plugi
 		vb.AddError("timeout", "must be a positive integer")
 	}
 
+	vb.ValidateOneOf(config, "target", []string{TargetNuGet, TargetGitHubPackages, TargetAzureArtifacts})
+
+	if parser.Has("azure_url") {
+		if err := validateSourceURL(ctx, parser.GetString("azure_url", "", DefaultAzureDevOpsURL)); err != nil {
+			vb.AddError("azure_url", err.Error())
+		}
+	}
+	vb.ValidateOneOf(config, "on_duplicate", []string{OnDuplicateFail, OnDuplicateSkip, OnDuplicateSkipIfIdentical})
+	vb.ValidateOneOf(config, "sbom", []string{SBOMOff, SBOMPackage, SBOMRelease})
+	vb.ValidateOneOf(config, "on_readme_issue", []string{ReadmeIssueFail, ReadmeIssueWarn})
+	vb.ValidateOneOf(config, "on_framework_removed", []string{FrameworkRemovedFail, FrameworkRemovedWarn, FrameworkRemovedIgnore})
+
+	if parser.GetBool("resume", false) {
+		if err := validatePackagePath(parser.GetString("state_file", "", DefaultStateFile)); err != nil {
+			vb.AddError("state_file", err.Error())
+		}
+	}
+
+	if parser.GetInt("retries", DefaultRetries) < 0 {
+		vb.AddError("retries", "cannot be negative")
+	}
+
+	if parser.GetInt("retry_delay", DefaultRetryDelay) < 0 {
+		vb.AddError("retry_delay", "cannot be negative")
+	}
+
+	if _, problems := parseSecretPatterns(config["secret_patterns"]); len(problems) > 0 {
+		vb.AddError("secret_patterns", strings.Join(problems, "; "))
+	}
+
+	if _, problems := parseAllowedLicenses(parser.GetStringSlice("allowed_licenses", nil)); len(problems) > 0 {
+		vb.AddError("allowed_licenses", strings.Join(problems, "; "))
+	}
+
+	if parser.Has("assembly_version_rules") {
+		if _, ok := config["assembly_version_rules"].(map[string]any); !ok {
+			vb.AddError("assembly_version_rules", "must be a map of informational, file and assembly to a rule")
+		}
+	}
+	if _, problems := parseAssemblyVersionRules(parser.GetMap("assembly_version_rules")); len(problems) > 0 {
+		vb.AddError("assembly_version_rules", strings.Join(problems, "; "))
+	}
+
+	if _, err := parsePackageSize(config["max_package_size"]); err != nil {
+		vb.AddError("max_package_size", err.Error())
+	}
+
+	if parser.GetInt("push_timeout", 0) < 0 {
+		vb.AddError("push_timeout", "cannot be negative")
+	}
+
+	if parser.GetInt("total_timeout", 0) < 0 {
+		vb.AddError("total_timeout", "cannot be negative")
+	}
+
+	if parser.Has("phases") {
+		if _, ok := config["phases"].(map[string]any); !ok {
+			vb.AddError("phases", "must be a map of phase names to true or false")
+		}
+	}
+	if _, problems := parsePhases(parser.GetMap("phases")); len(problems) > 0 {
+		vb.AddError("phases", strings.Join(problems, "; "))
+	}
+
+	if _, problems := parseAPIKeyMappings(config["api_keys"]); len(problems) > 0 {
+		vb.AddError("api_keys", strings.Join(problems, "; "))
+	}
+
+	channels, problems := parseChannels(config["channels"])
+	problems = append(problems, channelURLProblems(ctx, channels)...)
+	if len(problems) > 0 {
+		vb.AddError("channels", strings.Join(problems, "; "))
+	}
+
+	if parser.Has("symbol_source") {
+		if err := validateSourceURL(ctx, parser.GetString("symbol_source", "", "")); err != nil {
+			vb.AddError("symbol_source", err.Error())
+		}
+	}
+
+	if parser.GetInt("verify_timeout", DefaultVerifyTimeout) < 0 {
+		vb.AddError("verify_timeout", "cannot be negative")
+	}
+
+	if parser.Has("summary_path") {
+		if err := validatePackagePath(parser.GetString("summary_path", "", "")); err != nil {
+			vb.AddError("summary_path", err.Error())
+		}
+	}
+
+	if parser.Has("promote_from") {
+		if err := validateSourceURL(ctx, parser.GetString("promote_from", "", "")); err != nil {
+			vb.AddError("promote_from", err.Error())
+		}
+		promoted := parser.GetStringSlice("promote_packages", nil)
+		if len(promoted) == 0 {
+			vb.AddError("promote_packages", "must list the package IDs to promote")
+		}
+		for _, id := range promoted {
+			if err := validatePackageID(id); err != nil {
+				vb.AddError("promote_packages", err.Error())
+			}
+		}
+	} else if parser.Has("promote_packages") {
+		vb.AddError("promote_packages", "requires promote_from")
+	}
+
+	if parser.Has("github_api_url") {
+		if err := validateSourceURL(ctx, parser.GetString("github_api_url", "", DefaultGitHubAPIURL)); err != nil {
+			vb.AddError("github_api_url", err.Error())
+		}
+	}
+
 	// API key validation is optional at config time (can come from env var at runtime)
 	// We don't add an error here since the key can be provided via NUGET_API_KEY env var
 
@@ -3,6 +3,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"os"
 	"path/filepath"
@@ -21,6 +22,7 @@ type MockCommandExecutor struct {
 type MockCall struct {
 	Name string
 	Args []string
+	Env  []string
 }
 
 // Run implements CommandExecutor.
@@ -32,6 +34,13 @@ func (m *MockCommandExecutor) Run(ctx context.Context, name string, args ...stri
 	return nil, nil
 }
 
+// RunWithEnv implements EnvCommandExecutor.
+func (m *MockCommandExecutor) RunWithEnv(ctx context.Context, env []string, name string, args ...string) ([]byte, error) {
+	output, err := m.Run(ctx, name, args...)
+	m.Calls[len(m.Calls)-1].Env = env
+	return output, err
+}
+
 func TestGetInfo(t *testing.T) {
 	p := &NuGetPlugin{}
 	info := p.GetInfo()
@@ -113,6 +122,28 @@ func TestGetInfo(t *testing.T) {
 			t.Errorf("expected author 'Relicta Team', got '%s'", info.Author)
 		}
 	})
+
+	t.Run("top-level settings are not channel settings", func(t *testing.T) {
+		var schema struct {
+			Properties map[string]struct {
+				Items struct {
+					Properties map[string]any `json:"properties"`
+				} `json:"items"`
+			} `json:"properties"`
+		}
+		if err := json.Unmarshal([]byte(info.ConfigSchema), &schema); err != nil {
+			t.Fatalf("config schema is not valid JSON: %v", err)
+		}
+		channel := schema.Properties["channels"].Items.Properties
+		for _, name := range []string{"summary_path", "sbom", "sbom_dir", "embed_sbom"} {
+			if _, ok := schema.Properties[name]; !ok {
+				t.Errorf("expected %s at the top level of the schema", name)
+			}
+			if _, ok := channel[name]; ok {
+				t.Errorf("expected %s not to be a channel setting", name)
+			}
+		}
+	})
 }
 
 func TestValidate(t *testing.T) {
@@ -194,6 +225,38 @@ func TestValidate(t *testing.T) {
 			},
 			wantValid: false,
 		},
+		{
+			name: "invalid retries (negative)",
+			config: map[string]any{
+				"api_key": "test-api-key",
+				"retries": -1,
+			},
+			wantValid: false,
+		},
+		{
+			name: "unknown lifecycle phase",
+			config: map[string]any{
+				"api_key": "test-api-key",
+				"phases":  map[string]any{"deploy": true},
+			},
+			wantValid: false,
+		},
+		{
+			name: "invalid readme issue policy",
+			config: map[string]any{
+				"api_key":         "test-api-key",
+				"on_readme_issue": "ignore",
+			},
+			wantValid: false,
+		},
+		{
+			name: "unknown allowed license",
+			config: map[string]any{
+				"api_key":          "test-api-key",
+				"allowed_licenses": []any{"MIT", "Contoso-1.0"},
+			},
+			wantValid: false,
+		},
 		{
 			name: "localhost source is valid with HTTP",
 			config: map[string]any{
@@ -329,7 +392,8 @@ func TestExecuteDryRun(t *testing.T) {
 		t.Fatalf("failed to create test package: %v", err)
 	}
 
-	p := &NuGetPlugin{}
+	feed := newTestFeed(t)
+	p := &NuGetPlugin{httpClient: feed.server.Client()}
 	ctx := context.Background()
 
 	tests := []struct {
@@ -343,6 +407,7 @@ func TestExecuteDryRun(t *testing.T) {
 			name: "basic dry run",
 			config: map[string]any{
 				"api_key":      "test-key",
+				"source":       feed.SourceURL(),
 				"package_path": filepath.Join(tmpDir, "*.nupkg"),
 			},
 			releaseCtx: plugin.ReleaseContext{
@@ -355,6 +420,7 @@ func TestExecuteDryRun(t *testing.T) {
 			name: "dry run with skip_duplicate",
 			config: map[string]any{
 				"api_key":        "test-key",
+				"source":         feed.SourceURL(),
 				"skip_duplicate": true,
 				"package_path":   filepath.Join(tmpDir, "*.nupkg"),
 			},
@@ -368,7 +434,7 @@ func TestExecuteDryRun(t *testing.T) {
 			name: "dry run with localhost source",
 			config: map[string]any{
 				"api_key":      "test-key",
-				"source":       "http://localhost:5000/v3/index.json",
+				"source":       feed.SourceURL(),
 				"package_path": filepath.Join(tmpDir, "*.nupkg"),
 			},
 			releaseCtx: plugin.ReleaseContext{
@@ -412,10 +478,6 @@ func TestExecuteUnhandledHook(t *testing.T) {
 		name string
 		hook plugin.Hook
 	}{
-		{
-			name: "PreInit hook",
-			hook: plugin.HookPreInit,
-		},
 		{
 			name: "PostInit hook",
 			hook: plugin.HookPostInit,
@@ -638,8 +700,8 @@ func TestExecutePush_CommandArguments(t *testing.T) {
 			expectedArgs: []string{
 				"nuget", "push",
 				"--api-key", "my-secret-key",
-				"--skip-duplicate",
 			},
+			unexpectedArgs: []string{"--skip-duplicate"},
 		},
 		{
 			name: "push with custom timeout",
@@ -747,7 +809,7 @@ func TestValidateSourceURL(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := validateSourceURL(tt.url)
+			err := validateSourceURL(context.Background(), tt.url)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("validateSourceURL() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -758,6 +820,15 @@ func TestValidateSourceURL(t *testing.T) {
 			}
 		})
 	}
+
+	t.Run("lookup honors the hook context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := validateSourceURL(ctx, "https://api.nuget.org/v3/index.json")
+		if err == nil || !errors.Is(err, context.Canceled) {
+			t.Errorf("expected the canceled context to stop the lookup, got %v", err)
+		}
+	})
 }
 
 func TestValidatePackagePath(t *testing.T) {
@@ -984,7 +1055,7 @@ func TestValidateConfig(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := p.validateConfig(tt.config)
+			err := p.validateConfig(context.Background(), tt.config)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("validateConfig() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -1230,11 +1301,23 @@ func TestPartialPushFailure(t *testing.T) {
 		t.Errorf("expected error about failed push, got: %s", resp.Error)
 	}
 
-	// Check that outputs contain pushed packages
-	if resp.Outputs != nil {
-		pushedPkgs, ok := resp.Outputs["pushed_packages"].([]string)
-		if ok && len(pushedPkgs) != 1 {
-			t.Errorf("expected 1 pushed package before failure, got %d", len(pushedPkgs))
+	// Check that outputs describe every package
+	summary, ok := resp.Outputs["summary"].(RunSummary)
+	if !ok {
+		t.Fatalf("expected summary output, got %T", resp.Outputs["summary"])
+	}
+	if summary.Pushed != 1 || summary.Failed != 1 || summary.NotAttempted != 1 {
+		t.Errorf("expected 1 pushed, 1 failed, 1 not attempted, got %+v", summary)
+	}
+
+	results, ok := resp.Outputs["packages"].([]PackageResult)
+	if !ok {
+		t.Fatalf("expected package results, got %T", resp.Outputs["packages"])
+	}
+	wantStatuses := []PackageStatus{StatusPushed, StatusFailed, StatusNotAttempted}
+	for i, r := range results {
+		if r.Status != wantStatuses[i] {
+			t.Errorf("%s: expected status %s, got %s", r.Path, wantStatuses[i], r.Status)
 		}
 	}
 }
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// promotion holds the packages downloaded from the staging feed in promote mode.
+type promotion struct {
+	source string
+	dir    string
+	// hashes maps each downloaded file to the SHA-512 of the bytes the staging feed served.
+	hashes map[string]string
+}
+
+// paths returns the downloaded package files in a stable order.
+func (pr *promotion) paths() []string {
+	paths := make([]string, 0, len(pr.hashes))
+	for path := range pr.hashes {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// cleanup removes the downloaded packages. It is safe to call on a nil promotion.
+func (pr *promotion) cleanup() {
+	if pr != nil {
+		_ = os.RemoveAll(pr.dir)
+	}
+}
+
+// annotate records the staging source and hash on each result.
+func (pr *promotion) annotate(results []PackageResult) {
+	for i := range results {
+		results[i].PromotedFrom = pr.source
+		results[i].SHA512 = pr.hashes[results[i].Path]
+	}
+}
+
+// releasePackages returns the package files of the release: the local files
+// matching package_path or, in promote mode, the release version of each
+// promote_packages ID downloaded from the staging feed. The promotion is nil
+// outside promote mode; callers must clean it up.
+func (p *NuGetPlugin) releasePackages(ctx context.Context, cfg *Config, version string) ([]string, *promotion, error) {
+	if cfg.PromoteFrom != "" {
+		pr, err := p.downloadPromoted(ctx, cfg, version)
+		if err != nil {
+			return nil, nil, err
+		}
+		return pr.paths(), pr, nil
+	}
+
+	packages, err := p.findPackages(cfg.PackagePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find packages: %w", err)
+	}
+	if len(packages) == 0 {
+		return nil, nil, fmt.Errorf("no packages found matching pattern: %s", cfg.PackagePath)
+	}
+	return packages, nil, nil
+}
+
+// downloadPromoted downloads each package to promote from the staging feed's
+// flat container, hashing the bytes as they arrive, and checks that every
+// download is the requested id and version.
+func (p *NuGetPlugin) downloadPromoted(ctx context.Context, cfg *Config, version string) (*promotion, error) {
+	if version == "" {
+		return nil, fmt.Errorf("promote mode needs the release version")
+	}
+
+	staging := &feedClient{
+		httpClient: p.getHTTPClient(),
+		source:     cfg.PromoteFrom,
+		kind:       detectFeedKind(cfg.PromoteFrom),
+		username:   cfg.PromoteUsername,
+		password:   cfg.PromotePassword,
+		// Promoted packages are pushed to the target feed, so its limit applies.
+		maxDownload: cfg.downloadLimit(),
+	}
+	index, err := staging.serviceIndex(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("staging feed unreachable: %w", err)
+	}
+	baseAddress := index.resourceURL(resourcePackageBaseAddress)
+	if baseAddress == "" {
+		return nil, fmt.Errorf("staging feed %s does not advertise a PackageBaseAddress resource", cfg.PromoteFrom)
+	}
+
+	dir, err := os.MkdirTemp("", "nuget-promote-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	pr := &promotion{source: cfg.PromoteFrom, dir: dir, hashes: map[string]string{}}
+
+	for _, packageID := range cfg.PromotePackages {
+		id := packageIdentity{ID: packageID, Version: version}
+		// The ID and version become a file name; never let them leave dir.
+		if err := validatePackageID(packageID); err != nil {
+			pr.cleanup()
+			return nil, err
+		}
+		path := filepath.Join(dir, filepath.Base(packageID+"."+version+".nupkg"))
+		hash, err := downloadWithHash(ctx, staging, baseAddress, id, path)
+		if errors.Is(err, errPackageNotFound) {
+			pr.cleanup()
+			return nil, fmt.Errorf("%s is not on the staging feed %s", id, cfg.PromoteFrom)
+		}
+		if err != nil {
+			pr.cleanup()
+			return nil, fmt.Errorf("failed to download %s from the staging feed: %w", id, err)
+		}
+
+		got, err := readPackageIdentity(path)
+		if err != nil {
+			pr.cleanup()
+			return nil, err
+		}
+		if !strings.EqualFold(got.ID, id.ID) || comparableVersion(got.Version) != comparableVersion(id.Version) {
+			pr.cleanup()
+			return nil, fmt.Errorf("staging feed served %s when %s was requested", got, id)
+		}
+		pr.hashes[path] = hash
+	}
+	return pr, nil
+}
+
+// downloadWithHash downloads a package to path and returns the hex-encoded
+// SHA-512 of the downloaded bytes.
+func downloadWithHash(ctx context.Context, client *feedClient, baseAddress string, id packageIdentity, path string) (string, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	h := sha512.New()
+	err = client.downloadPackage(ctx, baseAddress, id, io.MultiWriter(f, h))
+	if closeErr := f.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to write %s: %w", path, closeErr)
+	}
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// checkPromotedHash re-hashes the file about to be pushed and fails if it no
+// longer matches the bytes downloaded from the staging feed.
+func checkPromotedHash(result *PackageResult) error {
+	hash, err := fileSHA512(result.pushPath())
+	if err != nil {
+		return err
+	}
+	if hash != result.SHA512 {
+		return fmt.Errorf("SHA-512 of %s changed since it was downloaded from %s", packageLabel(*result), result.PromotedFrom)
+	}
+	return nil
+}
+
+// confirmPromoted downloads each promoted package back from the target feed and
+// compares it with the staging copy. Copies must be byte-for-byte identical,
+// except for a repository signature the target feed adds on ingestion. Packages
+// the target does not serve yet are noted but not failed; packages that differ
+// are marked failed and returned.
+func (p *NuGetPlugin) confirmPromoted(ctx context.Context, cfg *Config, results []PackageResult) []string {
+	client := p.newFeedClient(cfg)
+	var baseAddress string
+	if index, err := client.serviceIndex(ctx); err == nil {
+		baseAddress = index.resourceURL(resourcePackageBaseAddress)
+	}
+
+	var differing []string
+	for i := range results {
+		result := &results[i]
+		if result.SHA512 == "" || (result.Status != StatusPushed && result.Status != StatusSkipped) {
+			continue
+		}
+		if baseAddress == "" {
+			result.Message = joinReasons(result.Message, "target feed has no flat container to compare the published copy against")
+			continue
+		}
+
+		identical, err := client.compareWithPublished(ctx, baseAddress, packageIdentity{ID: result.ID, Version: result.Version}, result.pushPath())
+		switch {
+		case errors.Is(err, errPackageNotFound):
+			result.Message = joinReasons(result.Message, "published copy not yet available to compare")
+		case err != nil:
+			result.Message = joinReasons(result.Message, fmt.Sprintf("could not compare published copy: %v", err))
+		case !identical:
+			result.Status = StatusFailed
+			result.setError(ErrorKindValidation, fmt.Sprintf("published copy differs from the staging copy (SHA-512 %s)", result.SHA512))
+			differing = append(differing, packageLabel(*result))
+		}
+	}
+	return differing
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestExecutePromote(t *testing.T) {
+	staged, err := os.ReadFile(writeTestPackage(t, t.TempDir(), "Contoso.Core", "1.2.0", map[string]string{"lib/net8.0/Contoso.Core.dll": "staged"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rebuilt, err := os.ReadFile(writeTestPackage(t, t.TempDir(), "Contoso.Core", "1.2.0", map[string]string{"lib/net8.0/Contoso.Core.dll": "rebuilt"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sum := sha512.Sum512(staged)
+	stagedHash := hex.EncodeToString(sum[:])
+
+	tests := []struct {
+		name      string
+		packages  []any
+		published []byte
+		wantErr   string
+		wantPush  bool
+	}{
+		{name: "promotes staged bytes", packages: []any{"Contoso.Core"}, wantPush: true},
+		{name: "published copy differs", packages: []any{"Contoso.Core"}, published: rebuilt, wantPush: true, wantErr: "differs from the staging feed"},
+		{name: "missing on staging", packages: []any{"Contoso.Data"}, wantErr: "Contoso.Data 1.2.0 is not on the staging feed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			staging := newTestFeed(t)
+			staging.AddPackage("Contoso.Core", "1.2.0", staged)
+			target := newTestFeed(t)
+
+			var pushed []byte
+			mockExec := &MockCommandExecutor{
+				RunFunc: func(_ context.Context, _ string, args ...string) ([]byte, error) {
+					data, err := os.ReadFile(args[2])
+					if err != nil {
+						return nil, err
+					}
+					pushed = data
+					if tt.published != nil {
+						data = tt.published
+					}
+					target.AddPackage("Contoso.Core", "1.2.0", data)
+					return nil, nil
+				},
+			}
+			p := &NuGetPlugin{cmdExecutor: mockExec, httpClient: staging.server.Client()}
+
+			resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+				Hook: plugin.HookPostPublish,
+				Config: map[string]any{
+					"api_key":          "test-key",
+					"source":           target.SourceURL(),
+					"promote_from":     staging.SourceURL(),
+					"promote_packages": tt.packages,
+					"promote_username": "ci",
+					"promote_password": "staging-token",
+				},
+				Context: plugin.ReleaseContext{Version: "v1.2.0"},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tt.wantPush != (len(mockExec.Calls) == 1) {
+				t.Fatalf("expected push=%v, got %d pushes", tt.wantPush, len(mockExec.Calls))
+			}
+			if tt.wantPush && string(pushed) != string(staged) {
+				t.Error("expected the staged bytes to be pushed unchanged")
+			}
+			if tt.wantErr != "" {
+				if resp.Success || !strings.Contains(resp.Error, tt.wantErr) {
+					t.Errorf("expected error containing %q, got success=%v error=%s", tt.wantErr, resp.Success, resp.Error)
+				}
+				return
+			}
+			if !resp.Success {
+				t.Fatalf("expected success, got: %s", resp.Error)
+			}
+			if !strings.Contains(resp.Message, "promoted 1 package(s) from "+staging.SourceURL()) {
+				t.Errorf("unexpected message: %s", resp.Message)
+			}
+			result := resp.Outputs["packages"].([]PackageResult)[0]
+			if result.SHA512 != stagedHash || result.PromotedFrom != staging.SourceURL() {
+				t.Errorf("expected staging hash and source on the result, got %+v", result)
+			}
+			wantAuth := "Basic " + base64.StdEncoding.EncodeToString([]byte("ci:staging-token"))
+			if staging.authorizations[0] != wantAuth {
+				t.Errorf("expected staging reads to authenticate, got %q", staging.authorizations[0])
+			}
+		})
+	}
+}
+
+func TestCheckPromotedHash(t *testing.T) {
+	path := writeTestPackage(t, t.TempDir(), "Contoso.Core", "1.2.0", nil)
+	hash, err := fileSHA512(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result := &PackageResult{Path: path, ID: "Contoso.Core", Version: "1.2.0", SHA512: hash, PromotedFrom: "staging"}
+
+	if err := checkPromotedHash(result); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("tampered"), 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := checkPromotedHash(result); err == nil || !strings.Contains(err.Error(), "changed since it was downloaded") {
+		t.Errorf("expected hash mismatch, got %v", err)
+	}
+}
+
+func TestValidateConfigPromote(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr string
+	}{
+		{name: "valid", cfg: Config{PromotePackages: []string{"Contoso.Core"}}},
+		{name: "no packages", wantErr: "promote_packages must list"},
+		{name: "path in package ID", cfg: Config{PromotePackages: []string{"../Contoso.Core"}}, wantErr: `"../Contoso.Core" is not a valid package ID`},
+		{name: "metadata injection", cfg: Config{PromotePackages: []string{"Contoso.Core"}, InjectMetadata: true}, wantErr: "inject_metadata cannot be used"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			cfg.APIKey = "key"
+			cfg.Source = "https://127.0.0.1/v3/index.json"
+			cfg.PackagePath = DefaultPackagePath
+			cfg.Timeout = DefaultTimeout
+			cfg.PromoteFrom = "https://127.0.0.1/v3/index.json"
+
+			err := (&NuGetPlugin{}).validateConfig(context.Background(), &cfg)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// PackageStatus is the outcome of processing a single package.
+type PackageStatus string
+
+const (
+	// StatusPushed means the package was accepted by the feed.
+	StatusPushed PackageStatus = "pushed"
+	// StatusSkipped means the package was intentionally not pushed (e.g. duplicate).
+	StatusSkipped PackageStatus = "skipped"
+	// StatusFailed means pushing the package failed.
+	StatusFailed PackageStatus = "failed"
+	// StatusNotAttempted means the run stopped before the package was processed.
+	StatusNotAttempted PackageStatus = "not_attempted"
+	// StatusWouldPush means a dry run predicts the package would be pushed.
+	StatusWouldPush PackageStatus = "would_push"
+	// StatusWouldSkip means a dry run predicts the package would be skipped.
+	StatusWouldSkip PackageStatus = "would_skip"
+	// StatusWouldFail means a dry run predicts pushing the package would fail.
+	StatusWouldFail PackageStatus = "would_fail"
+)
+
+// PackageResult is the structured result for a single package.
+type PackageResult struct {
+	Path       string        `json:"path"`
+	ID         string        `json:"id,omitempty"`
+	Version    string        `json:"version,omitempty"`
+	Status     PackageStatus `json:"status"`
+	Feed       string        `json:"feed"`
+	DurationMS int64         `json:"duration_ms"`
+	Attempts   int           `json:"attempts"`
+	ErrorClass string        `json:"error_class,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	Hint       string        `json:"hint,omitempty"`
+	Message    string        `json:"message,omitempty"`
+	URL        string        `json:"url,omitempty"`
+	// KeyPattern is the api_keys pattern that selected the package's API key.
+	KeyPattern string `json:"key_pattern,omitempty"`
+	// View is the Azure Artifacts view the package was promoted to.
+	View string `json:"view,omitempty"`
+	// PromotedFrom is the staging feed a promoted package was downloaded from.
+	PromotedFrom string `json:"promoted_from,omitempty"`
+	// SHA512 is the hash of the promoted package bytes served by the staging feed.
+	SHA512 string `json:"sha512,omitempty"`
+	// SizeBytes is the size of the package file.
+	SizeBytes int64 `json:"size_bytes,omitempty"`
+	// LargestFiles lists the largest files inside the package.
+	LargestFiles []PackageFile `json:"largest_files,omitempty"`
+	// Findings lists forbidden files and secrets found by the content scan.
+	Findings []ContentFinding `json:"findings,omitempty"`
+	// DocIssues lists problems with the readme and license the package ships.
+	DocIssues []DocIssue `json:"doc_issues,omitempty"`
+	// License is the package's SPDX license expression, checked against allowed_licenses.
+	License string `json:"license,omitempty"`
+	// AssemblyMismatches lists assembly versions that disagree with the package version.
+	AssemblyMismatches []AssemblyMismatch `json:"assembly_mismatches,omitempty"`
+	// APIBaseline is the published version whose public API was compared.
+	APIBaseline string `json:"api_baseline,omitempty"`
+	// BreakingChanges lists public API of APIBaseline that was removed or changed.
+	BreakingChanges []APIChange `json:"breaking_changes,omitempty"`
+	// Frameworks lists the target frameworks of the package.
+	Frameworks *TargetFrameworks `json:"frameworks,omitempty"`
+	// FrameworkBaseline is the published version whose target frameworks were compared.
+	FrameworkBaseline string `json:"framework_baseline,omitempty"`
+	// RemovedFrameworks lists target frameworks of FrameworkBaseline the package no longer supports.
+	RemovedFrameworks []string `json:"removed_frameworks,omitempty"`
+	// SBOM is the CycloneDX SBOM written for the package.
+	SBOM string `json:"sbom,omitempty"`
+	// Warnings describe how the package version will appear differently on the
+	// feed, target frameworks dropped under on_framework_removed: warn and
+	// readme issues under on_readme_issue: warn.
+	Warnings []string `json:"warnings,omitempty"`
+	// Resumed is set when the package was confirmed in a previous run of the same release.
+	Resumed bool `json:"resumed,omitempty"`
+	// Verified is set when the verify phase saw the version listed on the feed.
+	Verified bool `json:"verified,omitempty"`
+
+	// stagedPath is a rewritten copy of the package to push instead of Path.
+	stagedPath string
+	// apiKey is the API key selected for the package.
+	apiKey string
+}
+
+// pushPath returns the file that is actually pushed for this result.
+func (r *PackageResult) pushPath() string {
+	if r.stagedPath != "" {
+		return r.stagedPath
+	}
+	return r.Path
+}
+
+// setError records a classified failure on the result.
+func (r *PackageResult) setError(kind PushErrorKind, msg string) {
+	r.ErrorClass = string(kind)
+	r.Error = msg
+	r.Hint = kind.Hint()
+}
+
+// versionWarningNote summarizes the version warnings of the results for a
+// response message, or returns "" if there are none.
+func versionWarningNote(results []PackageResult) string {
+	var notes []string
+	for _, r := range results {
+		for _, w := range r.Warnings {
+			notes = append(notes, packageLabel(r)+": "+w)
+		}
+	}
+	if len(notes) == 0 {
+		return ""
+	}
+	return "; warning: " + strings.Join(notes, "; ")
+}
+
+// RunSummary aggregates the package results of a single hook execution.
+type RunSummary struct {
+	Source       string `json:"source"`
+	Version      string `json:"version"`
+	DryRun       bool   `json:"dry_run"`
+	Total        int    `json:"total"`
+	Pushed       int    `json:"pushed"`
+	Skipped      int    `json:"skipped"`
+	Failed       int    `json:"failed"`
+	NotAttempted int    `json:"not_attempted"`
+	DurationMS   int64  `json:"duration_ms"`
+}
+
+// newPackageResults creates one pending result per package path.
+func newPackageResults(packages []string, feed string) []PackageResult {
+	results := make([]PackageResult, 0, len(packages))
+	for _, pkg := range packages {
+		results = append(results, PackageResult{
+			Path:   pkg,
+			Status: StatusNotAttempted,
+			Feed:   feed,
+		})
+	}
+	return results
+}
+
+// summarize counts package results by status.
+// Dry-run predictions are counted under the status they predict.
+func summarize(cfg *Config, version string, dryRun bool, results []PackageResult, elapsed time.Duration) RunSummary {
+	summary := RunSummary{
+		Source:     cfg.Source,
+		Version:    version,
+		DryRun:     dryRun,
+		Total:      len(results),
+		DurationMS: elapsed.Milliseconds(),
+	}
+	for _, r := range results {
+		switch r.Status {
+		case StatusPushed, StatusWouldPush:
+			summary.Pushed++
+		case StatusSkipped, StatusWouldSkip:
+			summary.Skipped++
+		case StatusFailed, StatusWouldFail:
+			summary.Failed++
+		case StatusNotAttempted:
+			summary.NotAttempted++
+		}
+	}
+	return summary
+}
+
+// resultOutputs serializes the run summary and package results into plugin outputs.
+// Every code path uses the same keys so downstream automation can rely on them.
+func resultOutputs(summary RunSummary, results []PackageResult) map[string]any {
+	if results == nil {
+		results = []PackageResult{}
+	}
+	return map[string]any{
+		"summary":          summary,
+		"packages":         results,
+		"framework_matrix": frameworkMatrix(results),
+		"sboms":            sbomPaths(results),
+	}
+}
+
+// failureResponse builds a failed response that still carries the structured outputs.
+func failureResponse(summary RunSummary, results []PackageResult, errMsg string) *plugin.ExecuteResponse {
+	return &plugin.ExecuteResponse{
+		Success: false,
+		Error:   errMsg,
+		Outputs: resultOutputs(summary, results),
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestSummarize(t *testing.T) {
+	cfg := &Config{Source: "https://example.com/v3/index.json"}
+	results := []PackageResult{
+		{Status: StatusPushed},
+		{Status: StatusWouldPush},
+		{Status: StatusSkipped},
+		{Status: StatusWouldFail},
+		{Status: StatusNotAttempted},
+	}
+
+	summary := summarize(cfg, "1.0.0", false, results, 1500*time.Millisecond)
+
+	want := RunSummary{
+		Source:       cfg.Source,
+		Version:      "1.0.0",
+		Total:        5,
+		Pushed:       2,
+		Skipped:      1,
+		Failed:       1,
+		NotAttempted: 1,
+		DurationMS:   1500,
+	}
+	if summary != want {
+		t.Errorf("expected %+v, got %+v", want, summary)
+	}
+}
+
+func TestOutputsConsistentAcrossCodePaths(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTestPackage(t, tmpDir, "Contoso.Core", "1.0.0", nil)
+	feed := newTestFeed(t)
+
+	tests := []struct {
+		name   string
+		config map[string]any
+		dryRun bool
+	}{
+		{
+			name:   "configuration failure",
+			config: map[string]any{"source": feed.SourceURL(), "package_path": filepath.Join(tmpDir, "*.nupkg")},
+		},
+		{
+			name:   "no packages",
+			config: map[string]any{"api_key": "k", "source": feed.SourceURL(), "package_path": filepath.Join(tmpDir, "none*.nupkg")},
+		},
+		{
+			name:   "dry run",
+			config: map[string]any{"api_key": "k", "source": feed.SourceURL(), "package_path": filepath.Join(tmpDir, "*.nupkg")},
+			dryRun: true,
+		},
+		{
+			name:   "push",
+			config: map[string]any{"api_key": "k", "source": feed.SourceURL(), "package_path": filepath.Join(tmpDir, "*.nupkg")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("NUGET_API_KEY", "")
+			p := &NuGetPlugin{cmdExecutor: &MockCommandExecutor{}, httpClient: feed.server.Client()}
+
+			resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+				Hook:    plugin.HookPostPublish,
+				Config:  tt.config,
+				Context: plugin.ReleaseContext{Version: "v1.0.0"},
+				DryRun:  tt.dryRun,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			// Round-trip through JSON the way the SDK transports outputs.
+			data, err := json.Marshal(resp.Outputs)
+			if err != nil {
+				t.Fatalf("failed to marshal outputs: %v", err)
+			}
+			var decoded struct {
+				Summary  *RunSummary      `json:"summary"`
+				Packages *[]PackageResult `json:"packages"`
+			}
+			if err := json.Unmarshal(data, &decoded); err != nil {
+				t.Fatalf("failed to unmarshal outputs: %v", err)
+			}
+			if decoded.Summary == nil || decoded.Packages == nil {
+				t.Fatalf("expected summary and packages outputs, got %s", data)
+			}
+			if decoded.Summary.Version != "1.0.0" || decoded.Summary.DryRun != tt.dryRun {
+				t.Errorf("unexpected summary: %+v", *decoded.Summary)
+			}
+			if decoded.Summary.Total != len(*decoded.Packages) {
+				t.Errorf("summary total %d does not match %d package results", decoded.Summary.Total, len(*decoded.Packages))
+			}
+		})
+	}
+}
@@ -0,0 +1,565 @@
+package main
+
+import (
+	"archive/zip"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// SBOM generation modes.
+const (
+	// SBOMOff generates no SBOM.
+	SBOMOff = "off"
+	// SBOMPackage writes one SBOM per package.
+	SBOMPackage = "package"
+	// SBOMRelease writes one SBOM for all packages of the release.
+	SBOMRelease = "release"
+)
+
+// cycloneDXSpecVersion is the CycloneDX specification the SBOMs follow.
+const cycloneDXSpecVersion = "1.5"
+
+// sbomEntry is where embed_sbom stores the package's SBOM inside the package.
+const sbomEntry = "sbom/bom.cdx.json"
+
+// opcEntries are the packaging parts of a .nupkg that are not package content.
+var opcEntries = regexp.MustCompile(`^(?:\[Content_Types\]\.xml|_rels/.*|package/.*|\.signature\.p7s)$`)
+
+// contentTypesJSON matches a [Content_Types].xml default for .json files.
+var contentTypesJSON = regexp.MustCompile(`(?i)<Default\s[^>]*Extension\s*=\s*"json"`)
+
+// cdxBOM is a CycloneDX JSON document.
+type cdxBOM struct {
+	BOMFormat    string          `json:"bomFormat"`
+	SpecVersion  string          `json:"specVersion"`
+	SerialNumber string          `json:"serialNumber"`
+	Version      int             `json:"version"`
+	Metadata     cdxMetadata     `json:"metadata"`
+	Components   []cdxComponent  `json:"components,omitempty"`
+	Dependencies []cdxDependency `json:"dependencies,omitempty"`
+}
+
+// cdxMetadata describes when, by what and for which component a BOM was made.
+type cdxMetadata struct {
+	Timestamp string       `json:"timestamp"`
+	Tools     cdxTools     `json:"tools"`
+	Component cdxComponent `json:"component"`
+}
+
+// cdxTools lists the tools that produced a BOM.
+type cdxTools struct {
+	Components []cdxComponent `json:"components"`
+}
+
+// cdxComponent is a package, file or application in a BOM.
+type cdxComponent struct {
+	Type       string         `json:"type"`
+	BOMRef     string         `json:"bom-ref,omitempty"`
+	Name       string         `json:"name"`
+	Version    string         `json:"version,omitempty"`
+	Scope      string         `json:"scope,omitempty"`
+	PURL       string         `json:"purl,omitempty"`
+	Hashes     []cdxHash      `json:"hashes,omitempty"`
+	Licenses   []cdxLicense   `json:"licenses,omitempty"`
+	Properties []cdxProperty  `json:"properties,omitempty"`
+	Components []cdxComponent `json:"components,omitempty"`
+}
+
+// cdxHash is a hash of a component's contents.
+type cdxHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+// cdxLicense is a component license given as an SPDX expression.
+type cdxLicense struct {
+	Expression string `json:"expression"`
+}
+
+// cdxProperty is a name-value pair attached to a component.
+type cdxProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// cdxDependency lists the components a component depends on.
+type cdxDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+// packageBOM is the SBOM content of one package: the package with its files
+// as nested components, and the packages it depends on.
+type packageBOM struct {
+	Component    cdxComponent
+	Dependencies []cdxComponent
+}
+
+// nugetPURL returns the package URL of a NuGet package version.
+func nugetPURL(id, version string) string {
+	if version == "" {
+		return "pkg:nuget/" + id
+	}
+	return "pkg:nuget/" + id + "@" + version
+}
+
+// minimumVersion returns the lower bound of a NuGet version range such as
+// "[1.0.0, 2.0.0)", or the version itself for a plain minimum version.
+func minimumVersion(versionRange string) string {
+	lower, _, _ := strings.Cut(strings.TrimSpace(versionRange), ",")
+	return strings.TrimSpace(strings.Trim(lower, "[]() "))
+}
+
+// hashReader returns the SHA-256 and SHA-512 hashes of a stream.
+func hashReader(r io.Reader) ([]cdxHash, error) {
+	sha256Hash, sha512Hash := sha256.New(), sha512.New()
+	if _, err := io.Copy(io.MultiWriter(sha256Hash, sha512Hash), r); err != nil {
+		return nil, err
+	}
+	return []cdxHash{
+		{Alg: "SHA-256", Content: hexSum(sha256Hash)},
+		{Alg: "SHA-512", Content: hexSum(sha512Hash)},
+	}, nil
+}
+
+// hexSum returns the hex-encoded sum of h.
+func hexSum(h hash.Hash) string {
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// readPackageBOM reads the SBOM content of a package from its nuspec and
+// files. The hash of the .nupkg itself is left out when hashPackage is false,
+// as embedding the SBOM changes it.
+func readPackageBOM(path string, hashPackage bool) (packageBOM, error) {
+	doc, err := readNuspec(path)
+	if err != nil {
+		return packageBOM{}, err
+	}
+	meta := doc.Metadata
+
+	component := cdxComponent{
+		Type:    "library",
+		BOMRef:  nugetPURL(meta.ID, meta.Version),
+		Name:    meta.ID,
+		Version: meta.Version,
+		PURL:    nugetPURL(meta.ID, meta.Version),
+	}
+	if meta.License.Type == "expression" {
+		if parsed, err := parseLicenseExpression(meta.License.Value); err == nil {
+			component.Licenses = []cdxLicense{{Expression: parsed.String()}}
+		}
+	}
+	if hashPackage {
+		f, err := os.Open(path)
+		if err != nil {
+			return packageBOM{}, fmt.Errorf("failed to open package: %w", err)
+		}
+		component.Hashes, err = hashReader(f)
+		_ = f.Close()
+		if err != nil {
+			return packageBOM{}, fmt.Errorf("failed to hash package: %w", err)
+		}
+	}
+
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return packageBOM{}, fmt.Errorf("failed to open package: %w", err)
+	}
+	defer func() { _ = r.Close() }()
+	for _, f := range r.File {
+		if strings.HasSuffix(f.Name, "/") || opcEntries.MatchString(f.Name) || f.Name == sbomEntry {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return packageBOM{}, fmt.Errorf("failed to open %s: %w", f.Name, err)
+		}
+		hashes, err := hashReader(rc)
+		_ = rc.Close()
+		if err != nil {
+			return packageBOM{}, fmt.Errorf("failed to read %s: %w", f.Name, err)
+		}
+		component.Components = append(component.Components, cdxComponent{
+			Type:   "file",
+			BOMRef: component.BOMRef + "#" + f.Name,
+			Name:   f.Name,
+			Hashes: hashes,
+		})
+	}
+
+	return packageBOM{Component: component, Dependencies: nuspecDependencyComponents(meta.Dependencies)}, nil
+}
+
+// nuspecDependencyComponents converts nuspec dependencies into components,
+// one per package and minimum version, with the target frameworks and
+// version ranges they are declared for as properties.
+func nuspecDependencyComponents(deps nuspecDependencies) []cdxComponent {
+	groups := append([]nuspecDependencyGroup{{Dependencies: deps.Dependencies}}, deps.Groups...)
+	byRef := map[string]*cdxComponent{}
+	for _, group := range groups {
+		for _, dep := range group.Dependencies {
+			if dep.ID == "" {
+				continue
+			}
+			version := minimumVersion(dep.Version)
+			ref := nugetPURL(dep.ID, version)
+			c, ok := byRef[ref]
+			if !ok {
+				c = &cdxComponent{Type: "library", BOMRef: ref, Name: dep.ID, Version: version, Scope: "required", PURL: ref}
+				byRef[ref] = c
+			}
+			if dep.Version != "" {
+				c.addProperty("nuget:versionRange", strings.TrimSpace(dep.Version))
+			}
+			if group.TargetFramework != "" {
+				c.addProperty("nuget:targetFramework", normalizeFramework(group.TargetFramework))
+			}
+		}
+	}
+
+	components := make([]cdxComponent, 0, len(byRef))
+	for _, ref := range sortedSet(byRef) {
+		components = append(components, *byRef[ref])
+	}
+	return components
+}
+
+// addProperty adds a property unless the component already has it.
+func (c *cdxComponent) addProperty(name, value string) {
+	for _, p := range c.Properties {
+		if p.Name == name && p.Value == value {
+			return
+		}
+	}
+	c.Properties = append(c.Properties, cdxProperty{Name: name, Value: value})
+}
+
+// newBOM creates a BOM describing root, with a fresh serial number.
+func newBOM(info plugin.Info, root cdxComponent, now time.Time) (cdxBOM, error) {
+	var serial [16]byte
+	if _, err := rand.Read(serial[:]); err != nil {
+		return cdxBOM{}, fmt.Errorf("failed to generate SBOM serial number: %w", err)
+	}
+	serial[6] = serial[6]&0x0f | 0x40 // version 4
+	serial[8] = serial[8]&0x3f | 0x80 // RFC 4122 variant
+	s := hex.EncodeToString(serial[:])
+
+	return cdxBOM{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  cycloneDXSpecVersion,
+		SerialNumber: fmt.Sprintf("urn:uuid:%s-%s-%s-%s-%s", s[:8], s[8:12], s[12:16], s[16:20], s[20:]),
+		Version:      1,
+		Metadata: cdxMetadata{
+			Timestamp: now.UTC().Format(time.RFC3339),
+			Tools: cdxTools{Components: []cdxComponent{
+				{Type: "application", Name: "relicta-plugin-" + info.Name, Version: info.Version},
+			}},
+			Component: root,
+		},
+	}, nil
+}
+
+// addPackage adds the dependencies of a package to the BOM, once each.
+func (b *cdxBOM) addPackage(pkg packageBOM) {
+	refs := make([]string, 0, len(pkg.Dependencies))
+	for _, dep := range pkg.Dependencies {
+		refs = append(refs, dep.BOMRef)
+		if !b.hasDependency(dep.BOMRef) {
+			b.Components = append(b.Components, dep)
+			b.Dependencies = append(b.Dependencies, cdxDependency{Ref: dep.BOMRef})
+		}
+	}
+	b.Dependencies = append(b.Dependencies, cdxDependency{Ref: pkg.Component.BOMRef, DependsOn: refs})
+}
+
+// hasDependency reports whether the BOM already lists ref in its dependency graph.
+func (b *cdxBOM) hasDependency(ref string) bool {
+	for _, d := range b.Dependencies {
+		if d.Ref == ref {
+			return true
+		}
+	}
+	return false
+}
+
+// packageSBOM builds the SBOM of a single package.
+func packageSBOM(info plugin.Info, pkg packageBOM, now time.Time) (cdxBOM, error) {
+	bom, err := newBOM(info, pkg.Component, now)
+	if err != nil {
+		return cdxBOM{}, err
+	}
+	bom.addPackage(pkg)
+	return bom, nil
+}
+
+// releaseSBOM builds one SBOM for all packages of a release.
+func releaseSBOM(info plugin.Info, name, version string, pkgs []packageBOM, now time.Time) (cdxBOM, error) {
+	root := cdxComponent{Type: "application", BOMRef: name + "@" + version, Name: name, Version: version}
+	bom, err := newBOM(info, root, now)
+	if err != nil {
+		return cdxBOM{}, err
+	}
+
+	refs := make([]string, 0, len(pkgs))
+	for _, pkg := range pkgs {
+		refs = append(refs, pkg.Component.BOMRef)
+		bom.Components = append(bom.Components, pkg.Component)
+	}
+	bom.Dependencies = append(bom.Dependencies, cdxDependency{Ref: root.BOMRef, DependsOn: refs})
+	for _, pkg := range pkgs {
+		bom.addPackage(pkg)
+	}
+	return bom, nil
+}
+
+// writeSBOM writes a BOM as indented JSON.
+func writeSBOM(path string, bom cdxBOM) error {
+	data, err := json.MarshalIndent(bom, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode SBOM: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create SBOM directory: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write SBOM: %w", err)
+	}
+	return nil
+}
+
+// sbomDir returns the directory an SBOM is written to: sbom_dir, or the
+// directory of the package it describes.
+func sbomDir(cfg *Config, packagePath string) string {
+	if cfg.SBOMDir != "" {
+		return cfg.SBOMDir
+	}
+	return filepath.Dir(packagePath)
+}
+
+// generateSBOMs writes the CycloneDX SBOMs of every pending package, per
+// package or for the whole release, and records their paths on the results.
+// With embed_sbom, each package's SBOM is also added to a rewritten copy of
+// the package that is pushed instead; signed packages are pushed unchanged
+// with a note. The returned function removes the rewritten copies.
+func (p *NuGetPlugin) generateSBOMs(cfg *Config, releaseCtx plugin.ReleaseContext, results []PackageResult) (func(), error) {
+	cleanup := func() {}
+	var stageDir string
+	if cfg.EmbedSBOM {
+		dir, err := os.MkdirTemp("", "nuget-sbom-*")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create staging directory: %w", err)
+		}
+		stageDir = dir
+		cleanup = func() { _ = os.RemoveAll(dir) }
+	}
+
+	info := p.GetInfo()
+	now := time.Now()
+	var pkgs []packageBOM
+	var included []*PackageResult
+	for i := range results {
+		result := &results[i]
+		if result.Status != StatusNotAttempted || isSymbolsPackage(result.Path) {
+			continue
+		}
+		pkg, err := readPackageBOM(result.pushPath(), !cfg.EmbedSBOM)
+		if err != nil {
+			cleanup()
+			return nil, fmt.Errorf("failed to generate SBOM for %s: %w", packageLabel(*result), err)
+		}
+		pkgs = append(pkgs, pkg)
+		included = append(included, result)
+
+		if cfg.SBOM != SBOMPackage && !cfg.EmbedSBOM {
+			continue
+		}
+		bom, err := packageSBOM(info, pkg, now)
+		if err != nil {
+			cleanup()
+			return nil, err
+		}
+		if cfg.SBOM == SBOMPackage {
+			path := filepath.Join(sbomDir(cfg, result.Path), result.ID+"."+result.Version+".cdx.json")
+			if err := writeSBOM(path, bom); err != nil {
+				cleanup()
+				return nil, err
+			}
+			result.SBOM = path
+		}
+		if cfg.EmbedSBOM {
+			pkgDir, err := packageStagingDir(stageDir, i)
+			if err != nil {
+				cleanup()
+				return nil, err
+			}
+			staged, err := embedSBOM(result.pushPath(), pkgDir, bom)
+			if err != nil {
+				result.Message = joinReasons(result.Message, fmt.Sprintf("SBOM not embedded: %v", err))
+				continue
+			}
+			result.stagedPath = staged
+		}
+	}
+
+	if cfg.SBOM == SBOMRelease && len(included) > 0 {
+		name := releaseSBOMName(releaseCtx.RepositoryName)
+		if name == "" {
+			name = included[0].ID
+		}
+		version := strings.TrimPrefix(releaseCtx.Version, "v")
+		bom, err := releaseSBOM(info, name, version, pkgs, now)
+		if err != nil {
+			cleanup()
+			return nil, err
+		}
+		path := filepath.Join(sbomDir(cfg, included[0].Path), name+"."+version+".cdx.json")
+		if err := writeSBOM(path, bom); err != nil {
+			cleanup()
+			return nil, err
+		}
+		for _, result := range included {
+			result.SBOM = path
+		}
+	}
+	return cleanup, nil
+}
+
+// releaseSBOMName returns the last path segment of a repository name such as
+// "owner/repo", so it can name the release SBOM file and its root component.
+func releaseSBOMName(repository string) string {
+	name := repository[strings.LastIndexAny(repository, `/\`)+1:]
+	if name == "." || name == ".." {
+		return ""
+	}
+	return name
+}
+
+// embedSBOM writes a copy of the package into dir with the SBOM added at
+// sbomEntry, and returns the path of the copy. [Content_Types].xml gains a
+// default for .json files when it has none. Signed packages are refused
+// because adding a file would break the signature.
+func embedSBOM(path, dir string, bom cdxBOM) (string, error) {
+	signed, err := isSignedPackage(path)
+	if err != nil {
+		return "", err
+	}
+	if signed {
+		return "", fmt.Errorf("package is signed; embedding the SBOM would invalidate the signature")
+	}
+	data, err := json.MarshalIndent(bom, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode SBOM: %w", err)
+	}
+
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open package: %w", err)
+	}
+	defer func() { _ = r.Close() }()
+
+	out := filepath.Join(dir, filepath.Base(path))
+	f, err := os.Create(out)
+	if err != nil {
+		return "", fmt.Errorf("failed to create rewritten package: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	zw := zip.NewWriter(f)
+	for _, entry := range r.File {
+		switch entry.Name {
+		case sbomEntry:
+			continue
+		case "[Content_Types].xml":
+			types, err := readZipEntry(entry)
+			if err != nil {
+				return "", err
+			}
+			if err := writeZipFile(zw, entry.Name, addJSONContentType(types)); err != nil {
+				return "", err
+			}
+			continue
+		}
+		if err := copyZipEntry(zw, entry); err != nil {
+			return "", err
+		}
+	}
+	if err := writeZipFile(zw, sbomEntry, data); err != nil {
+		return "", err
+	}
+	if err := zw.Close(); err != nil {
+		return "", fmt.Errorf("failed to finalize rewritten package: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("failed to finalize rewritten package: %w", err)
+	}
+	return out, nil
+}
+
+// addJSONContentType adds a [Content_Types].xml default for .json files.
+func addJSONContentType(types []byte) []byte {
+	doc := string(types)
+	if contentTypesJSON.MatchString(doc) {
+		return types
+	}
+	i := strings.LastIndex(doc, "</Types>")
+	if i < 0 {
+		return types
+	}
+	return []byte(doc[:i] + `<Default Extension="json" ContentType="application/octet-stream" />` + doc[i:])
+}
+
+// writeZipFile adds a deflated file to an archive.
+func writeZipFile(zw *zip.Writer, name string, data []byte) error {
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// discardUnpushedSBOMs removes the SBOM files that describe a package that is
+// not on the feed after a failed push, and drops them from the results. A
+// release SBOM is removed when any of its packages is missing.
+func discardUnpushedSBOMs(results []PackageResult) {
+	unpushed := map[string]bool{}
+	for _, r := range results {
+		if r.SBOM != "" && r.Status != StatusPushed && r.Status != StatusSkipped {
+			unpushed[r.SBOM] = true
+		}
+	}
+	for i := range results {
+		if unpushed[results[i].SBOM] {
+			results[i].SBOM = ""
+		}
+	}
+	for _, path := range sortedSet(unpushed) {
+		_ = os.Remove(path)
+	}
+}
+
+// sbomPaths returns the distinct SBOM files written for the results, sorted.
+func sbomPaths(results []PackageResult) []string {
+	paths := map[string]bool{}
+	for _, r := range results {
+		if r.SBOM != "" {
+			paths[r.SBOM] = true
+		}
+	}
+	return sortedSet(paths)
+}
@@ -0,0 +1,347 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// sbomNuspec is a nuspec with grouped and ungrouped dependencies.
+const sbomNuspec = `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://schemas.microsoft.com/packaging/2013/05/nuspec.xsd">
+  <metadata>
+    <id>Contoso.Core</id>
+    <version>1.0.0</version>
+    <license type="expression">mit</license>
+    <dependencies>
+      <group targetFramework=".NETStandard2.0">
+        <dependency id="Newtonsoft.Json" version="[13.0.1, )" />
+        <dependency id="System.Memory" version="4.5.5" />
+      </group>
+      <group targetFramework="net8.0">
+        <dependency id="Newtonsoft.Json" version="[13.0.1, )" />
+      </group>
+    </dependencies>
+  </metadata>
+</package>`
+
+func TestMinimumVersion(t *testing.T) {
+	tests := map[string]string{
+		"1.0.0":          "1.0.0",
+		"[1.0.0]":        "1.0.0",
+		"[1.0.0, )":      "1.0.0",
+		"[1.0.0, 2.0.0)": "1.0.0",
+		"(, 2.0.0]":      "",
+		"":               "",
+	}
+	for in, want := range tests {
+		if got := minimumVersion(in); got != want {
+			t.Errorf("minimumVersion(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestReleaseSBOMName(t *testing.T) {
+	tests := []struct {
+		repository string
+		want       string
+	}{
+		{repository: "core", want: "core"},
+		{repository: "contoso/core", want: "core"},
+		{repository: `contoso\core`, want: "core"},
+		{repository: "contoso/..", want: ""},
+		{repository: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.repository, func(t *testing.T) {
+			if got := releaseSBOMName(tt.repository); got != tt.want {
+				t.Errorf("releaseSBOMName(%q) = %q, want %q", tt.repository, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddJSONContentType(t *testing.T) {
+	tests := []struct {
+		name  string
+		types string
+		want  string
+	}{
+		{name: "adds default", types: "<Types></Types>", want: `<Types><Default Extension="json" ContentType="application/octet-stream" /></Types>`},
+		{name: "keeps existing default", types: `<Types><Default Extension="json" ContentType="application/json" /></Types>`, want: `<Types><Default Extension="json" ContentType="application/json" /></Types>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(addJSONContentType([]byte(tt.types))); got != tt.want {
+				t.Errorf("addJSONContentType() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadPackageBOM(t *testing.T) {
+	path := writeTestPackageWithNuspec(t, t.TempDir(), "Contoso.Core", "1.0.0", sbomNuspec, map[string]string{
+		"lib/net8.0/Contoso.Core.dll": "dll",
+		"[Content_Types].xml":         "<Types/>",
+		"_rels/.rels":                 "<Relationships/>",
+	})
+
+	pkg, err := readPackageBOM(path, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	c := pkg.Component
+	if c.BOMRef != "pkg:nuget/Contoso.Core@1.0.0" || c.PURL != c.BOMRef || len(c.Hashes) != 2 ||
+		!reflect.DeepEqual(c.Licenses, []cdxLicense{{Expression: "MIT"}}) {
+		t.Errorf("unexpected package component: %+v", c)
+	}
+	var files []string
+	for _, f := range c.Components {
+		files = append(files, f.Name)
+	}
+	if want := []string{"Contoso.Core.nuspec", "lib/net8.0/Contoso.Core.dll"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("files = %v, want %v", files, want)
+	}
+	sum := sha256.Sum256([]byte("dll"))
+	if got := c.Components[1].Hashes[0]; got.Alg != "SHA-256" || got.Content != hex.EncodeToString(sum[:]) {
+		t.Errorf("unexpected file hash: %+v", got)
+	}
+
+	want := []cdxComponent{
+		{
+			Type: "library", BOMRef: "pkg:nuget/Newtonsoft.Json@13.0.1", Name: "Newtonsoft.Json", Version: "13.0.1",
+			Scope: "required", PURL: "pkg:nuget/Newtonsoft.Json@13.0.1",
+			Properties: []cdxProperty{
+				{Name: "nuget:versionRange", Value: "[13.0.1, )"},
+				{Name: "nuget:targetFramework", Value: "netstandard2.0"},
+				{Name: "nuget:targetFramework", Value: "net8.0"},
+			},
+		},
+		{
+			Type: "library", BOMRef: "pkg:nuget/System.Memory@4.5.5", Name: "System.Memory", Version: "4.5.5",
+			Scope: "required", PURL: "pkg:nuget/System.Memory@4.5.5",
+			Properties: []cdxProperty{
+				{Name: "nuget:versionRange", Value: "4.5.5"},
+				{Name: "nuget:targetFramework", Value: "netstandard2.0"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(pkg.Dependencies, want) {
+		t.Errorf("dependencies = %+v, want %+v", pkg.Dependencies, want)
+	}
+
+	unhashed, err := readPackageBOM(path, false)
+	if err != nil || len(unhashed.Component.Hashes) != 0 {
+		t.Errorf("expected no package hash, got %+v (%v)", unhashed.Component.Hashes, err)
+	}
+}
+
+// readTestSBOM decodes an SBOM file.
+func readTestSBOM(t *testing.T, path string) cdxBOM {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read SBOM: %v", err)
+	}
+	var bom cdxBOM
+	if err := json.Unmarshal(data, &bom); err != nil {
+		t.Fatalf("failed to decode SBOM: %v", err)
+	}
+	if bom.BOMFormat != "CycloneDX" || bom.SpecVersion != cycloneDXSpecVersion || !strings.HasPrefix(bom.SerialNumber, "urn:uuid:") {
+		t.Errorf("unexpected SBOM header: %+v", bom)
+	}
+	return bom
+}
+
+func TestExecuteSBOM(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    map[string]any
+		wantFiles []string
+		wantEmbed bool
+	}{
+		{name: "per package", config: map[string]any{"sbom": "package"}, wantFiles: []string{"Contoso.Core.1.0.0.cdx.json", "Contoso.Data.1.0.0.cdx.json"}},
+		{name: "per release", config: map[string]any{"sbom": "release"}, wantFiles: []string{"core.1.0.0.cdx.json"}},
+		{name: "embedded", config: map[string]any{"sbom": "package", "embed_sbom": true}, wantFiles: []string{"Contoso.Core.1.0.0.cdx.json", "Contoso.Data.1.0.0.cdx.json"}, wantEmbed: true},
+		{name: "off", config: map[string]any{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTestPackageWithNuspec(t, dir, "Contoso.Core", "1.0.0", sbomNuspec, map[string]string{"lib/net8.0/Contoso.Core.dll": "dll"})
+			writeTestPackage(t, dir, "Contoso.Data", "1.0.0", map[string]string{"lib/net8.0/Contoso.Data.dll": "dll"})
+			sbomDir := filepath.Join(t.TempDir(), "sbom")
+
+			embedded := map[string]bool{}
+			mockExec := &MockCommandExecutor{
+				RunFunc: func(_ context.Context, _ string, args ...string) ([]byte, error) {
+					r, err := zip.OpenReader(args[2])
+					if err != nil {
+						return nil, err
+					}
+					defer func() { _ = r.Close() }()
+					for _, f := range r.File {
+						if f.Name == sbomEntry {
+							embedded[filepath.Base(args[2])] = true
+						}
+					}
+					return []byte("Your package was pushed."), nil
+				},
+			}
+			config := map[string]any{
+				"api_key":      "test-key",
+				"source":       "https://127.0.0.1/v3/index.json",
+				"package_path": filepath.Join(dir, "*.nupkg"),
+				"sbom_dir":     sbomDir,
+			}
+			for k, v := range tt.config {
+				config[k] = v
+			}
+			p := &NuGetPlugin{cmdExecutor: mockExec}
+			resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+				Hook:    plugin.HookPostPublish,
+				Config:  config,
+				Context: plugin.ReleaseContext{Version: "v1.0.0", RepositoryName: "contoso/core"},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !resp.Success {
+				t.Fatalf("expected success, got: %s", resp.Error)
+			}
+
+			var want []string
+			for _, name := range tt.wantFiles {
+				want = append(want, filepath.Join(sbomDir, name))
+			}
+			if got := resp.Outputs["sboms"].([]string); strings.Join(got, ",") != strings.Join(want, ",") {
+				t.Fatalf("sboms = %v, want %v", got, want)
+			}
+			if tt.wantEmbed != (len(embedded) == 2) {
+				t.Errorf("embedded SBOMs in %v, want embedded=%v", embedded, tt.wantEmbed)
+			}
+			if len(want) == 0 {
+				return
+			}
+
+			bom := readTestSBOM(t, want[0])
+			if tt.config["sbom"] == "release" {
+				if bom.Metadata.Component.Name != "core" || len(bom.Components) != 4 ||
+					!reflect.DeepEqual(bom.Dependencies[0], cdxDependency{Ref: "core@1.0.0", DependsOn: []string{"pkg:nuget/Contoso.Core@1.0.0", "pkg:nuget/Contoso.Data@1.0.0"}}) {
+					t.Errorf("unexpected release SBOM: %+v", bom)
+				}
+				return
+			}
+			if bom.Metadata.Component.BOMRef != "pkg:nuget/Contoso.Core@1.0.0" || len(bom.Components) != 2 {
+				t.Errorf("unexpected package SBOM: %+v", bom)
+			}
+			if hashed := len(bom.Metadata.Component.Hashes) > 0; hashed == tt.wantEmbed {
+				t.Errorf("package hash present = %v, want %v", hashed, !tt.wantEmbed)
+			}
+		})
+	}
+}
+
+func TestEmbedSBOMSameFileName(t *testing.T) {
+	results := []PackageResult{
+		{Status: StatusNotAttempted, ID: "Contoso.Core", Version: "1.0.0", Path: writeTestPackage(t, t.TempDir(), "Contoso.Core", "1.0.0", map[string]string{"a.txt": "a"})},
+		{Status: StatusNotAttempted, ID: "Contoso.Core", Version: "1.0.0", Path: writeTestPackage(t, t.TempDir(), "Contoso.Core", "1.0.0", map[string]string{"b.txt": "b"})},
+	}
+
+	cleanup, err := (&NuGetPlugin{}).generateSBOMs(&Config{SBOM: SBOMPackage, EmbedSBOM: true}, plugin.ReleaseContext{Version: "v1.0.0"}, results)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer cleanup()
+
+	if results[0].stagedPath == "" || results[0].stagedPath == results[1].stagedPath {
+		t.Fatalf("expected separate staged copies, got %q and %q", results[0].stagedPath, results[1].stagedPath)
+	}
+	for i, want := range []string{"a.txt", "b.txt"} {
+		pkg, err := readPackageBOM(results[i].stagedPath, false)
+		if err != nil {
+			t.Fatalf("failed to read staged package: %v", err)
+		}
+		found := false
+		for _, f := range pkg.Component.Components {
+			found = found || f.Name == want
+		}
+		if !found {
+			t.Errorf("expected staged copy of package %d to contain %s", i, want)
+		}
+	}
+}
+
+func TestExecuteSBOMPushFailure(t *testing.T) {
+	tests := []struct {
+		name      string
+		sbom      string
+		wantFiles []string
+	}{
+		{name: "per package", sbom: SBOMPackage, wantFiles: []string{"Contoso.Core.1.0.0.cdx.json"}},
+		{name: "per release", sbom: SBOMRelease},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTestPackage(t, dir, "Contoso.Core", "1.0.0", nil)
+			writeTestPackage(t, dir, "Contoso.Data", "1.0.0", nil)
+			sbomDir := filepath.Join(t.TempDir(), "sbom")
+
+			mockExec := &MockCommandExecutor{
+				RunFunc: func(_ context.Context, _ string, args ...string) ([]byte, error) {
+					if strings.Contains(args[2], "Contoso.Data") {
+						return []byte("Response status code does not indicate success: 401 (Unauthorized)."), errors.New("exit status 1")
+					}
+					return []byte("Your package was pushed."), nil
+				},
+			}
+			p := &NuGetPlugin{cmdExecutor: mockExec}
+			resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+				Hook: plugin.HookPostPublish,
+				Config: map[string]any{
+					"api_key":      "test-key",
+					"source":       "https://127.0.0.1/v3/index.json",
+					"package_path": filepath.Join(dir, "*.nupkg"),
+					"sbom":         tt.sbom,
+					"sbom_dir":     sbomDir,
+					"retries":      0,
+				},
+				Context: plugin.ReleaseContext{Version: "v1.0.0", RepositoryName: "contoso/core"},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resp.Success {
+				t.Fatal("expected the push to fail")
+			}
+
+			var want []string
+			for _, name := range tt.wantFiles {
+				want = append(want, filepath.Join(sbomDir, name))
+			}
+			if got := resp.Outputs["sboms"].([]string); strings.Join(got, ",") != strings.Join(want, ",") {
+				t.Errorf("sboms = %v, want %v", got, want)
+			}
+			written, _ := filepath.Glob(filepath.Join(sbomDir, "*.cdx.json"))
+			if strings.Join(written, ",") != strings.Join(want, ",") {
+				t.Errorf("SBOM files on disk = %v, want %v", written, want)
+			}
+		})
+	}
+}
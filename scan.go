@@ -0,0 +1,219 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// DefaultForbiddenFiles are path globs that should never ship in a package.
+var DefaultForbiddenFiles = []string{"*.pdb", "*.pfx", "*.snk", ".env", "appsettings.*.json"}
+
+// maxScannedFileSize bounds how much of a file is searched for secrets.
+const maxScannedFileSize = 5 << 20
+
+// Kinds of content findings.
+const (
+	findingForbiddenFile = "forbidden_file"
+	findingSecret        = "secret"
+)
+
+// SecretDetector is a named regular expression that matches a secret.
+type SecretDetector struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// defaultSecretDetectors match common credentials and connection string secrets.
+var defaultSecretDetectors = []SecretDetector{
+	{Name: "private key", Pattern: regexp.MustCompile(`-----BEGIN (?:RSA |EC |DSA |OPENSSH |ENCRYPTED )?PRIVATE KEY-----`)},
+	{Name: "AWS access key", Pattern: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{Name: "GitHub token", Pattern: regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`)},
+	{Name: "NuGet API key", Pattern: regexp.MustCompile(`\boy2[a-z0-9]{43}\b`)},
+	{Name: "Azure storage account key", Pattern: regexp.MustCompile(`(?i)AccountKey=[A-Za-z0-9+/]{40,}={0,2}`)},
+	{Name: "connection string password", Pattern: regexp.MustCompile(`(?i)\b(?:password|pwd)\s*=\s*[^;'"\s<>{}$]{4,}`)},
+}
+
+// ContentFinding is a forbidden file or a secret found inside a package.
+// Secrets are reported by detector and line only; the matched text is never included.
+type ContentFinding struct {
+	File string `json:"file"`
+	Kind string `json:"kind"`
+	Rule string `json:"rule"`
+	Line int    `json:"line,omitempty"`
+}
+
+// String describes the finding in one line.
+func (f ContentFinding) String() string {
+	if f.Kind == findingSecret {
+		return fmt.Sprintf("%s:%d contains a %s", f.File, f.Line, f.Rule)
+	}
+	return fmt.Sprintf("%s matches forbidden pattern %s", f.File, f.Rule)
+}
+
+// parseSecretPatterns reads the secret_patterns config list of {name, pattern} entries.
+// Malformed entries and invalid expressions are returned as problems for validation.
+func parseSecretPatterns(raw any) ([]SecretDetector, []string) {
+	if raw == nil {
+		return nil, nil
+	}
+	list, ok := raw.([]any)
+	if !ok {
+		return nil, []string{"must be a list of {name, pattern} entries"}
+	}
+
+	var detectors []SecretDetector
+	var problems []string
+	for i, item := range list {
+		entry, ok := item.(map[string]any)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("entry %d must be an object with name and pattern", i))
+			continue
+		}
+		name, _ := entry["name"].(string)
+		expr, _ := entry["pattern"].(string)
+		if strings.TrimSpace(name) == "" || expr == "" {
+			problems = append(problems, fmt.Sprintf("entry %d requires name and pattern", i))
+			continue
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("entry %d has an invalid pattern: %v", i, err))
+			continue
+		}
+		detectors = append(detectors, SecretDetector{Name: strings.TrimSpace(name), Pattern: re})
+	}
+	return detectors, problems
+}
+
+// isSymbolsPackage reports whether a package file is a symbols package, which
+// is expected to contain .pdb files.
+func isSymbolsPackage(packagePath string) bool {
+	lower := strings.ToLower(packagePath)
+	return strings.HasSuffix(lower, ".snupkg") || strings.HasSuffix(lower, ".symbols.nupkg")
+}
+
+// matchForbidden returns the first forbidden glob matching a package entry.
+// Globs without a slash match the file name in any folder; globs with a slash
+// match the full entry path.
+func matchForbidden(globs []string, name string, symbols bool) (string, bool) {
+	base := path.Base(name)
+	for _, glob := range globs {
+		if symbols && strings.EqualFold(path.Ext(glob), ".pdb") {
+			continue
+		}
+		target := base
+		if strings.Contains(glob, "/") {
+			target = name
+		}
+		if ok, _ := path.Match(strings.ToLower(glob), strings.ToLower(target)); ok {
+			return glob, true
+		}
+	}
+	return "", false
+}
+
+// scanPackage walks a package and reports forbidden files and secrets in text files.
+func scanPackage(packagePath string, forbidden []string, detectors []SecretDetector) ([]ContentFinding, error) {
+	r, err := zip.OpenReader(packagePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open package: %w", err)
+	}
+	defer func() { _ = r.Close() }()
+
+	symbols := isSymbolsPackage(packagePath)
+	var findings []ContentFinding
+	for _, f := range r.File {
+		if strings.HasSuffix(f.Name, "/") || f.Name == signatureEntry {
+			continue
+		}
+		if glob, ok := matchForbidden(forbidden, f.Name, symbols); ok {
+			findings = append(findings, ContentFinding{File: f.Name, Kind: findingForbiddenFile, Rule: glob})
+		}
+		if len(detectors) == 0 {
+			continue
+		}
+
+		found, err := scanEntry(f, detectors)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", f.Name, err)
+		}
+		findings = append(findings, found...)
+	}
+	return findings, nil
+}
+
+// scanEntry searches a text entry line by line. Binary entries, recognized by a
+// NUL byte near the start, are skipped.
+func scanEntry(f *zip.File, detectors []SecretDetector) ([]ContentFinding, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rc.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(rc, maxScannedFileSize))
+	if err != nil {
+		return nil, err
+	}
+	if bytes.IndexByte(data[:min(len(data), 8<<10)], 0) >= 0 {
+		return nil, nil
+	}
+
+	var findings []ContentFinding
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64<<10), maxScannedFileSize)
+	for line := 1; scanner.Scan(); line++ {
+		for _, d := range detectors {
+			if d.Pattern.Match(scanner.Bytes()) {
+				findings = append(findings, ContentFinding{File: f.Name, Kind: findingSecret, Rule: d.Name, Line: line})
+			}
+		}
+	}
+	return findings, scanner.Err()
+}
+
+// scanContents scans every pending package, records its findings and fails
+// packages with findings (would-fail in a dry run), returning them.
+func scanContents(cfg *Config, results []PackageResult, dryRun bool) []string {
+	if !cfg.ScanContent {
+		return nil
+	}
+	detectors := append(append([]SecretDetector{}, defaultSecretDetectors...), cfg.SecretPatterns...)
+
+	var flagged []string
+	for i := range results {
+		result := &results[i]
+		if result.Status != StatusNotAttempted {
+			continue
+		}
+		findings, err := scanPackage(result.Path, cfg.ForbiddenFiles, detectors)
+		if err != nil {
+			// Unreadable archives are left for dotnet and the feed to reject.
+			result.Message = joinReasons(result.Message, "content scan skipped: "+err.Error())
+			continue
+		}
+		result.Findings = findings
+		if len(findings) == 0 {
+			continue
+		}
+
+		descriptions := make([]string, 0, len(findings))
+		for _, f := range findings {
+			descriptions = append(descriptions, f.String())
+		}
+		msg := "package contains forbidden content: " + strings.Join(descriptions, "; ")
+		result.Status = StatusFailed
+		if dryRun {
+			result.Status = StatusWouldFail
+		}
+		result.setError(ErrorKindForbiddenContent, msg)
+		flagged = append(flagged, packageLabel(*result))
+	}
+	return flagged
+}
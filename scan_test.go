@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestMatchForbidden(t *testing.T) {
+	tests := []struct {
+		name    string
+		symbols bool
+		want    string
+	}{
+		{name: "lib/net8.0/Contoso.Core.pdb", want: "*.pdb"},
+		{name: "lib/net8.0/Contoso.Core.pdb", symbols: true},
+		{name: "lib/net8.0/Contoso.Core.dll"},
+		{name: "content/signing.PFX", want: "*.pfx"},
+		{name: "contentFiles/any/any/.env", want: ".env"},
+		{name: "contentFiles/any/any/.env.example"},
+		{name: "content/appsettings.Development.json", want: "appsettings.*.json"},
+		{name: "content/appsettings.json"},
+		{name: "build/secrets/key.txt", want: "build/secrets/*"},
+	}
+
+	globs := append(append([]string{}, DefaultForbiddenFiles...), "build/secrets/*")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := matchForbidden(globs, tt.name, tt.symbols)
+			if got != tt.want || ok != (tt.want != "") {
+				t.Errorf("matchForbidden(%q) = %q, %v; want %q", tt.name, got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanPackage(t *testing.T) {
+	dir := t.TempDir()
+	pkg := writeTestPackage(t, dir, "Contoso.Core", "1.0.0", map[string]string{
+		"lib/net8.0/Contoso.Core.dll":          "MZ\x00\x00Password=ignored-in-binaries",
+		"lib/net8.0/Contoso.Core.pdb":          "pdb",
+		"content/appsettings.json":             `{"Logging": {"LogLevel": "Information"}}`,
+		"content/appsettings.Development.json": "{\n  \"Db\": \"Server=db;User Id=sa;Password=hunter22;\"\n}",
+		"tools/deploy.ps1":                     "# deploy\n$key = 'oy2abcdefghijklmnopqrstuvwxyz0123456789abcdefg'\n$host = 'build.corp.internal'",
+	})
+
+	custom := []SecretDetector{{Name: "internal host", Pattern: regexp.MustCompile(`\.corp\.internal\b`)}}
+	findings, err := scanPackage(pkg, DefaultForbiddenFiles, append(append([]SecretDetector{}, defaultSecretDetectors...), custom...))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []string
+	for _, f := range findings {
+		got = append(got, f.String())
+	}
+	want := []string{
+		"content/appsettings.Development.json matches forbidden pattern appsettings.*.json",
+		"content/appsettings.Development.json:2 contains a connection string password",
+		"lib/net8.0/Contoso.Core.pdb matches forbidden pattern *.pdb",
+		"tools/deploy.ps1:2 contains a NuGet API key",
+		"tools/deploy.ps1:3 contains a internal host",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected findings:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	for _, f := range findings {
+		if strings.Contains(f.String(), "hunter22") {
+			t.Error("expected secrets not to be included in the report")
+		}
+	}
+
+	symbols := filepath.Join(dir, "Contoso.Core.1.0.0.snupkg")
+	if err := os.Rename(writeTestPackage(t, t.TempDir(), "Contoso.Core", "1.0.0", map[string]string{"lib/net8.0/Contoso.Core.pdb": "pdb"}), symbols); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if findings, err := scanPackage(symbols, DefaultForbiddenFiles, defaultSecretDetectors); err != nil || len(findings) != 0 {
+		t.Errorf("expected symbols packages to allow .pdb files, got %v (%v)", findings, err)
+	}
+}
+
+func TestExecuteContentScan(t *testing.T) {
+	tmpDir := t.TempDir()
+	pkg := writeTestPackage(t, tmpDir, "Contoso.Core", "1.0.0", map[string]string{
+		"content/appsettings.Development.json": `{"Db": "Server=db;Password=hunter22"}`,
+	})
+
+	run := func(config map[string]any) (*plugin.ExecuteResponse, *MockCommandExecutor) {
+		t.Helper()
+		mockExec := &MockCommandExecutor{}
+		config["api_key"] = "test-key"
+		config["source"] = "https://127.0.0.1/v3/index.json"
+		config["package_path"] = pkg
+		resp, err := (&NuGetPlugin{cmdExecutor: mockExec}).Execute(context.Background(), plugin.ExecuteRequest{
+			Hook:    plugin.HookPostPublish,
+			Config:  config,
+			Context: plugin.ReleaseContext{Version: "v1.0.0"},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return resp, mockExec
+	}
+
+	resp, mockExec := run(map[string]any{"scan_content": true})
+	if resp.Success || !strings.Contains(resp.Error, "content scan flagged package(s): Contoso.Core 1.0.0") {
+		t.Errorf("expected the content scan to fail the push, got success=%v error=%s", resp.Success, resp.Error)
+	}
+	if len(mockExec.Calls) != 0 {
+		t.Errorf("expected nothing to be pushed, got %d pushes", len(mockExec.Calls))
+	}
+	result := resp.Outputs["packages"].([]PackageResult)[0]
+	if len(result.Findings) != 2 || result.ErrorClass != string(ErrorKindForbiddenContent) {
+		t.Errorf("expected a per-file report, got %+v", result)
+	}
+
+	resp, mockExec = run(map[string]any{})
+	if !resp.Success || len(mockExec.Calls) != 1 {
+		t.Errorf("expected the push to proceed without scan_content, got success=%v error=%s", resp.Success, resp.Error)
+	}
+}
+
+func TestParseSecretPatterns(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     any
+		want    int
+		wantErr string
+	}{
+		{name: "valid", raw: []any{map[string]any{"name": "internal host", "pattern": `\.corp\.internal`}}, want: 1},
+		{name: "invalid regex", raw: []any{map[string]any{"name": "broken", "pattern": `(`}}, wantErr: "invalid pattern"},
+		{name: "missing name", raw: []any{map[string]any{"pattern": `x`}}, wantErr: "requires name and pattern"},
+		{name: "not a list", raw: "x", wantErr: "must be a list"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detectors, problems := parseSecretPatterns(tt.raw)
+			if len(detectors) != tt.want {
+				t.Errorf("expected %d detector(s), got %d", tt.want, len(detectors))
+			}
+			if !strings.Contains(strings.Join(problems, "; "), tt.wantErr) || (tt.wantErr == "" && len(problems) > 0) {
+				t.Errorf("expected problem %q, got %v", tt.wantErr, problems)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Known upload limits of feeds, in bytes.
+const (
+	// NuGetOrgMaxPackageSize is nuget.org's upload limit.
+	NuGetOrgMaxPackageSize = 250 << 20
+	// AzureArtifactsMaxPackageSize is Azure Artifacts' NuGet upload limit.
+	AzureArtifactsMaxPackageSize = 500 << 20
+	// MaxDownloadSize bounds package downloads from feeds without a known upload limit.
+	MaxDownloadSize = 1 << 30
+)
+
+// largestFilesReported is how many of a package's largest files are reported.
+const largestFilesReported = 5
+
+// PackageFile is a file inside a package, reported to explain its size.
+type PackageFile struct {
+	Name           string `json:"name"`
+	SizeBytes      int64  `json:"size_bytes"`
+	CompressedSize int64  `json:"compressed_bytes"`
+}
+
+// sizeUnits maps size suffixes to their multipliers.
+var sizeUnits = []struct {
+	suffix string
+	scale  float64
+}{
+	{"KIB", 1 << 10}, {"MIB", 1 << 20}, {"GIB", 1 << 30},
+	{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30},
+	{"K", 1 << 10}, {"M", 1 << 20}, {"G", 1 << 30},
+	{"B", 1},
+}
+
+// parsePackageSize reads max_package_size: a number of bytes, or a string such
+// as "100MB" or "1.5GiB" (units are binary). Zero disables the limit; an unset
+// value returns -1 so the feed's default applies.
+func parsePackageSize(raw any) (int64, error) {
+	switch v := raw.(type) {
+	case nil:
+		return -1, nil
+	case int:
+		return nonNegativeSize(float64(v))
+	case int64:
+		return nonNegativeSize(float64(v))
+	case float64:
+		return nonNegativeSize(v)
+	case string:
+		s := strings.ToUpper(strings.TrimSpace(v))
+		scale := 1.0
+		for _, unit := range sizeUnits {
+			if strings.HasSuffix(s, unit.suffix) {
+				s, scale = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix)), unit.scale
+				break
+			}
+		}
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid size %q (use bytes or a value such as 100MB)", v)
+		}
+		return nonNegativeSize(n * scale)
+	default:
+		return 0, fmt.Errorf("must be a number of bytes or a size such as 100MB")
+	}
+}
+
+// nonNegativeSize converts a size to whole bytes, rejecting negative values.
+func nonNegativeSize(n float64) (int64, error) {
+	if n < 0 || math.IsNaN(n) || math.IsInf(n, 0) {
+		return 0, fmt.Errorf("cannot be negative")
+	}
+	return int64(n), nil
+}
+
+// packageSizeLimit returns the effective upload limit and where it comes from.
+// A limit of zero means packages are not checked.
+func (c *Config) packageSizeLimit() (int64, string) {
+	if c.MaxPackageSize >= 0 {
+		return c.MaxPackageSize, "max_package_size"
+	}
+	switch c.feedKind() {
+	case feedKindNuGetOrg:
+		return NuGetOrgMaxPackageSize, "nuget.org's upload limit"
+	case feedKindAzure:
+		return AzureArtifactsMaxPackageSize, "Azure Artifacts' upload limit"
+	default:
+		return 0, ""
+	}
+}
+
+// downloadLimit returns the largest package download accepted from a feed:
+// a package over the upload limit cannot be pushed, so it is not downloaded.
+func (c *Config) downloadLimit() int64 {
+	if limit, _ := c.packageSizeLimit(); limit > 0 {
+		return limit
+	}
+	return MaxDownloadSize
+}
+
+// largestFiles returns the largest files in a package by uncompressed size.
+func largestFiles(path string, n int) ([]PackageFile, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open package: %w", err)
+	}
+	defer func() { _ = r.Close() }()
+
+	files := make([]PackageFile, 0, len(r.File))
+	for _, f := range r.File {
+		if strings.HasSuffix(f.Name, "/") {
+			continue
+		}
+		files = append(files, PackageFile{Name: f.Name, SizeBytes: int64(f.UncompressedSize64), CompressedSize: int64(f.CompressedSize64)})
+	}
+	sort.SliceStable(files, func(i, j int) bool { return files[i].SizeBytes > files[j].SizeBytes })
+	if len(files) > n {
+		files = files[:n]
+	}
+	return files, nil
+}
+
+// oversizedDetails describes every package that failed the size check.
+func oversizedDetails(results []PackageResult) string {
+	var details []string
+	for _, r := range results {
+		if r.ErrorClass == string(ErrorKindTooLarge) {
+			details = append(details, packageLabel(r)+": "+r.Error)
+		}
+	}
+	return strings.Join(details, "; ")
+}
+
+// checkPackageSizes records the largest files of each package and fails
+// packages over the upload limit (would-fail in a dry run), returning them.
+func checkPackageSizes(cfg *Config, results []PackageResult, dryRun bool) []string {
+	limit, origin := cfg.packageSizeLimit()
+
+	var oversized []string
+	for i := range results {
+		result := &results[i]
+		result.LargestFiles, _ = largestFiles(result.Path, largestFilesReported)
+
+		if limit <= 0 || result.SizeBytes <= limit || result.Status != StatusNotAttempted {
+			continue
+		}
+		msg := fmt.Sprintf("package is %s, over the %s limit (%s)", formatSize(result.SizeBytes), formatSize(limit), origin)
+		if len(result.LargestFiles) > 0 {
+			largest := result.LargestFiles[0]
+			msg += fmt.Sprintf("; largest file is %s (%s)", largest.Name, formatSize(largest.SizeBytes))
+		}
+		result.Status = StatusFailed
+		if dryRun {
+			result.Status = StatusWouldFail
+		}
+		result.setError(ErrorKindTooLarge, msg)
+		oversized = append(oversized, packageLabel(*result))
+	}
+	return oversized
+}
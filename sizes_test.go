@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestParsePackageSize(t *testing.T) {
+	tests := []struct {
+		raw     any
+		want    int64
+		wantErr bool
+	}{
+		{raw: nil, want: -1},
+		{raw: 0, want: 0},
+		{raw: 1048576, want: 1 << 20},
+		{raw: float64(2048), want: 2048},
+		{raw: "100MB", want: 100 << 20},
+		{raw: "1.5 GiB", want: 3 << 29},
+		{raw: "512k", want: 512 << 10},
+		{raw: "300", want: 300},
+		{raw: "-1MB", wantErr: true},
+		{raw: "lots", wantErr: true},
+		{raw: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parsePackageSize(tt.raw)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parsePackageSize(%v): expected error, got %d", tt.raw, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("parsePackageSize(%v) = %d, %v; want %d", tt.raw, got, err, tt.want)
+		}
+	}
+}
+
+func TestPackageSizeLimit(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want int64
+	}{
+		{name: "nuget.org default", cfg: Config{Source: DefaultSource, MaxPackageSize: -1}, want: NuGetOrgMaxPackageSize},
+		{name: "azure default", cfg: Config{Target: TargetAzureArtifacts, MaxPackageSize: -1}, want: AzureArtifactsMaxPackageSize},
+		{name: "unknown feed", cfg: Config{Source: "https://nuget.example.com/v3/index.json", MaxPackageSize: -1}, want: 0},
+		{name: "configured", cfg: Config{Source: DefaultSource, MaxPackageSize: 10 << 20}, want: 10 << 20},
+		{name: "disabled", cfg: Config{Source: DefaultSource, MaxPackageSize: 0}, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := tt.cfg.packageSizeLimit(); got != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestExecuteRejectsOversizedPackages(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTestPackage(t, tmpDir, "Contoso.Small", "1.0.0", nil)
+	// Random content keeps the package large after compression.
+	blob := make([]byte, 8<<10)
+	_, _ = rand.New(rand.NewSource(1)).Read(blob)
+	writeTestPackage(t, tmpDir, "Contoso.Large", "1.0.0", map[string]string{
+		"tools/blob.bin": string(blob),
+		"readme.md":      "small",
+	})
+
+	run := func(hook plugin.Hook, dryRun bool) (*plugin.ExecuteResponse, *MockCommandExecutor) {
+		t.Helper()
+		mockExec := &MockCommandExecutor{}
+		p := &NuGetPlugin{cmdExecutor: mockExec}
+		resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+			Hook: hook,
+			Config: map[string]any{
+				"api_key":          "test-key",
+				"source":           "https://127.0.0.1/v3/index.json",
+				"package_path":     tmpDir + "/*.nupkg",
+				"max_package_size": "2KB",
+				"phases":           map[string]any{"preflight": true},
+			},
+			Context: plugin.ReleaseContext{Version: "v1.0.0"},
+			DryRun:  dryRun,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return resp, mockExec
+	}
+
+	resp, mockExec := run(plugin.HookPostPublish, false)
+	if resp.Success || !strings.Contains(resp.Error, "Contoso.Large 1.0.0: package is") || !strings.Contains(resp.Error, "largest file is tools/blob.bin") {
+		t.Errorf("expected the oversized package to be rejected, got success=%v error=%s", resp.Success, resp.Error)
+	}
+	if len(mockExec.Calls) != 0 {
+		t.Errorf("expected nothing to be pushed, got %d pushes", len(mockExec.Calls))
+	}
+	for _, r := range resp.Outputs["packages"].([]PackageResult) {
+		if len(r.LargestFiles) == 0 {
+			t.Errorf("expected largest files for %s", r.ID)
+			continue
+		}
+		if r.ID != "Contoso.Large" {
+			continue
+		}
+		if r.LargestFiles[0].Name != "tools/blob.bin" || r.LargestFiles[0].SizeBytes != 8<<10 {
+			t.Errorf("expected tools/blob.bin to be the largest file, got %+v", r.LargestFiles)
+		}
+		if r.Status != StatusFailed || r.ErrorClass != string(ErrorKindTooLarge) {
+			t.Errorf("expected a too_large failure, got %+v", r)
+		}
+	}
+
+	resp, _ = run(plugin.HookPreInit, false)
+	if resp.Success || !strings.Contains(resp.Error, "preflight: package(s) too large to push") {
+		t.Errorf("expected preflight to reject the oversized package, got success=%v error=%s", resp.Success, resp.Error)
+	}
+}
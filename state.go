@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultStateFile is the default location of the push state file, relative to the workspace.
+const DefaultStateFile = ".relicta/nuget-push-state.json"
+
+// pushState records which packages were confirmed pushed, per release version and feed,
+// so an interrupted release can be resumed without pushing everything again.
+type pushState struct {
+	path string
+
+	Releases map[string]*releaseState `json:"releases"`
+}
+
+// releaseState holds the pushed packages of one release version, keyed by feed.
+type releaseState struct {
+	Feeds map[string]map[string]pushedPackage `json:"feeds"`
+}
+
+// pushedPackage is a package the feed confirmed for a release.
+type pushedPackage struct {
+	Path     string        `json:"path"`
+	SHA512   string        `json:"sha512"`
+	Status   PackageStatus `json:"status"`
+	PushedAt time.Time     `json:"pushed_at"`
+}
+
+// loadPushState reads the state file, returning an empty state if it does not exist.
+func loadPushState(path string) (*pushState, error) {
+	state := &pushState{path: path, Releases: map[string]*releaseState{}}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse state file %s: %w", path, err)
+	}
+	if state.Releases == nil {
+		state.Releases = map[string]*releaseState{}
+	}
+	return state, nil
+}
+
+// reset forgets everything recorded for a release version.
+func (s *pushState) reset(version string) {
+	delete(s.Releases, comparableVersion(version))
+}
+
+// lookup returns the recorded push of a package to a feed for a release, if any.
+func (s *pushState) lookup(version, feed, key string) (pushedPackage, bool) {
+	release, ok := s.Releases[comparableVersion(version)]
+	if !ok {
+		return pushedPackage{}, false
+	}
+	pkg, ok := release.Feeds[feed][key]
+	return pkg, ok
+}
+
+// record marks a package as confirmed on a feed and persists the state immediately.
+func (s *pushState) record(version, feed, key string, pkg pushedPackage) error {
+	v := comparableVersion(version)
+	release, ok := s.Releases[v]
+	if !ok {
+		release = &releaseState{Feeds: map[string]map[string]pushedPackage{}}
+		s.Releases[v] = release
+	}
+	if release.Feeds[feed] == nil {
+		release.Feeds[feed] = map[string]pushedPackage{}
+	}
+	release.Feeds[feed][key] = pkg
+	return s.save()
+}
+
+// save writes the state file atomically.
+func (s *pushState) save() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".nuget-push-state-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	return nil
+}
+
+// stateKey identifies a package in the state file by id and version,
+// falling back to the file name when the identity is unknown.
+func stateKey(result *PackageResult) string {
+	if result.ID != "" && result.Version != "" {
+		return strings.ToLower(result.ID) + "/" + comparableVersion(result.Version)
+	}
+	return filepath.Base(result.Path)
+}
+
+// resumeFromState marks packages already confirmed in a previous run as skipped.
+// A recorded package only counts if the local file still has the same hash.
+func resumeFromState(state *pushState, version string, results []PackageResult, dryRun bool) {
+	for i := range results {
+		result := &results[i]
+		recorded, ok := state.lookup(version, result.Feed, stateKey(result))
+		if !ok {
+			continue
+		}
+		hash, err := fileSHA512(result.Path)
+		if err != nil || hash != recorded.SHA512 {
+			continue
+		}
+		result.Status = StatusSkipped
+		if dryRun {
+			result.Status = StatusWouldSkip
+		}
+		result.Resumed = true
+		result.Message = fmt.Sprintf("already %s in a previous run at %s", recorded.Status, recorded.PushedAt.Format(time.RFC3339))
+	}
+}
+
+// recordResult persists a confirmed push or skip to the state file.
+// Failures to save are reported on the result rather than failing the push.
+func recordResult(state *pushState, version string, result *PackageResult) {
+	if state == nil || (result.Status != StatusPushed && result.Status != StatusSkipped) {
+		return
+	}
+	hash, err := fileSHA512(result.Path)
+	if err == nil {
+		err = state.record(version, result.Feed, stateKey(result), pushedPackage{
+			Path:     result.Path,
+			SHA512:   hash,
+			Status:   result.Status,
+			PushedAt: time.Now().UTC(),
+		})
+	}
+	if err != nil {
+		result.Message = joinReasons(result.Message, fmt.Sprintf("could not update state file: %v", err))
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestPushStateRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "push.json")
+
+	state, err := loadPushState(path)
+	if err != nil {
+		t.Fatalf("unexpected error loading missing state: %v", err)
+	}
+
+	pkg := pushedPackage{Path: "a.nupkg", SHA512: "abc", Status: StatusPushed}
+	if err := state.record("v1.0.0", "https://feed", "contoso.core/1.0.0", pkg); err != nil {
+		t.Fatalf("failed to record: %v", err)
+	}
+
+	reloaded, err := loadPushState(path)
+	if err != nil {
+		t.Fatalf("failed to reload state: %v", err)
+	}
+	got, ok := reloaded.lookup("1.0.0", "https://feed", "contoso.core/1.0.0")
+	if !ok || got.SHA512 != "abc" {
+		t.Fatalf("expected recorded package, got %+v (found=%v)", got, ok)
+	}
+	if _, ok := reloaded.lookup("1.0.0", "https://other-feed", "contoso.core/1.0.0"); ok {
+		t.Error("expected state to be keyed by feed")
+	}
+	if _, ok := reloaded.lookup("1.0.1", "https://feed", "contoso.core/1.0.0"); ok {
+		t.Error("expected state to be keyed by release version")
+	}
+
+	reloaded.reset("1.0.0")
+	if _, ok := reloaded.lookup("1.0.0", "https://feed", "contoso.core/1.0.0"); ok {
+		t.Error("expected reset to forget the release")
+	}
+}
+
+func TestResumeInterruptedRelease(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, id := range []string{"Contoso.A", "Contoso.B", "Contoso.C"} {
+		writeTestPackage(t, tmpDir, id, "1.0.0", nil)
+	}
+	feed := newTestFeed(t)
+	stateFile := filepath.Join(tmpDir, "state.json")
+
+	run := func(failOn string, extra map[string]any) (*plugin.ExecuteResponse, []string) {
+		t.Helper()
+		var pushed []string
+		mockExec := &MockCommandExecutor{
+			RunFunc: func(_ context.Context, _ string, args ...string) ([]byte, error) {
+				pkg := filepath.Base(args[2])
+				pushed = append(pushed, pkg)
+				if pkg == failOn {
+					return []byte("error: Response status code does not indicate success: 500 (Internal Server Error)."), errors.New("exit status 1")
+				}
+				return []byte("Your package was pushed."), nil
+			},
+		}
+		p := &NuGetPlugin{cmdExecutor: mockExec, httpClient: feed.server.Client()}
+
+		config := map[string]any{
+			"api_key":      "test-key",
+			"source":       feed.SourceURL(),
+			"package_path": filepath.Join(tmpDir, "*.nupkg"),
+			"resume":       true,
+			"state_file":   stateFile,
+		}
+		for k, v := range extra {
+			config[k] = v
+		}
+
+		resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+			Hook:    plugin.HookPostPublish,
+			Config:  config,
+			Context: plugin.ReleaseContext{Version: "v1.0.0"},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return resp, pushed
+	}
+
+	// First run dies on the second package.
+	resp, pushed := run("Contoso.B.1.0.0.nupkg", nil)
+	if resp.Success || len(pushed) != 2 {
+		t.Fatalf("expected failure after 2 pushes, got success=%v pushes=%v", resp.Success, pushed)
+	}
+
+	// Re-run continues from where it stopped.
+	resp, pushed = run("", nil)
+	if !resp.Success {
+		t.Fatalf("expected resumed run to succeed, got: %s", resp.Error)
+	}
+	if len(pushed) != 2 || pushed[0] != "Contoso.B.1.0.0.nupkg" || pushed[1] != "Contoso.C.1.0.0.nupkg" {
+		t.Errorf("expected only the remaining packages to be pushed, got %v", pushed)
+	}
+	results := resp.Outputs["packages"].([]PackageResult)
+	if !results[0].Resumed || results[0].Status != StatusSkipped {
+		t.Errorf("expected first package to be resumed, got %+v", results[0])
+	}
+
+	// Dry run reports the resumed packages as skipped.
+	p := &NuGetPlugin{cmdExecutor: &MockCommandExecutor{}, httpClient: feed.server.Client()}
+	dry, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+		Hook: plugin.HookPostPublish,
+		Config: map[string]any{
+			"api_key":      "test-key",
+			"source":       feed.SourceURL(),
+			"package_path": filepath.Join(tmpDir, "*.nupkg"),
+			"resume":       true,
+			"state_file":   stateFile,
+		},
+		Context: plugin.ReleaseContext{Version: "v1.0.0"},
+		DryRun:  true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if summary := dry.Outputs["summary"].(RunSummary); summary.Skipped != 3 {
+		t.Errorf("expected 3 packages skipped in dry run, got %+v", summary)
+	}
+
+	// reset_state starts over.
+	resp, pushed = run("", map[string]any{"reset_state": true})
+	if !resp.Success || len(pushed) != 3 {
+		t.Errorf("expected reset run to push all 3 packages, got success=%v pushes=%v", resp.Success, pushed)
+	}
+}
+
+func TestResumeIgnoresChangedPackage(t *testing.T) {
+	tmpDir := t.TempDir()
+	pkg := writeTestPackage(t, tmpDir, "Contoso.A", "1.0.0", map[string]string{"lib/a.dll": "one"})
+
+	state, err := loadPushState(filepath.Join(tmpDir, "state.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	results := []PackageResult{{Path: pkg, ID: "Contoso.A", Version: "1.0.0", Feed: "f", Status: StatusPushed}}
+	recordResult(state, "1.0.0", &results[0])
+
+	// Rebuild the package with different contents.
+	writeTestPackage(t, tmpDir, "Contoso.A", "1.0.0", map[string]string{"lib/a.dll": "two"})
+
+	results[0].Status = StatusNotAttempted
+	resumeFromState(state, "1.0.0", results, false)
+	if results[0].Resumed {
+		t.Error("expected a rebuilt package not to be treated as already pushed")
+	}
+}
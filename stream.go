@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxOutputTail bounds how much command output is kept for error reports.
+const maxOutputTail = 64 << 10
+
+// maxOutputLine bounds the length of a single streamed output line.
+const maxOutputLine = 1 << 20
+
+// commandWaitDelay is how long a killed command may keep its output open.
+const commandWaitDelay = 5 * time.Second
+
+// Output streams reported to StreamingCommandExecutor callbacks.
+const (
+	streamStdout = "stdout"
+	streamStderr = "stderr"
+)
+
+// outputTail keeps the most recent lines of command output up to a byte limit.
+type outputTail struct {
+	mu    sync.Mutex
+	max   int
+	size  int
+	lines []string
+}
+
+// add appends a line, dropping the oldest lines once the limit is exceeded.
+func (t *outputTail) add(line string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(line) > t.max {
+		line = line[len(line)-t.max:]
+	}
+	t.lines = append(t.lines, line)
+	t.size += len(line) + 1
+	for t.size > t.max && len(t.lines) > 1 {
+		t.size -= len(t.lines[0]) + 1
+		t.lines = t.lines[1:]
+	}
+}
+
+// Bytes returns the retained output, one line per row.
+func (t *outputTail) Bytes() []byte {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.lines) == 0 {
+		return nil
+	}
+	return []byte(strings.Join(t.lines, "\n") + "\n")
+}
+
+// lineWriter splits written output into lines, recording each in the tail and
+// passing it to onLine. Partial lines longer than maxOutputLine are flushed.
+type lineWriter struct {
+	stream  string
+	tail    *outputTail
+	onLine  func(stream, line string)
+	mu      *sync.Mutex
+	pending []byte
+}
+
+// Write implements io.Writer.
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.pending = append(w.pending, p...)
+	for {
+		i := bytes.IndexByte(w.pending, '\n')
+		if i < 0 {
+			break
+		}
+		w.emit(w.pending[:i])
+		w.pending = w.pending[i+1:]
+	}
+	if len(w.pending) > maxOutputLine {
+		w.emit(w.pending)
+		w.pending = nil
+	}
+	return len(p), nil
+}
+
+// flush emits any unterminated final line.
+func (w *lineWriter) flush() {
+	if len(w.pending) > 0 {
+		w.emit(w.pending)
+		w.pending = nil
+	}
+}
+
+// emit records a single line.
+func (w *lineWriter) emit(raw []byte) {
+	line := strings.TrimRight(string(raw), "\r")
+	w.tail.add(line)
+	if w.onLine != nil {
+		w.mu.Lock()
+		w.onLine(w.stream, line)
+		w.mu.Unlock()
+	}
+}
+
+// RunStreaming executes the command with env added to the current environment,
+// calling onLine for each line of stdout and stderr as it is written. Only a
+// bounded tail of the combined output is kept and returned.
+func (e *RealCommandExecutor) RunStreaming(ctx context.Context, env []string, onLine func(stream, line string), name string, args ...string) ([]byte, error) {
+	cmd := newCommand(ctx, env, name, args...)
+
+	tail := &outputTail{max: maxOutputTail}
+	var mu sync.Mutex
+	stdout := &lineWriter{stream: streamStdout, tail: tail, onLine: onLine, mu: &mu}
+	stderr := &lineWriter{stream: streamStderr, tail: tail, onLine: onLine, mu: &mu}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+
+	err := cmd.Run()
+	stdout.flush()
+	stderr.flush()
+	return tail.Bytes(), err
+}
+
+// newCommand builds a command that is killed when ctx is done. WaitDelay stops
+// Wait from blocking on output pipes held open by orphaned child processes.
+func newCommand(ctx context.Context, env []string, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	cmd.WaitDelay = commandWaitDelay
+	return cmd
+}
+
+// getLogger returns the structured logger, defaulting to text on stderr.
+func (p *NuGetPlugin) getLogger() *slog.Logger {
+	if p.logger != nil {
+		return p.logger
+	}
+	return slog.New(slog.NewTextHandler(os.Stderr, nil))
+}
+
+// pushOutputLogger returns an onLine callback that logs dotnet output for a
+// package push attempt.
+func (p *NuGetPlugin) pushOutputLogger(packagePath string, attempt int) func(stream, line string) {
+	logger := p.getLogger().With("package", packagePath, "attempt", attempt)
+	return func(stream, line string) {
+		if strings.TrimSpace(line) == "" {
+			return
+		}
+		level := slog.LevelInfo
+		if stream == streamStderr {
+			level = slog.LevelWarn
+		}
+		logger.Log(context.Background(), level, line, "stream", stream)
+	}
+}
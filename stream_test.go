@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// streamingExecutor is a StreamingCommandExecutor that replays fixed output.
+type streamingExecutor struct {
+	MockCommandExecutor
+	lines  []string
+	output []byte
+	err    error
+}
+
+// RunStreaming implements StreamingCommandExecutor.
+func (s *streamingExecutor) RunStreaming(ctx context.Context, env []string, onLine func(stream, line string), name string, args ...string) ([]byte, error) {
+	s.Calls = append(s.Calls, MockCall{Name: name, Args: args, Env: env})
+	for _, line := range s.lines {
+		stream, text, _ := strings.Cut(line, ":")
+		onLine(stream, text)
+	}
+	return s.output, s.err
+}
+
+func TestOutputTail(t *testing.T) {
+	tail := &outputTail{max: 16}
+	for _, line := range []string{"first line", "second", "third"} {
+		tail.add(line)
+	}
+	if got := string(tail.Bytes()); got != "second\nthird\n" {
+		t.Errorf("expected only the most recent lines, got %q", got)
+	}
+
+	tail.add(strings.Repeat("x", 40))
+	if got := string(tail.Bytes()); got != strings.Repeat("x", 16)+"\n" {
+		t.Errorf("expected an overlong line to be truncated to the limit, got %q", got)
+	}
+}
+
+func TestRealCommandExecutorRunStreaming(t *testing.T) {
+	var got []string
+	onLine := func(stream, line string) { got = append(got, stream+":"+line) }
+
+	output, err := (&RealCommandExecutor{}).RunStreaming(context.Background(), []string{"PUSH_TEST=ok"}, onLine,
+		"sh", "-c", `echo "uploading $PUSH_TEST"; echo "warn: slow" >&2; exit 3`)
+	if err == nil {
+		t.Fatal("expected the exit status to be returned")
+	}
+	want := map[string]bool{"stdout:uploading ok": true, "stderr:warn: slow": true}
+	if len(got) != len(want) || !want[got[0]] || !want[got[1]] {
+		t.Errorf("unexpected streamed lines: %v", got)
+	}
+	if !strings.Contains(string(output), "uploading ok") || !strings.Contains(string(output), "warn: slow") {
+		t.Errorf("expected the output tail to hold both streams, got %q", output)
+	}
+}
+
+func TestExecuteStreamsPushOutput(t *testing.T) {
+	tmpDir := t.TempDir()
+	pkg := writeTestPackage(t, tmpDir, "Contoso.Core", "1.0.0", nil)
+
+	var logs bytes.Buffer
+	executor := &streamingExecutor{
+		lines:  []string{"stdout:Pushing Contoso.Core.1.0.0.nupkg", "stderr:Response status code does not indicate success: 409 (Conflict)"},
+		output: []byte("Response status code does not indicate success: 409 (Conflict)\n"),
+		err:    errors.New("exit status 1"),
+	}
+	p := &NuGetPlugin{cmdExecutor: executor, logger: slog.New(slog.NewJSONHandler(&logs, nil))}
+
+	resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+		Hook:    plugin.HookPostPublish,
+		Config:  map[string]any{"api_key": "test-key", "source": "https://127.0.0.1/v3/index.json", "package_path": pkg, "on_duplicate": "skip"},
+		Context: plugin.ReleaseContext{Version: "v1.0.0"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("expected the duplicate to be classified from the output tail, got: %s", resp.Error)
+	}
+
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("invalid log record %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected a start record and one record per line, got %v", records)
+	}
+	for _, record := range records {
+		if record["package"] != pkg || record["attempt"] != float64(1) {
+			t.Errorf("expected package and attempt on every record, got %v", record)
+		}
+	}
+	if records[2]["level"] != "WARN" || records[2]["stream"] != streamStderr {
+		t.Errorf("expected stderr to be logged as a warning, got %v", records[2])
+	}
+	if strings.Contains(logs.String(), "test-key") {
+		t.Error("expected the API key not to be logged")
+	}
+}
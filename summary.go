@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// githubStepSummaryEnv names the file GitHub Actions renders as the job summary.
+const githubStepSummaryEnv = "GITHUB_STEP_SUMMARY"
+
+// markdownCellEscaper keeps table cells on one line and out of the table syntax.
+var markdownCellEscaper = strings.NewReplacer("|", `\|`, "\r\n", " ", "\n", " ")
+
+// renderMarkdownSummary renders the outcome of a push as a Markdown report for
+// pull request comments, job summaries and notifications.
+func renderMarkdownSummary(summary RunSummary, results []PackageResult) string {
+	var b strings.Builder
+
+	title := "NuGet release"
+	if summary.Version != "" {
+		title += " " + summary.Version
+	}
+	if summary.DryRun {
+		title += " (dry run)"
+	}
+	_, _ = fmt.Fprintf(&b, "## %s\n\n", title)
+
+	verb := "Pushed"
+	if summary.DryRun {
+		verb = "Would push"
+	}
+	_, _ = fmt.Fprintf(&b, "%s %d of %d package(s) to %s", verb, summary.Pushed, summary.Total, summary.Source)
+	if summary.Skipped > 0 {
+		_, _ = fmt.Fprintf(&b, ", %d skipped", summary.Skipped)
+	}
+	if summary.Failed > 0 {
+		_, _ = fmt.Fprintf(&b, ", %d failed", summary.Failed)
+	}
+	_, _ = fmt.Fprintf(&b, " in %s.\n", (time.Duration(summary.DurationMS) * time.Millisecond).Round(100*time.Millisecond))
+
+	if len(results) == 0 {
+		return b.String()
+	}
+
+	b.WriteString("\n| Package | Version | Status | Feed | Size | Details |\n")
+	b.WriteString("|---|---|---|---|---|---|\n")
+	for _, r := range results {
+		name := r.ID
+		if name == "" {
+			name = filepath.Base(r.Path)
+		}
+		name = markdownCell(name)
+		if r.URL != "" {
+			name = fmt.Sprintf("[%s](%s)", name, r.URL)
+		}
+		_, _ = fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s |\n",
+			name, markdownCell(r.Version), r.Status, markdownCell(r.Feed), formatSize(r.SizeBytes), markdownCell(resultDetails(r)))
+	}
+	return b.String()
+}
+
+// resultDetails describes why a package was skipped or failed, and any warnings.
+func resultDetails(r PackageResult) string {
+	details := r.Message
+	if r.Error != "" {
+		details = joinReasons(details, r.Error)
+		if r.Hint != "" {
+			details = joinReasons(details, "hint: "+r.Hint)
+		}
+	}
+	if r.Resumed {
+		details = joinReasons(details, "pushed in a previous run")
+	}
+	if r.Verified {
+		details = joinReasons(details, "verified on feed")
+	}
+	for _, w := range r.Warnings {
+		details = joinReasons(details, w)
+	}
+	return details
+}
+
+// markdownCell escapes a value for use in a Markdown table cell.
+func markdownCell(s string) string {
+	return markdownCellEscaper.Replace(s)
+}
+
+// formatSize formats a byte count for humans, or returns "" if it is unknown.
+func formatSize(n int64) string {
+	switch {
+	case n <= 0:
+		return ""
+	case n < 1<<10:
+		return fmt.Sprintf("%d B", n)
+	case n < 1<<20:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	}
+}
+
+// writeMarkdownSummary writes the summary to summary_path, if configured, and
+// appends it to the GitHub Actions job summary when running in a workflow.
+func writeMarkdownSummary(cfg *Config, markdown string) error {
+	if cfg.SummaryPath != "" {
+		if err := os.MkdirAll(filepath.Dir(cfg.SummaryPath), 0o755); err != nil {
+			return fmt.Errorf("failed to create summary directory: %w", err)
+		}
+		if err := os.WriteFile(cfg.SummaryPath, []byte(markdown), 0o644); err != nil {
+			return fmt.Errorf("failed to write summary: %w", err)
+		}
+	}
+
+	stepSummary := os.Getenv(githubStepSummaryEnv)
+	if stepSummary == "" {
+		return nil
+	}
+	f, err := os.OpenFile(stepSummary, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open job summary: %w", err)
+	}
+	if _, err := f.WriteString(markdown + "\n"); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write job summary: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write job summary: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// TestMain keeps the plugin's own CI job summary free of test releases.
+func TestMain(m *testing.M) {
+	_ = os.Unsetenv(githubStepSummaryEnv)
+	os.Exit(m.Run())
+}
+
+func TestRenderMarkdownSummary(t *testing.T) {
+	summary := RunSummary{Source: DefaultSource, Version: "1.2.3", Total: 3, Pushed: 1, Skipped: 1, Failed: 1, DurationMS: 2340}
+	results := []PackageResult{
+		{Path: "Contoso.Core.1.2.3.nupkg", ID: "Contoso.Core", Version: "1.2.3", Status: StatusPushed, Feed: DefaultSource, URL: "https://www.nuget.org/packages/Contoso.Core/1.2.3", SizeBytes: 12800},
+		{Path: "Contoso.Data.1.2.3.nupkg", ID: "Contoso.Data", Version: "1.2.3", Status: StatusSkipped, Feed: DefaultSource, Message: "version already exists | skipped"},
+		{Path: "broken.nupkg", Status: StatusFailed, Feed: DefaultSource, Error: "invalid package", Hint: "rebuild it", SizeBytes: 300},
+	}
+
+	got := renderMarkdownSummary(summary, results)
+	for _, want := range []string{
+		"## NuGet release 1.2.3\n",
+		"Pushed 1 of 3 package(s) to https://api.nuget.org/v3/index.json, 1 skipped, 1 failed in 2.3s.",
+		"| [Contoso.Core](https://www.nuget.org/packages/Contoso.Core/1.2.3) | 1.2.3 | pushed | https://api.nuget.org/v3/index.json | 12.5 KiB |  |",
+		`| Contoso.Data | 1.2.3 | skipped | https://api.nuget.org/v3/index.json |  | version already exists \| skipped |`,
+		"| broken.nupkg |  | failed | https://api.nuget.org/v3/index.json | 300 B | invalid package; hint: rebuild it |",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected summary to contain %q, got:\n%s", want, got)
+		}
+	}
+
+	dry := renderMarkdownSummary(RunSummary{Version: "1.2.3", DryRun: true, Source: DefaultSource}, nil)
+	if !strings.Contains(dry, "(dry run)") || !strings.Contains(dry, "Would push 0 of 0") || strings.Contains(dry, "| Package |") {
+		t.Errorf("unexpected dry run summary:\n%s", dry)
+	}
+}
+
+func TestExecuteWritesMarkdownSummary(t *testing.T) {
+	tmpDir := t.TempDir()
+	pkg := writeTestPackage(t, tmpDir, "Contoso.Core", "1.2.3", nil)
+	feed := newTestFeed(t)
+	summaryPath := filepath.Join(tmpDir, "out", "release.md")
+	stepSummary := filepath.Join(tmpDir, "step-summary.md")
+	if err := os.WriteFile(stepSummary, []byte("earlier step\n"), 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Setenv(githubStepSummaryEnv, stepSummary)
+
+	run := func(dryRun bool) *plugin.ExecuteResponse {
+		t.Helper()
+		p := &NuGetPlugin{cmdExecutor: &MockCommandExecutor{}, httpClient: feed.server.Client()}
+		resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+			Hook: plugin.HookPostPublish,
+			Config: map[string]any{
+				"api_key":      "test-key",
+				"source":       feed.SourceURL(),
+				"package_path": pkg,
+				"summary_path": summaryPath,
+			},
+			Context: plugin.ReleaseContext{Version: "v1.2.3"},
+			DryRun:  dryRun,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !resp.Success {
+			t.Fatalf("expected success, got: %s", resp.Error)
+		}
+		return resp
+	}
+
+	resp := run(true)
+	if md, _ := resp.Outputs["markdown_summary"].(string); !strings.Contains(md, "(dry run)") {
+		t.Errorf("expected dry run summary in outputs, got %q", md)
+	}
+	if _, err := os.Stat(summaryPath); !os.IsNotExist(err) {
+		t.Errorf("expected dry run not to write the summary, got %v", err)
+	}
+
+	resp = run(false)
+	markdown, _ := resp.Outputs["markdown_summary"].(string)
+	if !strings.Contains(markdown, "| Contoso.Core | 1.2.3 | pushed |") {
+		t.Errorf("unexpected summary output:\n%s", markdown)
+	}
+	written, err := os.ReadFile(summaryPath)
+	if err != nil || string(written) != markdown {
+		t.Errorf("expected summary_path to hold the summary, got %q (%v)", written, err)
+	}
+	step, err := os.ReadFile(stepSummary)
+	if err != nil || string(step) != "earlier step\n"+markdown+"\n" {
+		t.Errorf("expected the summary to be appended to the job summary, got %q (%v)", step, err)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"os"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// Push targets.
+const (
+	// TargetNuGet pushes to any NuGet V3 feed given by source.
+	TargetNuGet = "nuget"
+	// TargetGitHubPackages pushes to the GitHub Packages NuGet registry of an owner.
+	TargetGitHubPackages = "github_packages"
+	// TargetAzureArtifacts pushes to an Azure Artifacts feed.
+	TargetAzureArtifacts = "azure_artifacts"
+)
+
+// applyTarget fills in target settings that depend on the release context.
+// For Azure Artifacts the source is built from the organization, project and feed.
+// For GitHub Packages the owner defaults to the repository owner, the source is
+// built from it, and the feed username defaults to the Actions actor or the owner.
+// An explicit source is always kept.
+func (c *Config) applyTarget(releaseCtx plugin.ReleaseContext) {
+	if c.Target == TargetAzureArtifacts {
+		if c.Source == "" && c.AzureOrganization != "" && c.AzureFeed != "" {
+			c.Source = c.azureArtifactsSource()
+		}
+		return
+	}
+	if c.Target != TargetGitHubPackages {
+		return
+	}
+	if c.GitHubOwner == "" {
+		c.GitHubOwner = releaseCtx.RepositoryOwner
+	}
+	if c.Source == "" && c.GitHubOwner != "" {
+		c.Source = githubPackagesSource(c.GitHubOwner)
+	}
+	if c.GitHubUsername == "" {
+		c.GitHubUsername = os.Getenv("GITHUB_ACTOR")
+	}
+	if c.GitHubUsername == "" {
+		c.GitHubUsername = c.GitHubOwner
+	}
+}
+
+// feedKind returns the kind of feed the configuration pushes to.
+func (c *Config) feedKind() feedKind {
+	switch c.Target {
+	case TargetGitHubPackages:
+		return feedKindGitHub
+	case TargetAzureArtifacts:
+		return feedKindAzure
+	}
+	return detectFeedKind(c.Source)
+}
+
+// feedCredentials returns the basic auth credentials for feed requests, if the target needs them.
+// GitHub Packages accepts any username with a token; Azure Artifacts any username with a PAT.
+func (c *Config) feedCredentials() (username, password string) {
+	switch c.Target {
+	case TargetGitHubPackages:
+		return c.GitHubUsername, c.APIKey
+	case TargetAzureArtifacts:
+		return azureAPIKeyPlaceholder, c.AzurePAT
+	}
+	return "", ""
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// sourceLookupTimeout bounds the DNS lookup that checks a source URL.
+const sourceLookupTimeout = 10 * time.Second
+
+// withPushTimeout bounds a single push attempt by push_timeout; zero disables
+// the deadline and leaves the attempt to dotnet's own timeout.
+func (c *Config) withPushTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.PushTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, time.Duration(c.PushTimeout)*time.Second)
+}
+
+// withTotalTimeout bounds ctx by total_timeout; zero disables the deadline.
+func (c *Config) withTotalTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.TotalTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, time.Duration(c.TotalTimeout)*time.Second)
+}
+
+// deadlineError reports which deadline cut a push attempt short, if any: the
+// hook's total_timeout, which fails the release, or the attempt's push_timeout,
+// which is retried like any other timeout.
+func deadlineError(ctx, attemptCtx context.Context, cfg *Config, err error) *PushError {
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return &PushError{Kind: ErrorKindDeadline, Output: fmt.Sprintf("total_timeout of %ds exceeded while pushing", cfg.TotalTimeout), Err: err}
+	case errors.Is(attemptCtx.Err(), context.DeadlineExceeded):
+		return &PushError{Kind: ErrorKindTimeout, Output: fmt.Sprintf("push attempt exceeded push_timeout of %ds and was killed", cfg.PushTimeout), Err: err}
+	}
+	return nil
+}
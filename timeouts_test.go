@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestTimeoutDefaults(t *testing.T) {
+	cfg := (&NuGetPlugin{}).parseConfig(map[string]any{})
+	if cfg.PushTimeout != 0 || cfg.TotalTimeout != 0 {
+		t.Errorf("expected deadlines to be disabled by default, got push_timeout=%d total_timeout=%d", cfg.PushTimeout, cfg.TotalTimeout)
+	}
+
+	tests := []struct {
+		name         string
+		cfg          Config
+		wantDeadline bool
+	}{
+		{name: "disabled", cfg: Config{Timeout: 300}},
+		{name: "push_timeout", cfg: Config{Timeout: 300, PushTimeout: 90}, wantDeadline: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := tt.cfg.withPushTimeout(context.Background())
+			defer cancel()
+			deadline, ok := ctx.Deadline()
+			if ok != tt.wantDeadline {
+				t.Fatalf("expected deadline=%v, got %v", tt.wantDeadline, ok)
+			}
+			if ok && time.Until(deadline) > 90*time.Second {
+				t.Errorf("expected a deadline within 90s, got %s", time.Until(deadline))
+			}
+		})
+	}
+}
+
+func TestExecuteDeadlines(t *testing.T) {
+	tests := []struct {
+		name         string
+		pushTimeout  int
+		totalTimeout int
+		wantClass    PushErrorKind
+		wantError    string
+		wantTimedOut bool
+	}{
+		{name: "attempt exceeds push_timeout", pushTimeout: 1, totalTimeout: 60, wantClass: ErrorKindTimeout, wantError: "exceeded push_timeout of 1s"},
+		{name: "hook exceeds total_timeout", pushTimeout: 60, totalTimeout: 1, wantClass: ErrorKindDeadline, wantError: "total_timeout of 1s exceeded", wantTimedOut: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg := writeTestPackage(t, t.TempDir(), "Contoso.Core", "1.0.0", nil)
+			// A wedged dotnet process that only stops when it is killed.
+			mockExec := &MockCommandExecutor{
+				RunFunc: func(ctx context.Context, _ string, _ ...string) ([]byte, error) {
+					<-ctx.Done()
+					return nil, ctx.Err()
+				},
+			}
+			p := &NuGetPlugin{cmdExecutor: mockExec}
+
+			resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+				Hook: plugin.HookPostPublish,
+				Config: map[string]any{
+					"api_key":       "test-key",
+					"source":        "https://127.0.0.1/v3/index.json",
+					"package_path":  pkg,
+					"retries":       0,
+					"push_timeout":  tt.pushTimeout,
+					"total_timeout": tt.totalTimeout,
+				},
+				Context: plugin.ReleaseContext{Version: "v1.0.0"},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resp.Success {
+				t.Fatal("expected the push to fail")
+			}
+			if !containsString(resp.Error, tt.wantError) {
+				t.Errorf("expected error containing %q, got %s", tt.wantError, resp.Error)
+			}
+			result := resp.Outputs["packages"].([]PackageResult)[0]
+			if result.ErrorClass != string(tt.wantClass) {
+				t.Errorf("expected error class %s, got %s", tt.wantClass, result.ErrorClass)
+			}
+			if timedOut, _ := resp.Outputs["timed_out"].(bool); timedOut != tt.wantTimedOut {
+				t.Errorf("expected timed_out=%v, got %v", tt.wantTimedOut, resp.Outputs["timed_out"])
+			}
+		})
+	}
+}
+
+func TestRealCommandExecutorKillsOnDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	_, err := (&RealCommandExecutor{}).RunStreaming(ctx, nil, nil, "sleep", "10")
+	if err == nil {
+		t.Fatal("expected the command to be killed")
+	}
+	if elapsed := time.Since(started); elapsed > commandWaitDelay {
+		t.Errorf("expected the command to stop at the deadline, took %s", elapsed)
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// nugetVersion is a parsed NuGet package version: a 1 to 4 part numeric
+// version, an optional dot-separated release label and optional build metadata.
+type nugetVersion struct {
+	Major    uint64
+	Minor    uint64
+	Patch    uint64
+	Revision uint64
+	Release  []string
+	Metadata string
+}
+
+// parseNuGetVersion parses a version the way NuGet does. A leading "v", as in
+// release tags, is ignored.
+func parseNuGetVersion(s string) (nugetVersion, error) {
+	var v nugetVersion
+	raw := strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if raw == "" {
+		return v, fmt.Errorf("version is empty")
+	}
+
+	if i := strings.IndexByte(raw, '+'); i >= 0 {
+		v.Metadata = raw[i+1:]
+		raw = raw[:i]
+		if !validIdentifiers(v.Metadata) {
+			return v, fmt.Errorf("version %q has invalid build metadata", s)
+		}
+	}
+	if i := strings.IndexByte(raw, '-'); i >= 0 {
+		label := raw[i+1:]
+		raw = raw[:i]
+		if !validIdentifiers(label) {
+			return v, fmt.Errorf("version %q has an invalid prerelease label", s)
+		}
+		v.Release = strings.Split(label, ".")
+	}
+
+	parts := strings.Split(raw, ".")
+	if len(parts) > 4 {
+		return v, fmt.Errorf("version %q has more than 4 numeric parts", s)
+	}
+	numbers := []*uint64{&v.Major, &v.Minor, &v.Patch, &v.Revision}
+	for i, part := range parts {
+		if !isNumeric(part) {
+			return v, fmt.Errorf("version %q has a non-numeric part %q", s, part)
+		}
+		n, err := strconv.ParseUint(part, 10, 31)
+		if err != nil {
+			return v, fmt.Errorf("version %q has an out of range part %q", s, part)
+		}
+		*numbers[i] = n
+	}
+	return v, nil
+}
+
+// validIdentifiers reports whether s is a non-empty dot-separated list of
+// non-empty [0-9A-Za-z-] identifiers.
+func validIdentifiers(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, ident := range strings.Split(s, ".") {
+		if ident == "" {
+			return false
+		}
+		for i := 0; i < len(ident); i++ {
+			c := ident[i]
+			if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// String returns the normalized version NuGet uses for package identity:
+// leading zeros are dropped, the revision only appears when non-zero, and
+// build metadata is omitted.
+func (v nugetVersion) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Revision > 0 {
+		s += fmt.Sprintf(".%d", v.Revision)
+	}
+	if len(v.Release) > 0 {
+		s += "-" + strings.Join(v.Release, ".")
+	}
+	return s
+}
+
+// IsSemVer2 reports whether the version needs SemVer 2.0.0 support, which is
+// the case for dotted prerelease labels and build metadata.
+func (v nugetVersion) IsSemVer2() bool {
+	return len(v.Release) > 1 || v.Metadata != ""
+}
+
+// Compare orders versions the way NuGet does: by numeric parts, then a
+// release before its prereleases, then prerelease labels identifier by
+// identifier (numeric identifiers numerically and below alphanumeric ones,
+// which compare case-insensitively). Build metadata is ignored.
+func (v nugetVersion) Compare(o nugetVersion) int {
+	for _, pair := range [][2]uint64{{v.Major, o.Major}, {v.Minor, o.Minor}, {v.Patch, o.Patch}, {v.Revision, o.Revision}} {
+		if c := cmpUint(pair[0], pair[1]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(v.Release) == 0 && len(o.Release) == 0:
+		return 0
+	case len(v.Release) == 0:
+		return 1
+	case len(o.Release) == 0:
+		return -1
+	}
+	for i := 0; i < len(v.Release) && i < len(o.Release); i++ {
+		a, b := v.Release[i], o.Release[i]
+		aNum, bNum := isNumeric(a), isNumeric(b)
+		switch {
+		case aNum && bNum:
+			an, _ := strconv.ParseUint(a, 10, 64)
+			bn, _ := strconv.ParseUint(b, 10, 64)
+			if c := cmpUint(an, bn); c != 0 {
+				return c
+			}
+		case aNum:
+			return -1
+		case bNum:
+			return 1
+		default:
+			if c := strings.Compare(strings.ToLower(a), strings.ToLower(b)); c != 0 {
+				return c
+			}
+		}
+	}
+	return cmpUint(uint64(len(v.Release)), uint64(len(o.Release)))
+}
+
+// cmpUint returns -1, 0 or 1 as a is less than, equal to or greater than b.
+func cmpUint(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// normalizedVersion returns the normalized form of a version, or the version
+// without a leading "v" and build metadata if it does not parse.
+func normalizedVersion(s string) string {
+	v, err := parseNuGetVersion(s)
+	if err != nil {
+		s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+		if i := strings.IndexByte(s, '+'); i >= 0 {
+			s = s[:i]
+		}
+		return s
+	}
+	return v.String()
+}
+
+// versionWarnings describes how a version will appear differently on a feed
+// than it was written.
+func versionWarnings(s string) []string {
+	v, err := parseNuGetVersion(s)
+	if err != nil {
+		return []string{err.Error() + "; NuGet will reject it"}
+	}
+
+	var warnings []string
+	written := strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexByte(written, '+'); i >= 0 {
+		written = written[:i]
+	}
+	if written != v.String() {
+		warnings = append(warnings, fmt.Sprintf("version %s appears on the feed as %s", written, v))
+	}
+	if v.Metadata != "" {
+		warnings = append(warnings, fmt.Sprintf("build metadata +%s is not part of the package identity and is ignored when versions are compared", v.Metadata))
+	}
+	if v.IsSemVer2() {
+		warnings = append(warnings, fmt.Sprintf("%s is a SemVer 2.0.0 version, hidden from NuGet clients older than 4.3.0 and the nuget.org V2 API", v))
+	}
+	return warnings
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseNuGetVersion(t *testing.T) {
+	tests := []struct {
+		version    string
+		normalized string
+		semver2    bool
+		wantErr    bool
+	}{
+		{version: "1.2.3", normalized: "1.2.3"},
+		{version: "v1.2.3", normalized: "1.2.3"},
+		{version: "1.2", normalized: "1.2.0"},
+		{version: "1", normalized: "1.0.0"},
+		{version: "01.02.003", normalized: "1.2.3"},
+		{version: "1.2.3.0", normalized: "1.2.3"},
+		{version: "1.2.3.4", normalized: "1.2.3.4"},
+		{version: "1.2.3-beta", normalized: "1.2.3-beta"},
+		{version: "1.2.3-beta.3", normalized: "1.2.3-beta.3", semver2: true},
+		{version: "1.2.3-beta.01", normalized: "1.2.3-beta.01", semver2: true},
+		{version: "1.2.3+sha.abc", normalized: "1.2.3", semver2: true},
+		{version: "1.2.3-rc-1+build", normalized: "1.2.3-rc-1", semver2: true},
+		{version: "1.2.3.4.5", wantErr: true},
+		{version: "1.x.3", wantErr: true},
+		{version: "1.2.3-", wantErr: true},
+		{version: "1.2.3-beta..1", wantErr: true},
+		{version: "1.2.3+", wantErr: true},
+		{version: "1.2.3-beta_1", wantErr: true},
+		{version: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			v, err := parseNuGetVersion(tt.version)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %s", v)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if v.String() != tt.normalized {
+				t.Errorf("expected %s, got %s", tt.normalized, v)
+			}
+			if v.IsSemVer2() != tt.semver2 {
+				t.Errorf("expected IsSemVer2() = %v", tt.semver2)
+			}
+		})
+	}
+}
+
+func TestComparableVersion(t *testing.T) {
+	tests := []struct {
+		a, b string
+	}{
+		{a: "v1.2.3", b: "1.2.3"},
+		{a: "1.2.3.0", b: "1.2.3"},
+		{a: "01.2.3", b: "1.2.3"},
+		{a: "1.2.3-Beta.1", b: "1.2.3-beta.1"},
+		{a: "1.2.3+sha.abc", b: "1.2.3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a, func(t *testing.T) {
+			if comparableVersion(tt.a) != comparableVersion(tt.b) {
+				t.Errorf("expected %s and %s to compare equal, got %s and %s", tt.a, tt.b, comparableVersion(tt.a), comparableVersion(tt.b))
+			}
+		})
+	}
+}
+
+func TestVersionWarnings(t *testing.T) {
+	tests := []struct {
+		version string
+		want    []string
+	}{
+		{version: "1.2.3"},
+		{version: "v1.2.3-beta"},
+		{version: "1.02.3", want: []string{"appears on the feed as 1.2.3"}},
+		{version: "1.2.3.0", want: []string{"appears on the feed as 1.2.3"}},
+		{version: "1.2.3-beta.3", want: []string{"SemVer 2.0.0 version, hidden from NuGet clients older than 4.3.0"}},
+		{version: "1.2.3+sha.abc", want: []string{"build metadata +sha.abc", "SemVer 2.0.0"}},
+		{version: "1.2.3.4.5", want: []string{"more than 4 numeric parts"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			got := versionWarnings(tt.version)
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %d warning(s), got %v", len(tt.want), got)
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(got[i], want) {
+					t.Errorf("expected warning %d to contain %q, got %q", i, want, got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestNuGetVersionCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "1.0.0", b: "1.0.0+build.5", want: 0},
+		{a: "1.0.0", b: "1.0.0.0", want: 0},
+		{a: "1.0.1", b: "1.0.0.9", want: 1},
+		{a: "1.0.0-beta", b: "1.0.0", want: -1},
+		{a: "1.0.0-beta.2", b: "1.0.0-beta.10", want: -1},
+		{a: "1.0.0-1", b: "1.0.0-alpha", want: -1},
+		{a: "1.0.0-RC", b: "1.0.0-rc", want: 0},
+		{a: "1.0.0-rc.1", b: "1.0.0-rc", want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" vs "+tt.b, func(t *testing.T) {
+			a, _ := parseNuGetVersion(tt.a)
+			b, _ := parseNuGetVersion(tt.b)
+			if got := a.Compare(b); got != tt.want {
+				t.Errorf("Compare() = %d, want %d", got, tt.want)
+			}
+			if got := b.Compare(a); got != -tt.want {
+				t.Errorf("reverse Compare() = %d, want %d", got, -tt.want)
+			}
+		})
+	}
+}